@@ -0,0 +1,19 @@
+// Command monkey-lsp is a Language Server Protocol server for Monkey.
+// It talks to the editor over stdin and stdout.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/jsonrpc"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lsp"
+)
+
+func main() {
+	s := lsp.NewServer(jsonrpc.NewConn(os.Stdin, os.Stdout))
+	if err := s.Serve(); err != nil {
+		fmt.Fprintf(os.Stderr, "monkey-lsp: %v\n", err)
+		os.Exit(1)
+	}
+}
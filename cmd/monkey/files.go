@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lexer"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/parser"
+)
+
+// sourceExtension is the extension of the files processed when walking a directory.
+const sourceExtension = ".monkey"
+
+// sourceFiles returns the files to process for the given paths.
+// Files are always included, directories are walked looking for Monkey files.
+func sourceFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || (path != root && filepath.Ext(path) != sourceExtension) {
+				return nil
+			}
+			files = append(files, path)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// parseSource parses a program, prefixing any error with the name of its source.
+func parseSource(name string, r io.Reader) (*ast.Root, error) {
+	l := lexer.New(lexer.NewRunePeeker(bufio.NewReader(r)))
+	root, err := parser.New(l).Parse()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return root, nil
+}
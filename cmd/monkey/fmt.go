@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/diff"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/format"
+)
+
+type fmtOptions struct {
+	list, write, diff bool
+}
+
+func runFmt(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: monkey fmt [flags] [path ...]")
+		flags.PrintDefaults()
+	}
+	opts := fmtOptions{}
+	flags.BoolVar(&opts.list, "l", false, "list files whose formatting differs from monkey fmt's")
+	flags.BoolVar(&opts.write, "w", false, "write result to (source) file instead of stdout")
+	flags.BoolVar(&opts.diff, "d", false, "display diffs instead of rewriting files")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		if opts.write {
+			fmt.Fprintln(stderr, "monkey fmt: cannot use -w with standard input")
+			return 2
+		}
+		if err := formatFile("<standard input>", stdin, stdout, opts); err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			return 2
+		}
+		return 0
+	}
+
+	files, err := sourceFiles(flags.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 2
+	}
+
+	exitCode := 0
+	for _, path := range files {
+		if err := formatPath(path, stdout, opts); err != nil {
+			// keep going with the rest of the files
+			fmt.Fprintf(stderr, "%v\n", err)
+			exitCode = 2
+		}
+	}
+
+	return exitCode
+}
+
+func formatPath(path string, out io.Writer, opts fmtOptions) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return formatFile(path, f, out, opts)
+}
+
+// formatFile formats the content of a file. Files with parse errors are never rewritten.
+func formatFile(name string, in io.Reader, out io.Writer, opts fmtOptions) error {
+	src, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+
+	res, err := format.Source(src)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	if bytes.Equal(src, res) {
+		if !opts.list && !opts.write && !opts.diff {
+			_, err = out.Write(res)
+		}
+		return err
+	}
+
+	if opts.list {
+		fmt.Fprintln(out, name)
+	}
+	if opts.write {
+		info, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, res, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if opts.diff {
+		_, err = out.Write(diff.Unified(name+".orig", name, src, res))
+	}
+	if !opts.list && !opts.write && !opts.diff {
+		_, err = out.Write(res)
+	}
+
+	return err
+}
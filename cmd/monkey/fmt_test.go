@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const (
+	unformatted = "let x=1+2\n"
+	formatted   = "let x = 1 + 2;\n"
+)
+
+func writeFiles(t *testing.T, g *WithT, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		g.Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+		g.Expect(os.WriteFile(path, []byte(content), 0o644)).To(Succeed())
+	}
+	return dir
+}
+
+func TestRunFmtStdin(t *testing.T) {
+	g := NewWithT(t)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := runFmt(nil, strings.NewReader(unformatted), stdout, stderr)
+	g.Expect(code).To(Equal(0))
+	g.Expect(stdout.String()).To(Equal(formatted))
+	g.Expect(stderr.String()).To(BeEmpty())
+}
+
+func TestRunFmtList(t *testing.T) {
+	g := NewWithT(t)
+	dir := writeFiles(t, g, map[string]string{
+		"a.monkey":        unformatted,
+		"b.monkey":        formatted,
+		"nested/c.monkey": unformatted,
+		"notes.txt":       unformatted,
+	})
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := runFmt([]string{"-l", dir}, nil, stdout, stderr)
+	g.Expect(code).To(Equal(0))
+	g.Expect(stdout.String()).To(Equal(
+		filepath.Join(dir, "a.monkey") + "\n" + filepath.Join(dir, "nested/c.monkey") + "\n",
+	))
+}
+
+func TestRunFmtWrite(t *testing.T) {
+	g := NewWithT(t)
+	dir := writeFiles(t, g, map[string]string{
+		"a.monkey":       unformatted,
+		"invalid.monkey": "let = 1;",
+	})
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := runFmt([]string{"-w", dir}, nil, stdout, stderr)
+	g.Expect(code).To(Equal(2))
+	g.Expect(stderr.String()).To(ContainSubstring("invalid.monkey: parsing source"))
+
+	content, err := os.ReadFile(filepath.Join(dir, "a.monkey"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(Equal(formatted))
+
+	content, err = os.ReadFile(filepath.Join(dir, "invalid.monkey"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(Equal("let = 1;"), "files with parse errors must not be rewritten")
+}
+
+func TestRunFmtDiff(t *testing.T) {
+	g := NewWithT(t)
+	dir := writeFiles(t, g, map[string]string{"a.monkey": unformatted})
+	path := filepath.Join(dir, "a.monkey")
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := runFmt([]string{"-d", path}, nil, stdout, stderr)
+	g.Expect(code).To(Equal(0))
+	g.Expect(stdout.String()).To(Equal(`--- ` + path + `.orig
++++ ` + path + `
+@@ -1,1 +1,1 @@
+-let x=1+2
++let x = 1 + 2;
+`))
+
+	content, err := os.ReadFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(Equal(unformatted))
+}
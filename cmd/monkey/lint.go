@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lint"
+)
+
+// severityFlag parses rule=severity pairs into the linter configuration.
+type severityFlag map[string]lint.Severity
+
+func (f severityFlag) String() string {
+	pairs := make([]string, 0, len(f))
+	for rule, s := range f {
+		pairs = append(pairs, rule+"="+s.String())
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f severityFlag) Set(value string) error {
+	for _, pair := range strings.Split(value, ",") {
+		rule, name, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("invalid rule severity %q, expected rule=severity", pair)
+		}
+		s, err := lint.ParseSeverity(name)
+		if err != nil {
+			return err
+		}
+		f[rule] = s
+	}
+	return nil
+}
+
+// lintResult is the JSON representation of a diagnostic.
+type lintResult struct {
+	File     string        `json:"file"`
+	Line     int           `json:"line"`
+	Column   int           `json:"column"`
+	Severity lint.Severity `json:"severity"`
+	Rule     string        `json:"rule"`
+	Message  string        `json:"message"`
+}
+
+func runLint(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	registry := lint.DefaultRegistry()
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: monkey lint [flags] [path ...]")
+		flags.PrintDefaults()
+		fmt.Fprintln(stderr, "Rules:")
+		for _, r := range registry.Rules() {
+			fmt.Fprintf(stderr, "\t%s (%s): %s\n", r.Name(), r.DefaultSeverity(), r.Description())
+		}
+	}
+	output := flags.String("format", "text", "output format, text or json")
+	severities := severityFlag{}
+	flags.Var(severities, "severity", "comma separated list of rule=severity to override the default severities (off, info, warning, error)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *output != "text" && *output != "json" {
+		fmt.Fprintf(stderr, "monkey lint: invalid format %q\n", *output)
+		return 2
+	}
+	for rule := range severities {
+		if registry.Get(rule) == nil {
+			fmt.Fprintf(stderr, "monkey lint: unknown rule %q\n", rule)
+			return 2
+		}
+	}
+
+	linter := lint.New(registry, lint.Config{Severities: severities})
+	results := []lintResult{}
+	exitCode := 0
+	lintSource := func(name string, r io.Reader) {
+		root, err := parseSource(name, r)
+		if err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			exitCode = 2
+			return
+		}
+
+		for _, d := range linter.Lint(root) {
+			results = append(results, lintResult{
+				File:     name,
+				Line:     d.Pos.Line,
+				Column:   d.Pos.Column,
+				Severity: d.Severity,
+				Rule:     d.Rule,
+				Message:  d.Message,
+			})
+			if d.Severity == lint.SeverityError && exitCode == 0 {
+				exitCode = 1
+			}
+		}
+	}
+
+	if flags.NArg() == 0 {
+		lintSource("<standard input>", stdin)
+	} else {
+		files, err := sourceFiles(flags.Args())
+		if err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			return 2
+		}
+		for _, path := range files {
+			f, err := os.Open(path)
+			if err != nil {
+				fmt.Fprintf(stderr, "%v\n", err)
+				exitCode = 2
+				continue
+			}
+			lintSource(path, f)
+			f.Close()
+		}
+	}
+
+	if *output == "json" {
+		e := json.NewEncoder(stdout)
+		e.SetIndent("", "  ")
+		if err := e.Encode(results); err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			return 2
+		}
+		return exitCode
+	}
+
+	for _, r := range results {
+		fmt.Fprintf(stdout, "%s:%d:%d: %s: %s (%s)\n", r.File, r.Line, r.Column, r.Severity, r.Message, r.Rule)
+	}
+	return exitCode
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const linted = `let x = 1 / 0;
+let y = 2;
+x == x;
+`
+
+func TestRunLintText(t *testing.T) {
+	g := NewWithT(t)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := runLint(nil, strings.NewReader(linted), stdout, stderr)
+	g.Expect(code).To(Equal(1))
+	g.Expect(stdout.String()).To(Equal(`<standard input>:1:11: error: division by zero (division-by-zero)
+<standard input>:2:5: warning: y is declared but never used (unused-let)
+<standard input>:3:3: warning: both operands of == are the same expression (self-comparison)
+`))
+	g.Expect(stderr.String()).To(BeEmpty())
+}
+
+func TestRunLintJSON(t *testing.T) {
+	g := NewWithT(t)
+	dir := writeFiles(t, g, map[string]string{"a.monkey": linted})
+	path := filepath.Join(dir, "a.monkey")
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := runLint([]string{
+		"-format", "json",
+		"-severity", "division-by-zero=warning,unused-let=off",
+		"-severity", "self-comparison=info",
+		dir,
+	}, nil, stdout, stderr)
+	g.Expect(code).To(Equal(0))
+	g.Expect(stdout.String()).To(MatchJSON(`[
+		{"file": "` + path + `", "line": 1, "column": 11, "severity": "warning", "rule": "division-by-zero", "message": "division by zero"},
+		{"file": "` + path + `", "line": 3, "column": 3, "severity": "info", "rule": "self-comparison", "message": "both operands of == are the same expression"}
+	]`))
+}
+
+func TestRunLintErrors(t *testing.T) {
+	g := NewWithT(t)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	g.Expect(runLint([]string{"-severity", "nope=error"}, strings.NewReader(""), stdout, stderr)).To(Equal(2))
+	g.Expect(stderr.String()).To(ContainSubstring(`unknown rule "nope"`))
+
+	stderr.Reset()
+	g.Expect(runLint(nil, strings.NewReader("let = 1;"), stdout, stderr)).To(Equal(2))
+	g.Expect(stderr.String()).To(ContainSubstring("<standard input>: invalid program"))
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/dump"
+)
+
+type parseOptions struct {
+	json, dot, sexp bool
+}
+
+// count returns the number of output formats selected.
+func (o parseOptions) count() int {
+	n := 0
+	for _, set := range []bool{o.json, o.dot, o.sexp} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+func runParse(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("parse", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: monkey parse [flags] [path ...]")
+		fmt.Fprintln(stderr, "Without flags, parse only reports syntax errors.")
+		flags.PrintDefaults()
+	}
+	var opts parseOptions
+	flags.BoolVar(&opts.json, "json", false, "print the syntax tree of each program as JSON")
+	flags.BoolVar(&opts.dot, "dot", false, "print the syntax tree of each program as a Graphviz DOT graph")
+	flags.BoolVar(&opts.sexp, "sexp", false, "print the syntax tree of each program as an S-expression")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if opts.count() > 1 {
+		fmt.Fprintln(stderr, "monkey parse: only one of -json, -dot and -sexp can be used")
+		return 2
+	}
+
+	exitCode := 0
+	parseFile := func(name string, r io.Reader) {
+		root, err := parseSource(name, r)
+		if err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			exitCode = 2
+			return
+		}
+		if err := printTree(stdout, root, opts); err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			exitCode = 2
+		}
+	}
+
+	if flags.NArg() == 0 {
+		parseFile("<standard input>", stdin)
+		return exitCode
+	}
+
+	files, err := sourceFiles(flags.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 2
+	}
+	for _, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			exitCode = 2
+			continue
+		}
+		parseFile(path, f)
+		f.Close()
+	}
+
+	return exitCode
+}
+
+// printTree writes the syntax tree of a program in the requested format.
+// Each tree is a separate JSON document or DOT graph.
+func printTree(w io.Writer, root *ast.Root, opts parseOptions) error {
+	switch {
+	case opts.json:
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(root)
+	case opts.dot:
+		return dump.Dot(w, root)
+	case opts.sexp:
+		return dump.SExpr(w, root)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+)
+
+func TestRunParseJSON(t *testing.T) {
+	g := NewWithT(t)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := runParse([]string{"--json"}, strings.NewReader("x"), stdout, stderr)
+	g.Expect(code).To(Equal(0))
+	g.Expect(stderr.String()).To(BeEmpty())
+	g.Expect(stdout.String()).To(MatchJSON(`{
+		"type": "Root",
+		"statements": [{
+			"type": "ExpressionStatement",
+			"token": {"type": "IDENT", "literal": "x", "pos": {"offset": 0, "line": 1, "column": 1}},
+			"expression": {
+				"type": "Identifier",
+				"token": {"type": "IDENT", "literal": "x", "pos": {"offset": 0, "line": 1, "column": 1}},
+				"value": "x"
+			}
+		}],
+		"comments": null
+	}`))
+}
+
+func TestRunParseFiles(t *testing.T) {
+	g := NewWithT(t)
+	dir := writeFiles(t, g, map[string]string{
+		"a.monkey":       "let a = 1;",
+		"b.monkey":       "let b = fn(x) { x };",
+		"invalid.monkey": "let = 1;",
+	})
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := runParse([]string{"-json", dir}, nil, stdout, stderr)
+	g.Expect(code).To(Equal(2))
+	g.Expect(stderr.String()).To(ContainSubstring(filepath.Join(dir, "invalid.monkey") + ": invalid program"))
+
+	var names []string
+	d := json.NewDecoder(stdout)
+	for d.More() {
+		root := &ast.Root{}
+		g.Expect(d.Decode(root)).To(Succeed())
+		names = append(names, root.Statements[0].(*ast.Let).Name.Value)
+	}
+	g.Expect(names).To(Equal([]string{"a", "b"}))
+}
+
+func TestRunParseCheck(t *testing.T) {
+	g := NewWithT(t)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	g.Expect(runParse(nil, strings.NewReader("let a = 1;"), stdout, stderr)).To(Equal(0))
+	g.Expect(stdout.String()).To(BeEmpty())
+	g.Expect(stderr.String()).To(BeEmpty())
+}
+
+func TestRunParseSExpr(t *testing.T) {
+	g := NewWithT(t)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := runParse([]string{"-sexp"}, strings.NewReader("-a * b + c"), stdout, stderr)
+	g.Expect(code).To(Equal(0))
+	g.Expect(stdout.String()).To(Equal("(program (expr (+ (* (- a) b) c)))\n"))
+}
+
+func TestRunParseDot(t *testing.T) {
+	g := NewWithT(t)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := runParse([]string{"-dot"}, strings.NewReader("a"), stdout, stderr)
+	g.Expect(code).To(Equal(0))
+	g.Expect(stdout.String()).To(HavePrefix("digraph ast {\n"))
+	g.Expect(stdout.String()).To(ContainSubstring(`n2 [label="a\n1:1"];`))
+}
+
+func TestRunParseFormats(t *testing.T) {
+	g := NewWithT(t)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := runParse([]string{"-dot", "-json"}, strings.NewReader("a"), stdout, stderr)
+	g.Expect(code).To(Equal(2))
+	g.Expect(stderr.String()).To(ContainSubstring("only one of -json, -dot and -sexp"))
+}
@@ -0,0 +1,33 @@
+package ast
+
+import "github.com/g-gaston/monkey-go-interpreter/pkg/token"
+
+type AssignOperator string
+
+const (
+	PlainAssign    AssignOperator = "="
+	AddAssign      AssignOperator = "+="
+	SubtractAssign AssignOperator = "-="
+	MultiplyAssign AssignOperator = "*="
+	DivideAssign   AssignOperator = "/="
+)
+
+var _ Expression = &Assign{}
+
+// Assign changes the value of a variable or of an element of an array
+// or a hash, like `x = 1` or `xs[0] += 2`. Its value is the assigned one.
+// The target is an *Identifier, or an *Index or a *Property that isn't optional.
+type Assign struct {
+	Token    token.Token
+	Operator AssignOperator
+	Target   Expression
+	Value    Expression
+}
+
+func (a *Assign) TokenLiteral() string {
+	return a.Token.Literal
+}
+
+func (a *Assign) Pos() token.Position {
+	return a.Token.Pos
+}
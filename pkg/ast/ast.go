@@ -1,9 +1,15 @@
 package ast
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+)
 
 type Node interface {
 	TokenLiteral() string
+	// Pos returns the position of the token the node was built from.
+	Pos() token.Position
 }
 
 type Statement interface {
@@ -19,6 +25,8 @@ var _ Node = &Root{}
 // Root is the beginning of the AST. It represents the whole program.
 type Root struct {
 	Statements []Statement
+	// Comments holds all the comments in the program in source order.
+	Comments []*Comment
 }
 
 func (r *Root) TokenLiteral() string {
@@ -30,3 +38,11 @@ func (r *Root) TokenLiteral() string {
 
 	return strings.Join(literals, " ")
 }
+
+func (r *Root) Pos() token.Position {
+	if len(r.Statements) == 0 {
+		return token.Position{}
+	}
+
+	return r.Statements[0].Pos()
+}
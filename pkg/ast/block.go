@@ -0,0 +1,21 @@
+package ast
+
+import "github.com/g-gaston/monkey-go-interpreter/pkg/token"
+
+var _ Statement = &Block{}
+
+// Block is a list of statements enclosed in braces.
+type Block struct {
+	Token      token.Token
+	Statements []Statement
+	// End is the position of the closing brace.
+	End token.Position
+}
+
+func (b *Block) TokenLiteral() string {
+	return b.Token.Literal
+}
+
+func (b *Block) Pos() token.Position {
+	return b.Token.Pos
+}
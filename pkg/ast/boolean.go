@@ -0,0 +1,18 @@
+package ast
+
+import "github.com/g-gaston/monkey-go-interpreter/pkg/token"
+
+var _ Expression = &Boolean{}
+
+type Boolean struct {
+	Token token.Token
+	Value bool
+}
+
+func (b *Boolean) TokenLiteral() string {
+	return b.Token.Literal
+}
+
+func (b *Boolean) Pos() token.Position {
+	return b.Token.Pos
+}
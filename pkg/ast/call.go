@@ -0,0 +1,24 @@
+package ast
+
+import "github.com/g-gaston/monkey-go-interpreter/pkg/token"
+
+var _ Expression = &Call{}
+
+type Call struct {
+	Token     token.Token
+	Function  Expression
+	Arguments []Expression
+	// Optional is set for `f?.()`, which is null if f is null, together
+	// with the accesses that follow it in the chain, like in `f?.()[0]`.
+	Optional bool
+	// End is the position of the closing parenthesis.
+	End token.Position
+}
+
+func (c *Call) TokenLiteral() string {
+	return c.Token.Literal
+}
+
+func (c *Call) Pos() token.Position {
+	return c.Token.Pos
+}
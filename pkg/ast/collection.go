@@ -0,0 +1,96 @@
+package ast
+
+import "github.com/g-gaston/monkey-go-interpreter/pkg/token"
+
+var (
+	_ Expression = &ArrayLiteral{}
+	_ Expression = &HashLiteral{}
+	_ Node       = &HashPair{}
+	_ Expression = &Index{}
+	_ Expression = &Property{}
+)
+
+// ArrayLiteral is a list of expressions in brackets, like `[1, 2, 3]`.
+type ArrayLiteral struct {
+	Token    token.Token
+	Elements []Expression
+	// End is the position of the closing bracket.
+	End token.Position
+}
+
+func (a *ArrayLiteral) TokenLiteral() string {
+	return a.Token.Literal
+}
+
+func (a *ArrayLiteral) Pos() token.Position {
+	return a.Token.Pos
+}
+
+// HashLiteral is a list of key value pairs in braces, like `{"a": 1}`.
+type HashLiteral struct {
+	Token token.Token
+	Pairs []*HashPair
+	// End is the position of the closing brace.
+	End token.Position
+}
+
+func (h *HashLiteral) TokenLiteral() string {
+	return h.Token.Literal
+}
+
+func (h *HashLiteral) Pos() token.Position {
+	return h.Token.Pos
+}
+
+// HashPair is a key and its value in a HashLiteral.
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+func (p *HashPair) TokenLiteral() string {
+	return p.Key.TokenLiteral()
+}
+
+func (p *HashPair) Pos() token.Position {
+	return p.Key.Pos()
+}
+
+// Index is an access to an element of an array or a hash, like `xs[0]`.
+// Its token is the opening bracket.
+type Index struct {
+	Token token.Token
+	Left  Expression
+	Index Expression
+	// Optional is set for `xs?[0]`, which is null if xs is null, together
+	// with the accesses that follow it in the chain, like in `xs?[0].name`.
+	Optional bool
+}
+
+func (i *Index) TokenLiteral() string {
+	return i.Token.Literal
+}
+
+func (i *Index) Pos() token.Position {
+	return i.Token.Pos
+}
+
+// Property is an access to the value of a string key in a hash by
+// its name, like `person.name` for `person["name"]`.
+// Its token is the dot.
+type Property struct {
+	Token token.Token
+	Left  Expression
+	Name  *Identifier
+	// Optional is set for `person?.name`, which is null if person is null,
+	// together with the accesses that follow it, like in `person?.name.first`.
+	Optional bool
+}
+
+func (p *Property) TokenLiteral() string {
+	return p.Token.Literal
+}
+
+func (p *Property) Pos() token.Position {
+	return p.Token.Pos
+}
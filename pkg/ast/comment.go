@@ -0,0 +1,19 @@
+package ast
+
+import "github.com/g-gaston/monkey-go-interpreter/pkg/token"
+
+var _ Node = &Comment{}
+
+// Comment is a line comment. Comments are not part of the statements,
+// they are kept in the Root so tools like the formatter can print them back.
+type Comment struct {
+	Token token.Token
+}
+
+func (c *Comment) TokenLiteral() string {
+	return c.Token.Literal
+}
+
+func (c *Comment) Pos() token.Position {
+	return c.Token.Pos
+}
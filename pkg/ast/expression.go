@@ -13,3 +13,7 @@ var _ Statement = &ExpressionStatement{}
 func (c *ExpressionStatement) TokenLiteral() string {
 	return c.Token.Literal
 }
+
+func (c *ExpressionStatement) Pos() token.Position {
+	return c.Token.Pos
+}
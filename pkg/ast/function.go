@@ -0,0 +1,37 @@
+package ast
+
+import "github.com/g-gaston/monkey-go-interpreter/pkg/token"
+
+var _ Expression = &FunctionLiteral{}
+
+type FunctionLiteral struct {
+	Token      token.Token
+	Parameters []*Parameter
+	// ReturnType is nil if the function doesn't declare it.
+	ReturnType TypeExpression
+	Body       *Block
+}
+
+func (f *FunctionLiteral) TokenLiteral() string {
+	return f.Token.Literal
+}
+
+func (f *FunctionLiteral) Pos() token.Position {
+	return f.Token.Pos
+}
+
+var _ Node = &Parameter{}
+
+type Parameter struct {
+	Name *Identifier
+	// Type is nil if the parameter is not annotated.
+	Type TypeExpression `json:"annotation"`
+}
+
+func (p *Parameter) TokenLiteral() string {
+	return p.Name.TokenLiteral()
+}
+
+func (p *Parameter) Pos() token.Position {
+	return p.Name.Pos()
+}
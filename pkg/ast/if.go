@@ -0,0 +1,21 @@
+package ast
+
+import "github.com/g-gaston/monkey-go-interpreter/pkg/token"
+
+var _ Expression = &If{}
+
+type If struct {
+	Token       token.Token
+	Condition   Expression
+	Consequence *Block
+	// Alternative is nil if the if doesn't have an else.
+	Alternative *Block
+}
+
+func (i *If) TokenLiteral() string {
+	return i.Token.Literal
+}
+
+func (i *If) Pos() token.Position {
+	return i.Token.Pos
+}
@@ -12,3 +12,7 @@ type Identifier struct {
 func (i *Identifier) TokenLiteral() string {
 	return i.Token.Literal
 }
+
+func (i *Identifier) Pos() token.Position {
+	return i.Token.Pos
+}
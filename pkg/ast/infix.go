@@ -13,6 +13,8 @@ const (
 	LessThan       InfixOperator = "<"
 	Equal          InfixOperator = "=="
 	NotEqual       InfixOperator = "!="
+	// NullCoalesce is the right operand if the left one is null, or the left one otherwise.
+	NullCoalesce InfixOperator = "??"
 )
 
 type Infix struct {
@@ -27,3 +29,7 @@ var _ Expression = &Infix{}
 func (i *Infix) TokenLiteral() string {
 	return i.Token.Literal
 }
+
+func (i *Infix) Pos() token.Position {
+	return i.Token.Pos
+}
@@ -0,0 +1,296 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// nodeTypes are the nodes that can be decoded from JSON, by the name
+// used in their "type" field.
+var nodeTypes = map[string]reflect.Type{}
+
+func init() {
+	for _, n := range []Node{
+		&Root{},
+		&Comment{},
+		&Block{},
+		&ExpressionStatement{},
+		&Let{},
+		&Return{},
+		&Import{},
+		&Export{},
+		&While{},
+		&For{},
+		&Break{},
+		&Continue{},
+		&Throw{},
+		&Try{},
+		&Identifier{},
+		&Literal{},
+		&StringLiteral{},
+		&TemplateLiteral{},
+		&TemplateText{},
+		&Boolean{},
+		&Null{},
+		&Prefix{},
+		&Infix{},
+		&If{},
+		&FunctionLiteral{},
+		&Parameter{},
+		&Call{},
+		&ArrayLiteral{},
+		&HashLiteral{},
+		&HashPair{},
+		&Index{},
+		&Property{},
+		&Assign{},
+		&Match{},
+		&MatchArm{},
+		&WildcardPattern{},
+		&BindingPattern{},
+		&LiteralPattern{},
+		&ArrayPattern{},
+		&HashPattern{},
+		&HashPatternPair{},
+		&RestPattern{},
+		&DefaultPattern{},
+		&NamedType{},
+		&ArrayType{},
+		&HashType{},
+		&FunctionType{},
+	} {
+		t := reflect.TypeOf(n).Elem()
+		for i := 0; i < t.NumField(); i++ {
+			if fieldKey(t.Field(i)) == "type" {
+				panic("ast: field " + t.Name() + "." + t.Field(i).Name + " clashes with the JSON node type")
+			}
+		}
+		nodeTypes[t.Name()] = t
+	}
+}
+
+var nodeType = reflect.TypeOf((*Node)(nil)).Elem()
+
+// optionalFields are the node fields, by type and field name, that can be
+// nil. The other node fields, and the elements of node slices, are required.
+var optionalFields = map[string]bool{
+	"Let.Name":                   true,
+	"Let.Pattern":                true,
+	"Let.Type":                   true,
+	"Return.Value":               true,
+	"If.Alternative":             true,
+	"FunctionLiteral.ReturnType": true,
+	"Parameter.Type":             true,
+	"MatchArm.Guard":             true,
+	"HashPatternPair.Key":        true,
+	"Try.Param":                  true,
+	"Try.Catch":                  true,
+	"Try.Finally":                true,
+}
+
+// MarshalJSON encodes the AST as JSON. Each node is an object with a
+// "type" field naming its kind, like "Let" or "Infix", followed by its
+// fields in lower camel case, including tokens and positions. The type
+// annotations of lets and parameters are in their "annotation" field.
+// Missing nodes, like the Alternative of an If without else, are null.
+// Decoding fails if a node that can't be missing is null or not there.
+func (r *Root) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := encodeNode(buf, reflect.ValueOf(r)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes an AST encoded by MarshalJSON.
+func (r *Root) UnmarshalJSON(b []byte) error {
+	v, err := decodeNode(b, reflect.TypeOf(r))
+	if err != nil {
+		return err
+	}
+	if v.IsNil() {
+		return errors.New("root can't be null")
+	}
+	*r = *v.Interface().(*Root)
+	return nil
+}
+
+// encodeNode writes a node, which must be a pointer to one of the nodeTypes.
+func encodeNode(buf *bytes.Buffer, v reflect.Value) error {
+	if v.IsNil() {
+		buf.WriteString("null")
+		return nil
+	}
+
+	s := v.Elem()
+	if _, ok := nodeTypes[s.Type().Name()]; !ok {
+		return errors.Errorf("unsupported node type %s", v.Type())
+	}
+
+	buf.WriteString(`{"type":`)
+	name, _ := json.Marshal(s.Type().Name())
+	buf.Write(name)
+	for i := 0; i < s.NumField(); i++ {
+		key, _ := json.Marshal(fieldKey(s.Type().Field(i)))
+		buf.WriteByte(',')
+		buf.Write(key)
+		buf.WriteByte(':')
+		if err := encodeValue(buf, s.Field(i)); err != nil {
+			return errors.Wrapf(err, "%s.%s", s.Type().Name(), s.Type().Field(i).Name)
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func encodeValue(buf *bytes.Buffer, v reflect.Value) error {
+	switch {
+	case isNodeType(v.Type()):
+		if v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				buf.WriteString("null")
+				return nil
+			}
+			v = v.Elem()
+		}
+		return encodeNode(buf, v)
+	case v.Kind() == reflect.Slice && isNodeType(v.Type().Elem()):
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeValue(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	}
+
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+	return nil
+}
+
+// decodeNode decodes a node into a value of type t, which can be a node
+// interface or a pointer to one of the nodeTypes.
+func decodeNode(b []byte, t reflect.Type) (reflect.Value, error) {
+	if string(bytes.TrimSpace(b)) == "null" {
+		return reflect.Zero(t), nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return reflect.Value{}, err
+	}
+	var name string
+	if err := json.Unmarshal(fields["type"], &name); err != nil {
+		return reflect.Value{}, errors.Wrap(err, "reading node type")
+	}
+	st, ok := nodeTypes[name]
+	if !ok {
+		return reflect.Value{}, errors.Errorf("unknown node type %q", name)
+	}
+	if !reflect.PointerTo(st).AssignableTo(t) {
+		return reflect.Value{}, errors.Errorf("node type %s can't be used as %s", name, t)
+	}
+
+	n := reflect.New(st)
+	s := n.Elem()
+	for i := 0; i < s.NumField(); i++ {
+		f := st.Field(i)
+		if raw, ok := fields[fieldKey(f)]; ok {
+			if err := decodeValue(raw, s.Field(i)); err != nil {
+				return reflect.Value{}, errors.Wrapf(err, "%s.%s", name, f.Name)
+			}
+		}
+		if isNodeType(f.Type) && s.Field(i).IsNil() && !optionalFields[name+"."+f.Name] {
+			return reflect.Value{}, errors.Errorf("%s.%s: missing node", name, f.Name)
+		}
+	}
+	if err := checkNode(n.Interface().(Node)); err != nil {
+		return reflect.Value{}, err
+	}
+
+	return n, nil
+}
+
+// checkNode checks the optional fields of a node that depend on each other.
+func checkNode(n Node) error {
+	switch n := n.(type) {
+	case *Let:
+		if (n.Name == nil) == (n.Pattern == nil) {
+			return errors.New("Let: needs either a name or a pattern")
+		}
+	case *Try:
+		if n.Catch == nil && n.Finally == nil {
+			return errors.New("Try: needs a catch or a finally block")
+		}
+		if (n.Param == nil) != (n.Catch == nil) {
+			return errors.New("Try: needs a parameter with the catch block, and only then")
+		}
+	}
+	return nil
+}
+
+func decodeValue(b []byte, v reflect.Value) error {
+	switch {
+	case isNodeType(v.Type()):
+		n, err := decodeNode(b, v.Type())
+		if err != nil {
+			return err
+		}
+		v.Set(n)
+		return nil
+	case v.Kind() == reflect.Slice && isNodeType(v.Type().Elem()):
+		var elems []json.RawMessage
+		if err := json.Unmarshal(b, &elems); err != nil {
+			return err
+		}
+		if elems == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		s := reflect.MakeSlice(v.Type(), len(elems), len(elems))
+		for i, e := range elems {
+			if err := decodeValue(e, s.Index(i)); err != nil {
+				return errors.Wrapf(err, "index %d", i)
+			}
+			if s.Index(i).IsNil() {
+				return errors.Errorf("index %d: missing node", i)
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+
+	return json.Unmarshal(b, v.Addr().Interface())
+}
+
+// isNodeType reports whether t is a node interface or a pointer to a node.
+func isNodeType(t reflect.Type) bool {
+	return t.Implements(nodeType) && (t.Kind() == reflect.Interface || t.Kind() == reflect.Pointer)
+}
+
+// fieldKey returns the JSON key of a node field: its json tag if it has
+// one, or its name in lower camel case.
+func fieldKey(f reflect.StructField) string {
+	if key := f.Tag.Get("json"); key != "" {
+		return key
+	}
+	r, size := utf8.DecodeRuneInString(f.Name)
+	return strings.ToLower(string(r)) + f.Name[size:]
+}
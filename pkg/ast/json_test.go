@@ -0,0 +1,164 @@
+package ast_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lexer"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/parser"
+)
+
+func parse(t *testing.T, input string) *ast.Root {
+	root, err := parser.New(lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)).Parse()
+	assert.Nil(t, err)
+	return root
+}
+
+func TestRootJSON(t *testing.T) {
+	root := parse(t, `-x + 1`)
+
+	b, err := json.Marshal(root)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"type": "Root",
+		"statements": [{
+			"type": "ExpressionStatement",
+			"token": {"type": "-", "literal": "-", "pos": {"offset": 0, "line": 1, "column": 1}},
+			"expression": {
+				"type": "Infix",
+				"token": {"type": "+", "literal": "+", "pos": {"offset": 3, "line": 1, "column": 4}},
+				"operator": "+",
+				"right": {
+					"type": "Literal",
+					"token": {"type": "INT", "literal": "1", "pos": {"offset": 5, "line": 1, "column": 6}},
+					"value": 1
+				},
+				"left": {
+					"type": "Prefix",
+					"token": {"type": "-", "literal": "-", "pos": {"offset": 0, "line": 1, "column": 1}},
+					"operator": "-",
+					"right": {
+						"type": "Identifier",
+						"token": {"type": "IDENT", "literal": "x", "pos": {"offset": 1, "line": 1, "column": 2}},
+						"value": "x"
+					}
+				}
+			}
+		}],
+		"comments": null
+	}`, string(b))
+}
+
+func TestRootJSONRoundTrip(t *testing.T) {
+	inputs := []string{
+		``,
+		`// only a comment`,
+		`let add = fn(a: int, b: int): int { return a + b; }; // add
+add(1, 2 * 3);`,
+		`let f: fn([int], {string: bool}): bool = g;`,
+		`if (!(x < 1)) { true } else { false == x }; if (x) {}`,
+		`let noop = fn() {}; noop()`,
+		`import "a/b"; export let greeting = "hi \"there\"";`,
+		`while (x) { if (y) { break; } continue; } for (i in range(3)) { i }`,
+		`let h = {"a": [1, 2], 3: {}}; h["a"][0] += 1; x = h[3] = [];`,
+		`a?.b.c?[0] ?? f?.(null);`,
+		`try { throw "x"; } catch (e) { e } finally { 1 } try {} finally {}`,
+		`match (x) { [a, _] if a => a, {b, "c": -1} => b, null => 0 } match (y) {}`,
+		`let [a = 1, ...rest] = xs; let {name, "k": [b] = []}: {string: [int]} = h;`,
+		"`a ${b + 1}\n${`c`}\\`` + ``;",
+	}
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			root := parse(t, input)
+
+			b, err := json.Marshal(root)
+			assert.Nil(t, err)
+
+			got := &ast.Root{}
+			assert.Nil(t, json.Unmarshal(b, got))
+			assert.Equal(t, root, got)
+		})
+	}
+}
+
+func TestRootUnmarshalJSONErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "unknown node type",
+			input: `{"type": "Root", "statements": [{"type": "Loop"}]}`,
+			want:  `Root.Statements: index 0: unknown node type "Loop"`,
+		},
+		{
+			name:  "node of the wrong kind",
+			input: `{"type": "Let", "name": {"type": "Literal"}}`,
+			want:  `node type Let can't be used as *ast.Root`,
+		},
+		{
+			name:  "typed field",
+			input: `{"type": "Root", "statements": [{"type": "Let", "name": {"type": "Literal"}}]}`,
+			want:  `Root.Statements: index 0: Let.Name: node type Literal can't be used as *ast.Identifier`,
+		},
+		{
+			name:  "null root",
+			input: `null`,
+			want:  `root can't be null`,
+		},
+		{
+			name:  "null statement",
+			input: `{"type": "Root", "statements": [null]}`,
+			want:  `Root.Statements: index 0: missing node`,
+		},
+		{
+			name:  "missing operand",
+			input: `{"type": "Root", "statements": [{"type": "ExpressionStatement", "expression": {"type": "Infix", "right": {"type": "Null"}}}]}`,
+			want:  `Root.Statements: index 0: ExpressionStatement.Expression: Infix.Left: missing node`,
+		},
+		{
+			name:  "infix without operands",
+			input: `{"type": "Root", "statements": [{"type": "Infix"}]}`,
+			want:  `Root.Statements: index 0: Infix.Right: missing node`,
+		},
+		{
+			name:  "null required field",
+			input: `{"type": "Root", "statements": [{"type": "Throw", "value": null}]}`,
+			want:  `Root.Statements: index 0: Throw.Value: missing node`,
+		},
+		{
+			name:  "null argument",
+			input: `{"type": "Root", "statements": [{"type": "ExpressionStatement", "expression": {"type": "Call", "function": {"type": "Identifier"}, "arguments": [null]}}]}`,
+			want:  `Root.Statements: index 0: ExpressionStatement.Expression: Call.Arguments: index 0: missing node`,
+		},
+		{
+			name:  "let without name or pattern",
+			input: `{"type": "Root", "statements": [{"type": "Let", "value": {"type": "Null"}}]}`,
+			want:  `Root.Statements: index 0: Let: needs either a name or a pattern`,
+		},
+		{
+			name:  "try without catch or finally",
+			input: `{"type": "Root", "statements": [{"type": "Try", "body": {"type": "Block"}}]}`,
+			want:  `Root.Statements: index 0: Try: needs a catch or a finally block`,
+		},
+		{
+			name:  "catch without parameter",
+			input: `{"type": "Root", "statements": [{"type": "Try", "body": {"type": "Block"}, "catch": {"type": "Block"}}]}`,
+			want:  `Root.Statements: index 0: Try: needs a parameter with the catch block, and only then`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := json.Unmarshal([]byte(tt.input), &ast.Root{})
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
@@ -4,12 +4,30 @@ import "github.com/g-gaston/monkey-go-interpreter/pkg/token"
 
 var _ Statement = &Let{}
 
+// Let binds the value of an expression to Name or, when it destructures
+// the value like `let [a, b] = xs;`, to the names in Pattern.
+// Only one of Name and Pattern is set.
 type Let struct {
-	Token token.Token
-	Name  *Identifier
+	Token   token.Token
+	Name    *Identifier
+	Pattern Pattern
+	// Type is nil if the binding is not annotated.
+	Type  TypeExpression `json:"annotation"`
 	Value Expression
 }
 
 func (l *Let) TokenLiteral() string {
 	return l.Token.Literal
 }
+
+func (l *Let) Pos() token.Position {
+	return l.Token.Pos
+}
+
+// Names returns the names declared by the let statement.
+func (l *Let) Names() []*Identifier {
+	if l.Pattern != nil {
+		return Bindings(l.Pattern)
+	}
+	return []*Identifier{l.Name}
+}
@@ -12,3 +12,7 @@ type Literal struct {
 func (i *Literal) TokenLiteral() string {
 	return i.Token.Literal
 }
+
+func (i *Literal) Pos() token.Position {
+	return i.Token.Pos
+}
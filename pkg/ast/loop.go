@@ -0,0 +1,68 @@
+package ast
+
+import "github.com/g-gaston/monkey-go-interpreter/pkg/token"
+
+var (
+	_ Statement = &While{}
+	_ Statement = &For{}
+	_ Statement = &Break{}
+	_ Statement = &Continue{}
+)
+
+// While runs its body as long as the condition is truthy.
+type While struct {
+	Token     token.Token
+	Condition Expression
+	Body      *Block
+}
+
+func (w *While) TokenLiteral() string {
+	return w.Token.Literal
+}
+
+func (w *While) Pos() token.Position {
+	return w.Token.Pos
+}
+
+// For runs its body once for each element of an iterable,
+// like `for (x in xs) { ... }`.
+type For struct {
+	Token    token.Token
+	Variable *Identifier
+	Iterable Expression
+	Body     *Block
+}
+
+func (f *For) TokenLiteral() string {
+	return f.Token.Literal
+}
+
+func (f *For) Pos() token.Position {
+	return f.Token.Pos
+}
+
+// Break stops the innermost loop.
+type Break struct {
+	Token token.Token
+}
+
+func (b *Break) TokenLiteral() string {
+	return b.Token.Literal
+}
+
+func (b *Break) Pos() token.Position {
+	return b.Token.Pos
+}
+
+// Continue skips to the next iteration of the innermost loop.
+type Continue struct {
+	Token token.Token
+}
+
+func (c *Continue) TokenLiteral() string {
+	return c.Token.Literal
+}
+
+func (c *Continue) Pos() token.Position {
+	return c.Token.Pos
+}
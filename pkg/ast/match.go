@@ -0,0 +1,42 @@
+package ast
+
+import "github.com/g-gaston/monkey-go-interpreter/pkg/token"
+
+var (
+	_ Expression = &Match{}
+	_ Node       = &MatchArm{}
+)
+
+// Match evaluates to the body of the first arm whose pattern matches
+// the subject and whose guard, if any, is true.
+type Match struct {
+	Token   token.Token
+	Subject Expression
+	Arms    []*MatchArm
+	// End is the position of the closing brace.
+	End token.Position
+}
+
+func (m *Match) TokenLiteral() string {
+	return m.Token.Literal
+}
+
+func (m *Match) Pos() token.Position {
+	return m.Token.Pos
+}
+
+// MatchArm is a case of a Match, like `[x, y] if x > y => x`.
+// Guard is nil if the arm doesn't have one.
+type MatchArm struct {
+	Pattern Pattern
+	Guard   Expression
+	Body    Expression
+}
+
+func (a *MatchArm) TokenLiteral() string {
+	return a.Pattern.TokenLiteral()
+}
+
+func (a *MatchArm) Pos() token.Position {
+	return a.Pattern.Pos()
+}
@@ -0,0 +1,38 @@
+package ast
+
+import "github.com/g-gaston/monkey-go-interpreter/pkg/token"
+
+var (
+	_ Statement = &Import{}
+	_ Statement = &Export{}
+)
+
+// Import brings the names exported by another module into the program,
+// like `import "path/to/mod";`.
+type Import struct {
+	Token token.Token
+	Path  *StringLiteral
+}
+
+func (i *Import) TokenLiteral() string {
+	return i.Token.Literal
+}
+
+func (i *Import) Pos() token.Position {
+	return i.Token.Pos
+}
+
+// Export makes the binding of a let available to the modules that import
+// the program, like `export let x = 1;`.
+type Export struct {
+	Token token.Token
+	Let   *Let
+}
+
+func (e *Export) TokenLiteral() string {
+	return e.Token.Literal
+}
+
+func (e *Export) Pos() token.Position {
+	return e.Token.Pos
+}
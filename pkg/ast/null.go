@@ -0,0 +1,18 @@
+package ast
+
+import "github.com/g-gaston/monkey-go-interpreter/pkg/token"
+
+var _ Expression = &Null{}
+
+// Null is the null keyword, the value of the absence of a value.
+type Null struct {
+	Token token.Token
+}
+
+func (n *Null) TokenLiteral() string {
+	return n.Token.Literal
+}
+
+func (n *Null) Pos() token.Position {
+	return n.Token.Pos
+}
@@ -0,0 +1,202 @@
+package ast
+
+import "github.com/g-gaston/monkey-go-interpreter/pkg/token"
+
+var (
+	_ Pattern = &WildcardPattern{}
+	_ Pattern = &BindingPattern{}
+	_ Pattern = &LiteralPattern{}
+	_ Pattern = &ArrayPattern{}
+	_ Pattern = &HashPattern{}
+	_ Node    = &HashPatternPair{}
+	_ Pattern = &RestPattern{}
+	_ Pattern = &DefaultPattern{}
+)
+
+// Pattern describes the shape of a value, binding the parts of it that
+// are matched by names.
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+// WildcardPattern is `_`, which matches any value without binding it.
+type WildcardPattern struct {
+	Token token.Token
+}
+
+func (w *WildcardPattern) TokenLiteral() string {
+	return w.Token.Literal
+}
+
+func (w *WildcardPattern) Pos() token.Position {
+	return w.Token.Pos
+}
+
+func (w *WildcardPattern) patternNode() {}
+
+// BindingPattern matches any value, binding it to Name.
+type BindingPattern struct {
+	Name *Identifier
+}
+
+func (b *BindingPattern) TokenLiteral() string {
+	return b.Name.TokenLiteral()
+}
+
+func (b *BindingPattern) Pos() token.Position {
+	return b.Name.Pos()
+}
+
+func (b *BindingPattern) patternNode() {}
+
+// LiteralPattern matches the values equal to a literal: an integer,
+// optionally negated, a string, a boolean or null.
+type LiteralPattern struct {
+	Value Expression
+}
+
+func (l *LiteralPattern) TokenLiteral() string {
+	return l.Value.TokenLiteral()
+}
+
+func (l *LiteralPattern) Pos() token.Position {
+	return l.Value.Pos()
+}
+
+func (l *LiteralPattern) patternNode() {}
+
+// ArrayPattern matches the arrays with as many elements as patterns,
+// each one matching the element in the same position, like `[x, 0]`.
+// The last element can be a RestPattern, which takes any elements left,
+// and the elements with a DefaultPattern can be missing.
+type ArrayPattern struct {
+	Token    token.Token
+	Elements []Pattern
+}
+
+func (a *ArrayPattern) TokenLiteral() string {
+	return a.Token.Literal
+}
+
+func (a *ArrayPattern) Pos() token.Position {
+	return a.Token.Pos
+}
+
+func (a *ArrayPattern) patternNode() {}
+
+// HashPattern matches the hashes that have all the keys of its pairs,
+// with values matching their patterns, like `{"name": n, age}`.
+// Other keys in the hash are ignored.
+type HashPattern struct {
+	Token token.Token
+	Pairs []*HashPatternPair
+}
+
+func (h *HashPattern) TokenLiteral() string {
+	return h.Token.Literal
+}
+
+func (h *HashPattern) Pos() token.Position {
+	return h.Token.Pos
+}
+
+func (h *HashPattern) patternNode() {}
+
+// HashPatternPair is a key and the pattern for its value in a HashPattern.
+// The key is a literal or an Identifier, which stands for the string
+// with its name. Key is nil in the shorthand `{age}` or `{age = 1}`,
+// where the key is the name bound by Value, a BindingPattern
+// or a DefaultPattern of a BindingPattern.
+type HashPatternPair struct {
+	Key   Expression
+	Value Pattern
+}
+
+func (p *HashPatternPair) TokenLiteral() string {
+	if p.Key == nil {
+		return p.Value.TokenLiteral()
+	}
+	return p.Key.TokenLiteral()
+}
+
+func (p *HashPatternPair) Pos() token.Position {
+	if p.Key == nil {
+		return p.Value.Pos()
+	}
+	return p.Key.Pos()
+}
+
+// KeyName returns the name of the key of a shorthand pair,
+// or nil if the pair has a key.
+func (p *HashPatternPair) KeyName() *Identifier {
+	if p.Key != nil {
+		return nil
+	}
+	value := p.Value
+	if d, ok := value.(*DefaultPattern); ok {
+		value = d.Pattern
+	}
+	if b, ok := value.(*BindingPattern); ok {
+		return b.Name
+	}
+	return nil
+}
+
+// RestPattern is `...name` at the end of an ArrayPattern, which binds
+// the elements not taken by the other patterns to name as an array.
+type RestPattern struct {
+	Token token.Token
+	Name  *Identifier
+}
+
+func (r *RestPattern) TokenLiteral() string {
+	return r.Token.Literal
+}
+
+func (r *RestPattern) Pos() token.Position {
+	return r.Token.Pos
+}
+
+func (r *RestPattern) patternNode() {}
+
+// DefaultPattern is a pattern with a value for when the element of an
+// array or the key of a hash it matches is missing, like `x = 0`.
+// Its token is the =.
+type DefaultPattern struct {
+	Token   token.Token
+	Pattern Pattern
+	Default Expression
+}
+
+func (d *DefaultPattern) TokenLiteral() string {
+	return d.Token.Literal
+}
+
+func (d *DefaultPattern) Pos() token.Position {
+	return d.Pattern.Pos()
+}
+
+func (d *DefaultPattern) patternNode() {}
+
+// Bindings returns the names bound by a pattern in source order.
+func Bindings(p Pattern) []*Identifier {
+	var names []*Identifier
+	switch p := p.(type) {
+	case *BindingPattern:
+		names = append(names, p.Name)
+	case *RestPattern:
+		names = append(names, p.Name)
+	case *DefaultPattern:
+		names = append(names, Bindings(p.Pattern)...)
+	case *ArrayPattern:
+		for _, e := range p.Elements {
+			names = append(names, Bindings(e)...)
+		}
+	case *HashPattern:
+		for _, pair := range p.Pairs {
+			names = append(names, Bindings(pair.Value)...)
+		}
+	}
+	return names
+}
@@ -20,3 +20,7 @@ type Prefix struct {
 func (i *Prefix) TokenLiteral() string {
 	return i.Token.Literal
 }
+
+func (i *Prefix) Pos() token.Position {
+	return i.Token.Pos
+}
@@ -6,9 +6,14 @@ var _ Statement = &Return{}
 
 type Return struct {
 	Token token.Token
+	// Value is nil in `return;`.
 	Value Expression
 }
 
 func (l *Return) TokenLiteral() string {
 	return l.Token.Literal
 }
+
+func (l *Return) Pos() token.Position {
+	return l.Token.Pos
+}
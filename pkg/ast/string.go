@@ -0,0 +1,20 @@
+package ast
+
+import "github.com/g-gaston/monkey-go-interpreter/pkg/token"
+
+var _ Expression = &StringLiteral{}
+
+// StringLiteral is a double quoted string. The token keeps the literal
+// as written in the source, with its quotes and escape sequences.
+type StringLiteral struct {
+	Token token.Token
+	Value string
+}
+
+func (s *StringLiteral) TokenLiteral() string {
+	return s.Token.Literal
+}
+
+func (s *StringLiteral) Pos() token.Position {
+	return s.Token.Pos
+}
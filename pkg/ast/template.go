@@ -0,0 +1,41 @@
+package ast
+
+import "github.com/g-gaston/monkey-go-interpreter/pkg/token"
+
+var (
+	_ Expression = &TemplateLiteral{}
+	_ Expression = &TemplateText{}
+)
+
+// TemplateLiteral is a backtick string with interpolated expressions,
+// like `x is ${x}`. Parts has the texts and expressions in source order,
+// without empty texts.
+type TemplateLiteral struct {
+	Token token.Token
+	Parts []Expression
+	// End is the position of the closing backtick.
+	End token.Position
+}
+
+func (t *TemplateLiteral) TokenLiteral() string {
+	return t.Token.Literal
+}
+
+func (t *TemplateLiteral) Pos() token.Position {
+	return t.Token.Pos
+}
+
+// TemplateText is a text part of a TemplateLiteral. The token keeps the
+// text as written in the source, with its escape sequences.
+type TemplateText struct {
+	Token token.Token
+	Value string
+}
+
+func (t *TemplateText) TokenLiteral() string {
+	return t.Token.Literal
+}
+
+func (t *TemplateText) Pos() token.Position {
+	return t.Token.Pos
+}
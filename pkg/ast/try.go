@@ -0,0 +1,42 @@
+package ast
+
+import "github.com/g-gaston/monkey-go-interpreter/pkg/token"
+
+var (
+	_ Statement = &Throw{}
+	_ Statement = &Try{}
+)
+
+// Throw raises a value as an error, which stops the program
+// unless a try statement catches it.
+type Throw struct {
+	Token token.Token
+	Value Expression
+}
+
+func (t *Throw) TokenLiteral() string {
+	return t.Token.Literal
+}
+
+func (t *Throw) Pos() token.Position {
+	return t.Token.Pos
+}
+
+// Try runs its body and, if it fails, the Catch block with the error
+// bound to Param. The Finally block runs after them in any case.
+// Either Catch or Finally can be nil, but not both.
+type Try struct {
+	Token   token.Token
+	Body    *Block
+	Param   *Identifier
+	Catch   *Block
+	Finally *Block
+}
+
+func (t *Try) TokenLiteral() string {
+	return t.Token.Literal
+}
+
+func (t *Try) Pos() token.Position {
+	return t.Token.Pos
+}
@@ -0,0 +1,82 @@
+package ast
+
+import "github.com/g-gaston/monkey-go-interpreter/pkg/token"
+
+// TypeExpression is an optional type annotation, like the one in `let x: int = 5;`.
+type TypeExpression interface {
+	Node
+	typeExpression()
+}
+
+var (
+	_ TypeExpression = &NamedType{}
+	_ TypeExpression = &ArrayType{}
+	_ TypeExpression = &HashType{}
+	_ TypeExpression = &FunctionType{}
+)
+
+// NamedType is a type referenced by its name, like int or string.
+type NamedType struct {
+	Token token.Token
+	Name  string
+}
+
+func (n *NamedType) TokenLiteral() string {
+	return n.Token.Literal
+}
+
+func (n *NamedType) Pos() token.Position {
+	return n.Token.Pos
+}
+
+func (n *NamedType) typeExpression() {}
+
+// ArrayType is the type of an array, like [int].
+type ArrayType struct {
+	Token   token.Token
+	Element TypeExpression
+}
+
+func (a *ArrayType) TokenLiteral() string {
+	return a.Token.Literal
+}
+
+func (a *ArrayType) Pos() token.Position {
+	return a.Token.Pos
+}
+
+func (a *ArrayType) typeExpression() {}
+
+// HashType is the type of a hash, like {string: int}.
+type HashType struct {
+	Token token.Token
+	Key   TypeExpression
+	Value TypeExpression
+}
+
+func (h *HashType) TokenLiteral() string {
+	return h.Token.Literal
+}
+
+func (h *HashType) Pos() token.Position {
+	return h.Token.Pos
+}
+
+func (h *HashType) typeExpression() {}
+
+// FunctionType is the type of a function, like fn(int, string): bool.
+type FunctionType struct {
+	Token      token.Token
+	Parameters []TypeExpression
+	Return     TypeExpression
+}
+
+func (f *FunctionType) TokenLiteral() string {
+	return f.Token.Literal
+}
+
+func (f *FunctionType) Pos() token.Position {
+	return f.Token.Pos
+}
+
+func (f *FunctionType) typeExpression() {}
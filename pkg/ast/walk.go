@@ -0,0 +1,161 @@
+package ast
+
+import "reflect"
+
+// Inspect traverses the AST in depth-first order, starting with node.
+// It calls f for each node; if f returns false, the children of that
+// node are not visited.
+func Inspect(node Node, f func(Node) bool) {
+	if isNil(node) || !f(node) {
+		return
+	}
+
+	for _, c := range Children(node) {
+		Inspect(c, f)
+	}
+}
+
+// End returns the offset right after the last token of a node.
+func End(n Node) int {
+	e := 0
+	Inspect(n, func(c Node) bool {
+		if b, ok := c.(*Block); ok && b.End.Offset+1 > e {
+			e = b.End.Offset + 1
+		}
+		if t, ok := c.(*TemplateLiteral); ok && t.End.Offset+1 > e {
+			e = t.End.Offset + 1
+		}
+		if end := c.Pos().Offset + len(c.TokenLiteral()); end > e {
+			e = end
+		}
+		return true
+	})
+	return e
+}
+
+// Children returns the direct children of a node in source order,
+// skipping the ones that are nil.
+func Children(node Node) []Node {
+	var c []Node
+	add := func(nodes ...Node) {
+		for _, n := range nodes {
+			if !isNil(n) {
+				c = append(c, n)
+			}
+		}
+	}
+
+	switch n := node.(type) {
+	case *Root:
+		for _, s := range n.Statements {
+			add(s)
+		}
+	case *Block:
+		for _, s := range n.Statements {
+			add(s)
+		}
+	case *ExpressionStatement:
+		add(n.Expression)
+	case *Let:
+		add(n.Name, n.Pattern, n.Type, n.Value)
+	case *Return:
+		add(n.Value)
+	case *Import:
+		add(n.Path)
+	case *Export:
+		add(n.Let)
+	case *While:
+		add(n.Condition, n.Body)
+	case *For:
+		add(n.Variable, n.Iterable, n.Body)
+	case *Throw:
+		add(n.Value)
+	case *Try:
+		add(n.Body, n.Param, n.Catch, n.Finally)
+	case *TemplateLiteral:
+		for _, part := range n.Parts {
+			add(part)
+		}
+	case *Prefix:
+		add(n.Right)
+	case *Infix:
+		add(n.Left, n.Right)
+	case *If:
+		add(n.Condition, n.Consequence, n.Alternative)
+	case *FunctionLiteral:
+		for _, p := range n.Parameters {
+			add(p)
+		}
+		add(n.ReturnType, n.Body)
+	case *Parameter:
+		add(n.Name, n.Type)
+	case *Call:
+		add(n.Function)
+		for _, a := range n.Arguments {
+			add(a)
+		}
+	case *ArrayLiteral:
+		for _, e := range n.Elements {
+			add(e)
+		}
+	case *HashLiteral:
+		for _, pair := range n.Pairs {
+			add(pair)
+		}
+	case *HashPair:
+		add(n.Key, n.Value)
+	case *Index:
+		add(n.Left, n.Index)
+	case *Property:
+		add(n.Left, n.Name)
+	case *Assign:
+		add(n.Target, n.Value)
+	case *Match:
+		add(n.Subject)
+		for _, a := range n.Arms {
+			add(a)
+		}
+	case *MatchArm:
+		add(n.Pattern, n.Guard, n.Body)
+	case *BindingPattern:
+		add(n.Name)
+	case *LiteralPattern:
+		add(n.Value)
+	case *ArrayPattern:
+		for _, e := range n.Elements {
+			add(e)
+		}
+	case *HashPattern:
+		for _, pair := range n.Pairs {
+			add(pair)
+		}
+	case *HashPatternPair:
+		add(n.Key, n.Value)
+	case *RestPattern:
+		add(n.Name)
+	case *DefaultPattern:
+		add(n.Pattern, n.Default)
+	case *ArrayType:
+		add(n.Element)
+	case *HashType:
+		add(n.Key, n.Value)
+	case *FunctionType:
+		for _, p := range n.Parameters {
+			add(p)
+		}
+		add(n.Return)
+	}
+
+	return c
+}
+
+// isNil reports whether a node is nil, including typed nil pointers
+// stored in the Node interface, like an If without Alternative.
+func isNil(node Node) bool {
+	if node == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(node)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
@@ -0,0 +1,48 @@
+package ast_test
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lexer"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/parser"
+)
+
+func TestInspect(t *testing.T) {
+	input := `let f = fn(a: int) { if (a > 1) { -a } };
+f(2);`
+	root, err := parser.New(lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)).Parse()
+	assert.Nil(t, err)
+
+	var got []string
+	ast.Inspect(root, func(n ast.Node) bool {
+		got = append(got, fmt.Sprintf("%T %s", n, n.TokenLiteral()))
+		// skip the if's condition and branches
+		_, isIf := n.(*ast.If)
+		return !isIf
+	})
+
+	assert.Equal(t, []string{
+		"*ast.Root let f",
+		"*ast.Let let",
+		"*ast.Identifier f",
+		"*ast.FunctionLiteral fn",
+		"*ast.Parameter a",
+		"*ast.Identifier a",
+		"*ast.NamedType int",
+		"*ast.Block {",
+		"*ast.ExpressionStatement if",
+		"*ast.If if",
+		"*ast.ExpressionStatement f",
+		"*ast.Call (",
+		"*ast.Identifier f",
+		"*ast.Literal 2",
+	}, got)
+}
@@ -0,0 +1,109 @@
+// Package cst defines a concrete syntax tree for Monkey programs.
+//
+// Unlike the AST, the CST keeps every token of the source, including
+// punctuation, together with the whitespace and comments around them,
+// so printing it reproduces the source exactly. Each node points to the
+// AST node it was parsed into, which gives the conversion to the AST.
+package cst
+
+import (
+	"bytes"
+	"io"
+	"iter"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+)
+
+// Element is a child of a Node, either a *Node or a *Token.
+type Element interface {
+	element()
+}
+
+var (
+	_ Element = &Node{}
+	_ Element = &Token{}
+)
+
+// Token is a token with the trivia around it.
+type Token struct {
+	token.Token
+	Leading  []token.Trivia
+	Trailing []token.Trivia
+}
+
+func (t *Token) element() {}
+
+// Node is a syntax node with the tokens and nodes it's made of, in source order.
+type Node struct {
+	// Syntax is the AST node for this node. It's nil for nodes that failed
+	// to parse. Parenthesized expressions get a node of their own, with the
+	// same Syntax as the expression inside the parentheses.
+	Syntax   ast.Node
+	Children []Element
+}
+
+func (n *Node) element() {}
+
+// Tokens returns an iterator over all the tokens under the node, in source order.
+func (n *Node) Tokens() iter.Seq[*Token] {
+	return func(yield func(*Token) bool) {
+		n.walkTokens(yield)
+	}
+}
+
+func (n *Node) walkTokens(yield func(*Token) bool) bool {
+	for _, c := range n.Children {
+		switch c := c.(type) {
+		case *Token:
+			if !yield(c) {
+				return false
+			}
+		case *Node:
+			if !c.walkTokens(yield) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Root returns the AST of a node built by parsing a whole program.
+// It returns nil for any other node.
+func (n *Node) Root() *ast.Root {
+	r, _ := n.Syntax.(*ast.Root)
+	return r
+}
+
+// Print writes the source text of a node, with all its tokens and trivia.
+func Print(w io.Writer, n *Node) error {
+	for t := range n.Tokens() {
+		if err := printTrivia(w, t.Leading); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, t.Literal); err != nil {
+			return err
+		}
+		if err := printTrivia(w, t.Trailing); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printTrivia(w io.Writer, trivia []token.Trivia) error {
+	for _, t := range trivia {
+		if _, err := io.WriteString(w, t.Text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String returns the source text of a node.
+func (n *Node) String() string {
+	b := &bytes.Buffer{}
+	// writing to a bytes.Buffer never fails
+	_ = Print(b, n)
+	return b.String()
+}
@@ -0,0 +1,188 @@
+package cst_test
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/cst"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lexer"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/parser"
+)
+
+func parseCST(input string) (*cst.Node, error) {
+	l := lexer.New(lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))))
+	l.Trivia = true
+	return parser.New(l).ParseCST()
+}
+
+func parseAST(input string) (*ast.Root, error) {
+	l := lexer.New(lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))))
+	return parser.New(l).Parse()
+}
+
+func TestPrintReproducesSource(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "empty",
+			input: ``,
+		},
+		{
+			name:  "only trivia",
+			input: "  // nothing here  \n\n",
+		},
+		{
+			name: "program with comments",
+			input: `// leading comment
+let add = fn(a: int,b : int)  :int {   a+b }; // trailing comment	
+let  x=add( 1 ,(2) )
+
+  // dangling comment
+if(x>2){x}else{ -x } `,
+		},
+		{
+			name:  "types",
+			input: "let f : fn( [int] , {string:bool} ):int = g;",
+		},
+		{
+			name:  "modules",
+			input: "import  \"lib/math\" ;\nexport   let x = \"a \\\" // b\";",
+		},
+		{
+			name:  "collections and assignments",
+			input: "let h = { \"a\" : [ 1,2 ] , 3:{} };\nh[\"a\"] [0]  += 1 ; x /=2",
+		},
+		{
+			name:  "optional chaining",
+			input: "a ?. b .c?[ 0 ]  ?? f?.( null )",
+		},
+		{
+			name:  "exceptions",
+			input: "try{ throw  \"x\" } catch ( e ) {e}\nfinally { } ;",
+		},
+		{
+			name:  "match",
+			input: "match( x ){ [a,_ ] if a=> a , { b, \"c\" :-1 }=>b,\n null =>0 ,}",
+		},
+		{
+			name:  "destructuring",
+			input: "let [ a=1 ,... rest]= xs;let{ name , \"k\":[b] = [] } =h",
+		},
+		{
+			name:  "templates",
+			input: "`a ${ b + 1 }\n ${` ${c}`}\\${ d }` + `` // `x`",
+		},
+		{
+			name:  "division next to a comment",
+			input: "a / b // comment\n/ c",
+		},
+		{
+			name:  "windows newlines and unicode",
+			input: "let é = 1;\r\n// ça\r\né\r\n",
+		},
+		{
+			name:  "syntax errors",
+			input: "let = 5; fn(x { x } ) + ; let y = 1",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			tree, _ := parseCST(tc.input)
+			g.Expect(tree.String()).To(Equal(tc.input))
+		})
+	}
+}
+
+func TestRootMatchesParse(t *testing.T) {
+	g := NewWithT(t)
+	input := "// doc\nlet f = fn(x) { x * (2 + x) }; // trailing\nf(1)\n"
+
+	tree, err := parseCST(input)
+	g.Expect(err).NotTo(HaveOccurred())
+	want, err := parseAST(input)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tree.Root()).To(Equal(want))
+}
+
+func TestTreeStructure(t *testing.T) {
+	g := NewWithT(t)
+	tree, err := parseCST("let x: [int] = (a + b) * f(c);")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(dump(tree, "")).To(Equal(`*ast.Root
+  *ast.Let
+    "let"
+    *ast.Identifier
+      "x"
+    ":"
+    *ast.ArrayType
+      "["
+      *ast.NamedType
+        "int"
+      "]"
+    "="
+    *ast.Infix
+      *ast.Infix
+        "("
+        *ast.Infix
+          *ast.Identifier
+            "a"
+          "+"
+          *ast.Identifier
+            "b"
+        ")"
+      "*"
+      *ast.Call
+        *ast.Identifier
+          "f"
+        "("
+        *ast.Identifier
+          "c"
+        ")"
+    ";"
+  ""
+`))
+}
+
+// dump prints the tree with a line per node or token.
+func dump(n *cst.Node, indent string) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%s%T\n", indent, n.Syntax)
+	for _, c := range n.Children {
+		switch c := c.(type) {
+		case *cst.Node:
+			b.WriteString(dump(c, indent+"  "))
+		case *cst.Token:
+			fmt.Fprintf(b, "%s  %q\n", indent, c.Literal)
+		}
+	}
+	return b.String()
+}
+
+func FuzzPrintReproducesSource(f *testing.F) {
+	f.Add("let x = 5; // five\nx")
+	f.Add("fn(a: [int]) { if (a) { 1 } else { -2 } }(3) / 4 // c")
+	f.Add("let = ; ) ( {")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		if !utf8.ValidString(input) {
+			t.Skip("invalid UTF-8 is not kept by the lexer")
+		}
+
+		// programs with syntax errors are kept too, but parsing stops at limits
+		tree, err := parseCST(input)
+		if got := tree.String(); got != input && !errors.Is(err, parser.ErrDepthLimit) {
+			t.Fatalf("printed %q, want %q", got, input)
+		}
+	})
+}
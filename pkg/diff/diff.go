@@ -0,0 +1,168 @@
+// Package diff computes line based differences between two texts.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change.
+const context = 3
+
+type operation int
+
+const (
+	equal operation = iota
+	deletion
+	insertion
+)
+
+type edit struct {
+	op   operation
+	line string
+}
+
+// Unified returns the differences between old and new in unified diff format,
+// or nil if they are equal.
+func Unified(oldName, newName string, old, new []byte) []byte {
+	if bytes.Equal(old, new) {
+		return nil
+	}
+
+	edits := lineEdits(splitLines(old), splitLines(new))
+
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "--- %s\n+++ %s\n", oldName, newName)
+
+	// oldLine and newLine are the 0-based line numbers of edits[i] in each text
+	oldLine, newLine := 0, 0
+	for i := 0; i < len(edits); {
+		if edits[i].op == equal {
+			i++
+			oldLine++
+			newLine++
+			continue
+		}
+
+		// a hunk starts some context lines before the first change and
+		// includes the following changes until there is a gap of equal
+		// lines too big to be covered by the context of both sides
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].op == equal {
+				continue
+			}
+			if j-end > 2*context {
+				break
+			}
+			end = j + 1
+		}
+		end += context
+		if end > len(edits) {
+			end = len(edits)
+		}
+
+		hunkOld, hunkNew := oldLine-(i-start), newLine-(i-start)
+		var oldCount, newCount int
+		body := &strings.Builder{}
+		for _, e := range edits[start:end] {
+			switch e.op {
+			case equal:
+				oldCount++
+				newCount++
+				body.WriteString(" " + e.line)
+			case deletion:
+				oldCount++
+				body.WriteString("-" + e.line)
+			case insertion:
+				newCount++
+				body.WriteString("+" + e.line)
+			}
+			if !strings.HasSuffix(e.line, "\n") {
+				body.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		fmt.Fprintf(b, "@@ -%s +%s @@\n%s", hunkRange(hunkOld, oldCount), hunkRange(hunkNew, newCount), body)
+
+		for _, e := range edits[i:end] {
+			if e.op != insertion {
+				oldLine++
+			}
+			if e.op != deletion {
+				newLine++
+			}
+		}
+		i = end
+	}
+
+	return b.Bytes()
+}
+
+func hunkRange(line, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", line)
+	}
+	return fmt.Sprintf("%d,%d", line+1, count)
+}
+
+func splitLines(b []byte) []string {
+	if len(b) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(b), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineEdits computes the shortest list of edits that transforms a into b
+// using the longest common subsequence of their lines.
+func lineEdits(a, b []string) []edit {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var edits []edit
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			edits = append(edits, edit{op: equal, line: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			edits = append(edits, edit{op: deletion, line: a[i]})
+			i++
+		default:
+			edits = append(edits, edit{op: insertion, line: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		edits = append(edits, edit{op: deletion, line: a[i]})
+	}
+	for ; j < len(b); j++ {
+		edits = append(edits, edit{op: insertion, line: b[j]})
+	}
+
+	return edits
+}
@@ -0,0 +1,167 @@
+// Package dump renders syntax trees to inspect how a program was parsed.
+package dump
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+)
+
+// lineWidth is the width up to which SExpr keeps a list in a single line.
+const lineWidth = 60
+
+// SExpr writes a tree as an indented S-expression. Nodes without children
+// are atoms and the rest are lists headed by their label, like (+ a b).
+// Lists that don't fit in a line are split with a child per line, except
+// for the atoms at their start, which stay next to the label.
+func SExpr(w io.Writer, node ast.Node) error {
+	b := bufio.NewWriter(w)
+	writeSExpr(b, node, 0)
+	b.WriteByte('\n')
+	return b.Flush()
+}
+
+// writeSExpr writes a node that starts at the given nesting depth,
+// indented by two spaces per level.
+func writeSExpr(b *bufio.Writer, node ast.Node, depth int) {
+	flat := flatSExpr(node)
+	children := ast.Children(node)
+	if len(children) == 0 || 2*depth+len(flat) <= lineWidth {
+		b.WriteString(flat)
+		return
+	}
+
+	b.WriteString("(" + label(node))
+	for len(children) > 0 && len(ast.Children(children[0])) == 0 {
+		b.WriteString(" " + label(children[0]))
+		children = children[1:]
+	}
+	for _, c := range children {
+		b.WriteString("\n" + strings.Repeat("  ", depth+1))
+		writeSExpr(b, c, depth+1)
+	}
+	b.WriteByte(')')
+}
+
+func flatSExpr(node ast.Node) string {
+	children := ast.Children(node)
+	if len(children) == 0 {
+		return label(node)
+	}
+
+	parts := []string{label(node)}
+	for _, c := range children {
+		parts = append(parts, flatSExpr(c))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+// Dot writes a tree as a Graphviz DOT graph, with a box per node labeled
+// with its kind and position. Children are drawn from left to right in
+// source order.
+func Dot(w io.Writer, node ast.Node) error {
+	b := bufio.NewWriter(w)
+	b.WriteString("digraph ast {\n")
+	b.WriteString("\tordering=out;\n")
+	b.WriteString("\tnode [shape=box];\n")
+	ids := 0
+	var write func(n ast.Node) int
+	write = func(n ast.Node) int {
+		id := ids
+		ids++
+		text := label(n)
+		if n.Pos().IsValid() {
+			text += "\n" + n.Pos().String()
+		}
+		fmt.Fprintf(b, "\tn%d [label=%s];\n", id, dotQuote(text))
+		for _, c := range ast.Children(n) {
+			fmt.Fprintf(b, "\tn%d -> n%d;\n", id, write(c))
+		}
+		return id
+	}
+	write(node)
+	b.WriteString("}\n")
+	return b.Flush()
+}
+
+// dotQuote quotes a string as a DOT identifier.
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// label returns the text that identifies a node in a dump: the operator
+// or value for expressions and the kind of node for the rest.
+func label(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.Root:
+		return "program"
+	case *ast.ExpressionStatement:
+		return "expr"
+	case *ast.Identifier:
+		return n.Value
+	case *ast.Literal:
+		return strconv.FormatInt(n.Value, 10)
+	case *ast.Boolean:
+		return strconv.FormatBool(n.Value)
+	case *ast.StringLiteral:
+		return n.Token.Literal
+	case *ast.TemplateLiteral:
+		return "template"
+	case *ast.TemplateText:
+		return strconv.Quote(n.Value)
+	case *ast.Prefix:
+		return string(n.Operator)
+	case *ast.Infix:
+		return string(n.Operator)
+	case *ast.Assign:
+		return string(n.Operator)
+	case *ast.Property:
+		return n.Token.Literal
+	case *ast.FunctionLiteral:
+		return "fn"
+	case *ast.ArrayLiteral:
+		return "array"
+	case *ast.HashLiteral:
+		return "hash"
+	case *ast.HashPair:
+		return "pair"
+	case *ast.Parameter:
+		return "param"
+	case *ast.MatchArm:
+		return "arm"
+	case *ast.WildcardPattern:
+		return "_"
+	case *ast.BindingPattern:
+		return "bind"
+	case *ast.LiteralPattern:
+		return "literal"
+	case *ast.ArrayPattern:
+		return "array-pattern"
+	case *ast.HashPattern:
+		return "hash-pattern"
+	case *ast.HashPatternPair:
+		return "pair"
+	case *ast.RestPattern:
+		return "rest"
+	case *ast.DefaultPattern:
+		return "default"
+	case *ast.NamedType:
+		return n.Name
+	case *ast.ArrayType:
+		return "array-type"
+	case *ast.HashType:
+		return "hash-type"
+	case *ast.FunctionType:
+		return "fn-type"
+	}
+
+	// the rest are named after their kind, like let or block
+	return strings.ToLower(reflect.TypeOf(node).Elem().Name())
+}
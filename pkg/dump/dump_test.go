@@ -0,0 +1,155 @@
+package dump_test
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/dump"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lexer"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/parser"
+)
+
+func parse(g *WithT, input string) *ast.Root {
+	root, err := parser.New(lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)).Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+	return root
+}
+
+func TestSExpr(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "empty",
+			input: ``,
+			want:  "program\n",
+		},
+		{
+			name:  "precedence",
+			input: `-a * b + c; a + b * c == !d`,
+			want: `(program
+  (expr (+ (* (- a) b) c))
+  (expr (== (+ a (* b c)) (! d))))
+`,
+		},
+		{
+			name:  "long lists are split",
+			input: `let max = fn(a: int, b: int): int { if (a > b) { return a; } else { return b; } }; max(1, 2)`,
+			want: `(program
+  (let max
+    (fn
+      (param a int)
+      (param b int)
+      int
+      (block
+        (expr
+          (if (> a b) (block (return a)) (block (return b)))))))
+  (expr (call max 1 2)))
+`,
+		},
+		{
+			name:  "types",
+			input: `let f: fn([int], {string: bool}): bool = g;`,
+			want: `(program
+  (let f
+    (fn-type (array-type int) (hash-type string bool) bool)
+    g))
+`,
+		},
+		{
+			name:  "collections and assignments",
+			input: `xs[0] += [1, {"a": 2}][1];`,
+			want: `(program
+  (expr
+    (+=
+      (index xs 0)
+      (index (array 1 (hash (pair "a" 2))) 1))))
+`,
+		},
+		{
+			name:  "optional chaining",
+			input: `a?.b.c ?? null`,
+			want: `(program (expr (?? (. (?. a b) c) null)))
+`,
+		},
+		{
+			name:  "exceptions",
+			input: `try { throw 1; } catch (e) { e }`,
+			want: `(program (try (block (throw 1)) e (block (expr e))))
+`,
+		},
+		{
+			name:  "match",
+			input: `match (x) { [_, y] if y => 1, {a} => a }`,
+			want: `(program
+  (expr
+    (match x
+      (arm (array-pattern _ (bind y)) y 1)
+      (arm (hash-pattern (pair (bind a))) a))))
+`,
+		},
+		{
+			name:  "destructuring",
+			input: `let [a = 1, ...b] = xs;`,
+			want: `(program
+  (let (array-pattern (default (bind a) 1) (rest b)) xs))
+`,
+		},
+		{
+			name:  "template",
+			input: "`a ${b + 1}\\n${``}`",
+			want: `(program (expr (template "a " (+ b 1) "\n" template)))
+`,
+		},
+		{
+			name:  "modules",
+			input: `import "lib/math"; export let s = "a \"b\"";`,
+			want: `(program (import "lib/math") (export (let s "a \"b\"")))
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			b := &bytes.Buffer{}
+			g.Expect(dump.SExpr(b, parse(g, tc.input))).To(Succeed())
+			g.Expect(b.String()).To(Equal(tc.want))
+		})
+	}
+}
+
+func TestDot(t *testing.T) {
+	g := NewWithT(t)
+	b := &bytes.Buffer{}
+
+	g.Expect(dump.Dot(b, parse(g, `-a * b + c`))).To(Succeed())
+	g.Expect(b.String()).To(Equal(`digraph ast {
+	ordering=out;
+	node [shape=box];
+	n0 [label="program\n1:1"];
+	n1 [label="expr\n1:1"];
+	n2 [label="+\n1:8"];
+	n3 [label="*\n1:4"];
+	n4 [label="-\n1:1"];
+	n5 [label="a\n1:2"];
+	n4 -> n5;
+	n3 -> n4;
+	n6 [label="b\n1:6"];
+	n3 -> n6;
+	n2 -> n3;
+	n7 [label="c\n1:10"];
+	n2 -> n7;
+	n1 -> n2;
+	n0 -> n1;
+}
+`))
+}
@@ -0,0 +1,448 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/object"
+)
+
+// Builtins holds the Go functions that programs can call by name.
+// They are looked up after the variables in scope, so programs can
+// shadow them with their own bindings.
+type Builtins struct {
+	builtins map[string]*object.Builtin
+	// names keeps the registration order.
+	names []string
+}
+
+func NewBuiltins() *Builtins {
+	return &Builtins{builtins: map[string]*object.Builtin{}}
+}
+
+// DefaultBuiltins returns the standard builtins, with puts writing to out.
+// The errors of the builtins are prefixed by their name, like
+// "first: argument 1 must be ARRAY, got INTEGER", except for error.
+//
+//   - len(x) is the number of characters of a string or the number of
+//     elements of an array or hash.
+//   - puts(x...) writes each argument in its own line and returns null.
+//     Strings are written as they are and the rest with their Inspect form.
+//   - first(a) and last(a) are the first and last elements of an array,
+//     or null if it's empty.
+//   - rest(a) is a new array with all the elements but the first one,
+//     or null if a is empty.
+//   - push(a, x) is a new array with the elements of a followed by x.
+//   - type(x) is the name of the type of x, like "INTEGER".
+//   - str(x) converts x to a string, the same way puts writes it.
+//   - int(x) converts a string, boolean or integer to an integer.
+//   - keys(h) and values(h) are arrays with the keys and values of a hash,
+//     sorted by key.
+//   - range(end), range(start, end) and range(start, end, step) are arrays
+//     with the integers from start, 0 by default, up to but not including
+//     end, increasing by step, 1 by default. step can't be 0.
+//   - error(message) stops the program with message as a runtime error.
+func DefaultBuiltins(out io.Writer) *Builtins {
+	b := NewBuiltins()
+	for _, builtin := range []*object.Builtin{
+		newBuiltin("len", 1, 1, builtinLen),
+		withInspectSize(newBuiltin("puts", 0, -1, func(args ...object.Object) (object.Object, error) {
+			for _, a := range args {
+				s, err := toString(a)
+				if err != nil {
+					return nil, err
+				}
+				if _, err := fmt.Fprintln(out, s); err != nil {
+					return nil, err
+				}
+			}
+			return object.Null, nil
+		})),
+		withSize(newBuiltin("first", 1, 1, builtinFirst), allocatesNothing),
+		withSize(newBuiltin("last", 1, 1, builtinLast), allocatesNothing),
+		withSize(newBuiltin("rest", 1, 1, builtinRest), func(args ...object.Object) int64 {
+			return arraySize(max(lengthOf(args)-1, 0))
+		}),
+		withSize(newBuiltin("push", 2, 2, builtinPush), func(args ...object.Object) int64 {
+			return arraySize(lengthOf(args) + 1)
+		}),
+		newBuiltin("type", 1, 1, builtinType),
+		withInspectSize(newBuiltin("str", 1, 1, builtinStr)),
+		newBuiltin("int", 1, 1, builtinInt),
+		withSize(newBuiltin("keys", 1, 1, builtinKeys), func(args ...object.Object) int64 {
+			return arraySize(lengthOf(args))
+		}),
+		withSize(newBuiltin("values", 1, 1, builtinValues), func(args ...object.Object) int64 {
+			return arraySize(lengthOf(args))
+		}),
+		withSize(newBuiltin("range", 1, 3, builtinRange), rangeSize),
+		{Name: "error", Fn: builtinError},
+	} {
+		// names are unique, so this can't fail
+		_ = b.Register(builtin)
+	}
+	return b
+}
+
+// Register adds a builtin. It fails if there is already one with the same name.
+func (b *Builtins) Register(builtin *object.Builtin) error {
+	if _, ok := b.builtins[builtin.Name]; ok {
+		return errors.Errorf("builtin %s is already registered", builtin.Name)
+	}
+
+	b.builtins[builtin.Name] = builtin
+	b.names = append(b.names, builtin.Name)
+	return nil
+}
+
+// Get returns the builtin with the given name or nil if it's not registered.
+func (b *Builtins) Get(name string) *object.Builtin {
+	if b == nil {
+		return nil
+	}
+	return b.builtins[name]
+}
+
+// All returns the registered builtins in registration order.
+func (b *Builtins) All() []*object.Builtin {
+	all := make([]*object.Builtin, 0, len(b.names))
+	for _, name := range b.names {
+		all = append(all, b.builtins[name])
+	}
+	return all
+}
+
+// newBuiltin returns a builtin that checks the number of arguments before
+// calling fn and prefixes its errors with its name. A negative maxArgs
+// means that there is no maximum.
+func newBuiltin(name string, minArgs, maxArgs int, fn object.BuiltinFunction) *object.Builtin {
+	return &object.Builtin{
+		Name: name,
+		Fn: func(args ...object.Object) (object.Object, error) {
+			if len(args) < minArgs || (maxArgs >= 0 && len(args) > maxArgs) {
+				return nil, errors.Errorf("%s: wrong number of arguments: want=%s, got=%d", name, arity(minArgs, maxArgs), len(args))
+			}
+
+			o, err := fn(args...)
+			if err != nil {
+				return nil, errors.Wrap(err, name)
+			}
+			return o, nil
+		},
+	}
+}
+
+// withSize sets the estimate of the memory allocated by a builtin,
+// so the memory limit is checked before it allocates anything.
+// The elements of the arrays it returns that already exist aren't
+// part of it, since they were accounted for when they were created.
+func withSize(b *object.Builtin, size func(args ...object.Object) int64) *object.Builtin {
+	b.Size = func(_ context.Context, args ...object.Object) (int64, error) {
+		return size(args...), nil
+	}
+	return b
+}
+
+// withInspectSize sets the memory allocated by a builtin to the size of
+// the Inspect form of its arguments that aren't strings, computed without
+// building it, which fails if one is longer than maxStringLength.
+func withInspectSize(b *object.Builtin) *object.Builtin {
+	b.Size = func(ctx context.Context, args ...object.Object) (int64, error) {
+		n := int64(headerSize)
+		for _, a := range args {
+			if _, ok := a.(*object.String); ok {
+				continue
+			}
+			length, err := object.InspectLength(ctx, a, maxStringLength)
+			if err != nil {
+				return 0, err
+			}
+			if length > maxStringLength {
+				return 0, errors.Wrap(errTooLong(a), b.Name)
+			}
+			n += length
+		}
+		return n, nil
+	}
+	return b
+}
+
+func allocatesNothing(...object.Object) int64 {
+	return 0
+}
+
+// arraySize is the size of a new array of n elements, without the elements.
+func arraySize(n int) int64 {
+	return headerSize + int64(n)*wordSize
+}
+
+// lengthOf returns the number of elements of the first argument if it's
+// an array or a hash, or 0 otherwise, which is left to the builtin to fail.
+func lengthOf(args []object.Object) int {
+	if len(args) == 0 {
+		return 0
+	}
+	switch a := args[0].(type) {
+	case *object.Array:
+		return len(a.Elements)
+	case *object.Hash:
+		return len(a.Pairs)
+	}
+	return 0
+}
+
+func arity(minArgs, maxArgs int) string {
+	switch {
+	case maxArgs < 0:
+		return fmt.Sprintf("%d or more", minArgs)
+	case minArgs != maxArgs:
+		return fmt.Sprintf("%d to %d", minArgs, maxArgs)
+	}
+	return strconv.Itoa(minArgs)
+}
+
+// argument returns the argument at index i, checking it's of type T.
+func argument[T object.Object](args []object.Object, i int, want object.Type) (T, error) {
+	o, ok := args[i].(T)
+	if !ok {
+		return o, errors.Errorf("argument %d must be %s, got %s", i+1, want, args[i].Type())
+	}
+	return o, nil
+}
+
+func builtinLen(args ...object.Object) (object.Object, error) {
+	switch a := args[0].(type) {
+	case *object.String:
+		return &object.Integer{Value: int64(utf8.RuneCountInString(a.Value))}, nil
+	case *object.Array:
+		return &object.Integer{Value: int64(len(a.Elements))}, nil
+	case *object.Hash:
+		return &object.Integer{Value: int64(len(a.Pairs))}, nil
+	}
+
+	return nil, errors.Errorf("argument 1 must be %s, %s or %s, got %s", object.StringType, object.ArrayType, object.HashType, args[0].Type())
+}
+
+func builtinFirst(args ...object.Object) (object.Object, error) {
+	a, err := argument[*object.Array](args, 0, object.ArrayType)
+	if err != nil {
+		return nil, err
+	}
+	if len(a.Elements) == 0 {
+		return object.Null, nil
+	}
+	return a.Elements[0], nil
+}
+
+func builtinLast(args ...object.Object) (object.Object, error) {
+	a, err := argument[*object.Array](args, 0, object.ArrayType)
+	if err != nil {
+		return nil, err
+	}
+	if len(a.Elements) == 0 {
+		return object.Null, nil
+	}
+	return a.Elements[len(a.Elements)-1], nil
+}
+
+func builtinRest(args ...object.Object) (object.Object, error) {
+	a, err := argument[*object.Array](args, 0, object.ArrayType)
+	if err != nil {
+		return nil, err
+	}
+	if len(a.Elements) == 0 {
+		return object.Null, nil
+	}
+
+	elements := make([]object.Object, len(a.Elements)-1)
+	copy(elements, a.Elements[1:])
+	return &object.Array{Elements: elements}, nil
+}
+
+func builtinPush(args ...object.Object) (object.Object, error) {
+	a, err := argument[*object.Array](args, 0, object.ArrayType)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := make([]object.Object, len(a.Elements), len(a.Elements)+1)
+	copy(elements, a.Elements)
+	return &object.Array{Elements: append(elements, args[1])}, nil
+}
+
+func builtinType(args ...object.Object) (object.Object, error) {
+	return &object.String{Value: string(args[0].Type())}, nil
+}
+
+func builtinStr(args ...object.Object) (object.Object, error) {
+	if s, ok := args[0].(*object.String); ok {
+		return s, nil
+	}
+	s, err := toString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return &object.String{Value: s}, nil
+}
+
+// maxStringLength bounds the strings made from the Inspect form of other
+// objects, even when there's no memory limit.
+const maxStringLength = 1 << 24
+
+// toString returns a string as it is and other objects in their Inspect form.
+func toString(o object.Object) (string, error) {
+	if s, ok := o.(*object.String); ok {
+		return s.Value, nil
+	}
+	s, ok := object.InspectN(o, maxStringLength)
+	if !ok {
+		return "", errTooLong(o)
+	}
+	return s, nil
+}
+
+func errTooLong(o object.Object) error {
+	return errors.Errorf("%s too long, the maximum length is %d", o.Type(), maxStringLength)
+}
+
+// maxDescriptionLength bounds the Inspect form of the objects in messages.
+const maxDescriptionLength = 1 << 10
+
+// describe returns the Inspect form of o for a message, cut if it's too long.
+func describe(o object.Object) string {
+	s, ok := object.InspectN(o, maxDescriptionLength)
+	if !ok {
+		s += "..."
+	}
+	return s
+}
+
+func builtinInt(args ...object.Object) (object.Object, error) {
+	switch a := args[0].(type) {
+	case *object.Integer:
+		return a, nil
+	case *object.Boolean:
+		if a.Value {
+			return &object.Integer{Value: 1}, nil
+		}
+		return &object.Integer{Value: 0}, nil
+	case *object.String:
+		i, err := strconv.ParseInt(a.Value, 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("can't convert %s to %s", a.Inspect(), object.IntegerType)
+		}
+		return &object.Integer{Value: i}, nil
+	}
+
+	return nil, errors.Errorf("argument 1 must be %s, %s or %s, got %s", object.IntegerType, object.BooleanType, object.StringType, args[0].Type())
+}
+
+func builtinKeys(args ...object.Object) (object.Object, error) {
+	h, err := argument[*object.Hash](args, 0, object.HashType)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := h.SortedPairs()
+	keys := make([]object.Object, 0, len(pairs))
+	for _, p := range pairs {
+		keys = append(keys, p.Key)
+	}
+	return &object.Array{Elements: keys}, nil
+}
+
+func builtinValues(args ...object.Object) (object.Object, error) {
+	h, err := argument[*object.Hash](args, 0, object.HashType)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := h.SortedPairs()
+	values := make([]object.Object, 0, len(pairs))
+	for _, p := range pairs {
+		values = append(values, p.Value)
+	}
+	return &object.Array{Elements: values}, nil
+}
+
+// maxRangeLength bounds the arrays created by range, even when
+// there's no memory limit.
+const maxRangeLength = 1 << 24
+
+func builtinRange(args ...object.Object) (object.Object, error) {
+	start, step, length, err := rangeOf(args)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := make([]object.Object, 0, length)
+	for i := int64(0); i < length; i++ {
+		elements = append(elements, &object.Integer{Value: start + i*step})
+	}
+	return &object.Array{Elements: elements}, nil
+}
+
+// rangeSize is the size of the array created by range and its integers.
+func rangeSize(args ...object.Object) int64 {
+	_, _, length, err := rangeOf(args)
+	if err != nil {
+		return 0
+	}
+	return arraySize(int(length)) + length*(headerSize+wordSize)
+}
+
+// rangeOf returns the first element, the step and the number of elements
+// of the range with the given arguments.
+func rangeOf(args []object.Object) (start, step, length int64, err error) {
+	bounds := make([]int64, 0, len(args))
+	for i := range args {
+		n, err := argument[*object.Integer](args, i, object.IntegerType)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		bounds = append(bounds, n.Value)
+	}
+	if len(bounds) == 0 {
+		return 0, 0, 0, errors.New("missing end")
+	}
+
+	start, end, step := int64(0), bounds[0], int64(1)
+	if len(bounds) > 1 {
+		start, end = bounds[0], bounds[1]
+	}
+	if len(bounds) > 2 {
+		step = bounds[2]
+	}
+	if step == 0 {
+		return 0, 0, 0, errors.New("step can't be 0")
+	}
+
+	// the distance between start and end can overflow an int64, but not an uint64
+	var n uint64
+	switch {
+	case step > 0 && start < end:
+		n = (uint64(end)-uint64(start)-1)/uint64(step) + 1
+	case step < 0 && start > end:
+		n = (uint64(start)-uint64(end)-1)/uint64(-step) + 1
+	}
+	if n > maxRangeLength {
+		return 0, 0, 0, errors.Errorf("too many elements, the maximum is %d", maxRangeLength)
+	}
+	return start, step, int64(n), nil
+}
+
+func builtinError(args ...object.Object) (object.Object, error) {
+	if len(args) != 1 {
+		return nil, errors.Errorf("error: wrong number of arguments: want=1, got=%d", len(args))
+	}
+	message, err := toString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return nil, errors.New(message)
+}
@@ -0,0 +1,162 @@
+package evaluator_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/evaluator"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lexer"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/object"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/parser"
+)
+
+func TestBuiltins(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{name: "len of a string", input: `len(s)`, want: "5"},
+		{name: "len of an array", input: `len(range(3))`, want: "3"},
+		{name: "len of a hash", input: `len(h)`, want: "2"},
+		{name: "first", input: `first(range(1, 4))`, want: "1"},
+		{name: "first of an empty array", input: `first(range(0))`, want: "null"},
+		{name: "last", input: `last(range(1, 4))`, want: "3"},
+		{name: "rest", input: `rest(range(1, 4))`, want: "[2, 3]"},
+		{name: "rest of an empty array", input: `rest(range(0))`, want: "null"},
+		{name: "push doesn't modify the array", input: `let a = range(2); let b = push(a, 5); len(a) * 10 + last(b)`, want: "25"},
+		{name: "type", input: `type(1)`, want: `"INTEGER"`},
+		{name: "type of a builtin", input: `type(len)`, want: `"BUILTIN"`},
+		{name: "str", input: `str(range(2))`, want: `"[0, 1]"`},
+		{name: "str of a string", input: `str(s)`, want: `"héllo"`},
+		{name: "str of an array containing itself", input: `let a = [1]; a[0] = a; str(a)`, want: `"[[...]]"`},
+		{name: "str of a hash containing itself", input: `let x = {}; x.self = x; str(x)`, want: `"{\"self\": {...}}"`},
+		{name: "rest of arrays containing themselves", input: `let a = [1]; a[0] = a; rest([a, a])`, want: "[[[...]]]"},
+		{name: "int of a string", input: `int(str(-42))`, want: "-42"},
+		{name: "int of a boolean", input: `int(true) + int(false)`, want: "1"},
+		{name: "keys", input: `keys(h)`, want: `["a", "b"]`},
+		{name: "values", input: `values(h)`, want: "[1, 2]"},
+		{name: "range with start", input: `range(-2, 2)`, want: "[-2, -1, 0, 1]"},
+		{name: "range with step", input: `range(0, 10, 3)`, want: "[0, 3, 6, 9]"},
+		{name: "range backwards", input: `range(3, 0, -1)`, want: "[3, 2, 1]"},
+		{name: "empty range", input: `range(3, 0)`, want: "[]"},
+		{name: "builtins can be shadowed", input: `let len = fn(x) { 42 }; len(s)`, want: "42"},
+		{name: "builtins are values", input: `let f = fn(g) { g(range(4)) }; f(len)`, want: "4"},
+		{
+			name:    "wrong number of arguments",
+			input:   `len(s, s)`,
+			wantErr: "runtime error at 1:4: len: wrong number of arguments: want=1, got=2",
+		},
+		{
+			name:    "wrong number of arguments to range",
+			input:   `range()`,
+			wantErr: "runtime error at 1:6: range: wrong number of arguments: want=1 to 3, got=0",
+		},
+		{
+			name:    "unsupported argument",
+			input:   `len(1)`,
+			wantErr: "runtime error at 1:4: len: argument 1 must be STRING, ARRAY or HASH, got INTEGER",
+		},
+		{
+			name:    "wrong argument type",
+			input:   `push(1, 2)`,
+			wantErr: "runtime error at 1:5: push: argument 1 must be ARRAY, got INTEGER",
+		},
+		{
+			name:    "invalid integer",
+			input:   `int(s)`,
+			wantErr: `runtime error at 1:4: int: can't convert "héllo" to INTEGER`,
+		},
+		{
+			name:    "zero step",
+			input:   `range(1, 2, 0)`,
+			wantErr: "runtime error at 1:6: range: step can't be 0",
+		},
+		{
+			name:    "too large range",
+			input:   `range(0 - 4611686018427387904 * 2, 4611686018427387903 * 2)`,
+			wantErr: "runtime error at 1:6: range: too many elements, the maximum is 16777216",
+		},
+		{
+			name:    "str of arrays sharing elements",
+			input:   `let a = [1]; let i = 0; while (i < 40) { a = [a, a]; i += 1; } str(a)`,
+			wantErr: "runtime error at 1:67: str: ARRAY too long, the maximum length is 16777216",
+		},
+		{
+			name:    "error",
+			input:   `let check = fn(x) { if (x < 0) { error(x) } x }; check(-1)`,
+			wantErr: "runtime error at 1:39: -1",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			l := lexer.New(lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(tc.input))))
+			root, err := parser.New(l).Parse()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			env := object.NewEnvironment()
+			env.Set("s", &object.String{Value: "héllo"})
+			h := object.NewHash()
+			h.Set(&object.String{Value: "b"}, &object.Integer{Value: 2})
+			h.Set(&object.String{Value: "a"}, &object.Integer{Value: 1})
+			env.Set("h", h)
+
+			got, err := evaluator.New().Eval(context.Background(), root, env)
+			if tc.wantErr != "" {
+				g.Expect(err).To(MatchError(tc.wantErr))
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got.Inspect()).To(Equal(tc.want))
+		})
+	}
+}
+
+func TestBuiltinsPuts(t *testing.T) {
+	g := NewWithT(t)
+	l := lexer.New(lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(`puts(1, s, range(2)); puts()`))))
+	root, err := parser.New(l).Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	out := &bytes.Buffer{}
+	e := evaluator.New()
+	e.Builtins = evaluator.DefaultBuiltins(out)
+	env := object.NewEnvironment()
+	env.Set("s", &object.String{Value: "text"})
+
+	got, err := e.Eval(context.Background(), root, env)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(Equal(object.Null))
+	g.Expect(out.String()).To(Equal("1\ntext\n[0, 1]\n"))
+}
+
+func TestBuiltinsRegister(t *testing.T) {
+	g := NewWithT(t)
+	l := lexer.New(lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(`double(len(range(4)))`))))
+	root, err := parser.New(l).Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	e := evaluator.New()
+	double := &object.Builtin{Name: "double", Fn: func(args ...object.Object) (object.Object, error) {
+		return &object.Integer{Value: 2 * args[0].(*object.Integer).Value}, nil
+	}}
+	g.Expect(e.Builtins.Register(double)).To(Succeed())
+	g.Expect(e.Builtins.Register(double)).To(MatchError("builtin double is already registered"))
+	g.Expect(e.Builtins.Get("double")).To(BeIdenticalTo(double))
+
+	got, err := e.Eval(context.Background(), root, object.NewEnvironment())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got.Inspect()).To(Equal("8"))
+
+	e.Builtins = nil
+	_, err = e.Eval(context.Background(), root, object.NewEnvironment())
+	g.Expect(err).To(MatchError("runtime error at 1:1: identifier not found: double"))
+}
@@ -0,0 +1,50 @@
+package evaluator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/object"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+)
+
+// Error is a runtime error, located at the node that caused it.
+type Error struct {
+	err error
+	pos token.Position
+}
+
+// NewError locates err at pos, unless it's already located.
+func NewError(err error, pos token.Position) Error {
+	e := Error{}
+	if errors.As(err, &e) {
+		return e
+	}
+
+	e.err = err
+	e.pos = pos
+	return e
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("runtime error at %s: %s", e.pos, e.err)
+}
+
+func (e Error) Unwrap() error {
+	return e.err
+}
+
+// Pos returns the position of the node that caused the error.
+func (e Error) Pos() token.Position {
+	return e.pos
+}
+
+// Exception is the error of a program that raised an error value with
+// throw and didn't catch it.
+type Exception struct {
+	Value *object.Error
+}
+
+func (e *Exception) Error() string {
+	return "uncaught exception: " + e.Value.Message
+}
@@ -0,0 +1,1201 @@
+// Package evaluator runs Monkey programs by walking their AST.
+package evaluator
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/object"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+)
+
+type Evaluator struct {
+	// Limits bounds the cost of each call to Eval or Call.
+	// It defaults to DefaultLimits.
+	Limits Limits
+	// Builtins are the functions available to programs besides
+	// the variables in their environment.
+	Builtins *Builtins
+	// Importer loads the modules imported by programs.
+	// Programs with imports fail if it's nil.
+	Importer Importer
+}
+
+// Importer returns the names exported by the module at path,
+// loading it if needed.
+type Importer interface {
+	Import(ctx context.Context, path string) (map[string]object.Object, error)
+}
+
+// New returns an evaluator with the DefaultBuiltins, writing to the standard output.
+func New() *Evaluator {
+	return &Evaluator{Limits: DefaultLimits, Builtins: DefaultBuiltins(os.Stdout)}
+}
+
+// Eval runs a program in env and returns the value of its last statement
+// or the value of the first top level return.
+// It stops with the context error if ctx is cancelled, or with one of
+// the limit errors if the program exceeds the evaluator Limits.
+func (e *Evaluator) Eval(ctx context.Context, root *ast.Root, env *object.Environment) (object.Object, error) {
+	r, cancel := e.newRun(ctx)
+	defer cancel()
+
+	o, err := r.statements(root.Statements, env)
+	if err != nil {
+		return nil, err
+	}
+
+	return unwrapReturn(o), nil
+}
+
+// Call calls a function or builtin object with the given arguments.
+func (e *Evaluator) Call(ctx context.Context, fn object.Object, args []object.Object) (object.Object, error) {
+	r, cancel := e.newRun(ctx)
+	defer cancel()
+
+	return r.call(fn, args)
+}
+
+func (e *Evaluator) newRun(ctx context.Context) (*run, context.CancelFunc) {
+	r := &run{parent: ctx, ctx: ctx, limits: e.Limits, builtins: e.Builtins, importer: e.Importer}
+	if e.Limits.Timeout <= 0 {
+		return r, func() {}
+	}
+
+	var cancel context.CancelFunc
+	r.ctx, cancel = context.WithTimeout(ctx, e.Limits.Timeout)
+	return r, cancel
+}
+
+// run holds the state of a single evaluation.
+type run struct {
+	// parent is the context given by the caller, ctx adds the timeout to it.
+	parent   context.Context
+	ctx      context.Context
+	limits   Limits
+	builtins *Builtins
+	importer Importer
+	steps    int64
+	depth    int
+	memory   int64
+	// calls are the function calls being run, outermost first. The calls
+	// that fail are kept, so a try catching the error can trace them.
+	calls []call
+}
+
+// call is a function call being run.
+type call struct {
+	function string
+	pos      token.Position
+}
+
+func (r *run) statements(statements []ast.Statement, env *object.Environment) (object.Object, error) {
+	var result object.Object = object.Null
+	for _, s := range statements {
+		if err := r.step(s.Pos()); err != nil {
+			return nil, err
+		}
+
+		o, err := r.statement(s, env)
+		if ret, ok := err.(*returnSignal); ok {
+			o, err = ret.value, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = o
+
+		// stop at the first return, keeping it wrapped so outer blocks stop too
+		if _, ok := o.(*object.ReturnValue); ok {
+			return o, nil
+		}
+	}
+
+	return result, nil
+}
+
+func (r *run) statement(s ast.Statement, env *object.Environment) (object.Object, error) {
+	switch s := s.(type) {
+	case *ast.ExpressionStatement:
+		return r.expression(s.Expression, env)
+	case *ast.Let:
+		return r.let(s, env)
+	case *ast.Export:
+		return r.let(s.Let, env)
+	case *ast.Import:
+		return r.importModule(s, env)
+	case *ast.While:
+		return r.while(s, env)
+	case *ast.For:
+		return r.forLoop(s, env)
+	case *ast.Break, *ast.Continue:
+		return nil, &loopSignal{statement: s}
+	case *ast.Throw:
+		return nil, r.throw(s, env)
+	case *ast.Try:
+		return r.try(s, env)
+	case *ast.Return:
+		v, err := r.expression(s.Value, env)
+		if err != nil {
+			return nil, err
+		}
+		return &object.ReturnValue{Value: v}, nil
+	case *ast.Block:
+		return r.statements(s.Statements, object.NewEnclosedEnvironment(env))
+	}
+
+	return nil, NewError(errors.Errorf("unsupported statement %T", s), s.Pos())
+}
+
+func (r *run) let(l *ast.Let, env *object.Environment) (object.Object, error) {
+	v, err := r.expression(l.Value, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.Pattern != nil {
+		ok, err := r.matchPattern(l.Pattern, v, env)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, NewError(errors.Errorf("cannot destructure %s", describe(v)), l.Pos())
+		}
+		return object.Null, nil
+	}
+
+	env.Set(l.Name.Value, v)
+	return object.Null, nil
+}
+
+// importModule binds the names exported by a module in env.
+func (r *run) importModule(i *ast.Import, env *object.Environment) (object.Object, error) {
+	if r.importer == nil {
+		return nil, NewError(errors.New("imports are not supported"), i.Pos())
+	}
+
+	exports, err := r.importer.Import(r.ctx, i.Path.Value)
+	if err != nil {
+		// the errors of the module are located in its own source,
+		// so they are wrapped instead of kept as they are by NewError
+		return nil, Error{err: errors.Wrapf(err, "importing %q", i.Path.Value), pos: i.Pos()}
+	}
+	for name, o := range exports {
+		env.Set(name, o)
+	}
+	return object.Null, nil
+}
+
+// expression evaluates an expression. It's the end of the optional chains
+// it contains, so it's null if one of their optional accesses is on null.
+func (r *run) expression(e ast.Expression, env *object.Environment) (object.Object, error) {
+	o, err := r.link(e, env)
+	if _, ok := err.(*optionalSignal); ok {
+		return object.Null, nil
+	}
+	return o, err
+}
+
+// link evaluates an expression that can be a link of an optional chain,
+// like the left side of an index, returning an optionalSignal if the rest
+// of the chain has to be skipped.
+func (r *run) link(e ast.Expression, env *object.Environment) (object.Object, error) {
+	if err := r.step(e.Pos()); err != nil {
+		return nil, err
+	}
+
+	o, err := r.evalExpression(e, env)
+	if err != nil {
+		return nil, err
+	}
+	if ret, ok := o.(*object.ReturnValue); ok {
+		// a block used as a value returned, like in `let x = if (c) { return 1; } else { 2 };`
+		return nil, &returnSignal{value: ret}
+	}
+
+	// these are the expressions that create new objects
+	allocates := false
+	switch e := e.(type) {
+	case *ast.Literal, *ast.StringLiteral, *ast.TemplateLiteral, *ast.Prefix, *ast.FunctionLiteral, *ast.ArrayLiteral, *ast.HashLiteral:
+		allocates = true
+	case *ast.Infix:
+		// ?? returns one of its operands
+		allocates = e.Operator != ast.NullCoalesce
+	}
+	if allocates {
+		if err := r.alloc(o, e.Pos()); err != nil {
+			return nil, err
+		}
+	}
+
+	return o, nil
+}
+
+func (r *run) evalExpression(e ast.Expression, env *object.Environment) (object.Object, error) {
+	switch e := e.(type) {
+	case *ast.Literal:
+		return &object.Integer{Value: e.Value}, nil
+	case *ast.Boolean:
+		return object.NativeBool(e.Value), nil
+	case *ast.StringLiteral:
+		return &object.String{Value: e.Value}, nil
+	case *ast.TemplateLiteral:
+		return r.template(e, env)
+	case *ast.Null:
+		return object.Null, nil
+	case *ast.Identifier:
+		if v, ok := env.Get(e.Value); ok {
+			return v, nil
+		}
+		if b := r.builtins.Get(e.Value); b != nil {
+			return b, nil
+		}
+		return nil, NewError(errors.Errorf("identifier not found: %s", e.Value), e.Pos())
+	case *ast.Prefix:
+		right, err := r.expression(e.Right, env)
+		if err != nil {
+			return nil, err
+		}
+		return r.prefix(e, right)
+	case *ast.Infix:
+		left, err := r.expression(e.Left, env)
+		if err != nil {
+			return nil, err
+		}
+		if e.Operator == ast.NullCoalesce {
+			// the right side is only evaluated when it's needed
+			if left != object.Null {
+				return left, nil
+			}
+			return r.expression(e.Right, env)
+		}
+		right, err := r.expression(e.Right, env)
+		if err != nil {
+			return nil, err
+		}
+		return r.infix(e, left, right)
+	case *ast.If:
+		return r.ifExpression(e, env)
+	case *ast.FunctionLiteral:
+		return &object.Function{Parameters: e.Parameters, Body: e.Body, Env: env}, nil
+	case *ast.Call:
+		fn, err := r.link(e.Function, env)
+		if err != nil {
+			return nil, err
+		}
+		if e.Optional && fn == object.Null {
+			return nil, &optionalSignal{}
+		}
+		args := make([]object.Object, 0, len(e.Arguments))
+		for _, a := range e.Arguments {
+			v, err := r.expression(a, env)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, v)
+		}
+		_, traced := fn.(*object.Function)
+		if traced {
+			r.calls = append(r.calls, call{function: calleeName(e.Function), pos: e.Pos()})
+		}
+		o, err := r.call(fn, args)
+		if err != nil {
+			return nil, NewError(err, e.Pos())
+		}
+		if traced {
+			r.calls = r.calls[:len(r.calls)-1]
+		}
+		return o, nil
+	case *ast.ArrayLiteral:
+		a := &object.Array{Elements: make([]object.Object, 0, len(e.Elements))}
+		for _, el := range e.Elements {
+			v, err := r.expression(el, env)
+			if err != nil {
+				return nil, err
+			}
+			a.Elements = append(a.Elements, v)
+		}
+		return a, nil
+	case *ast.HashLiteral:
+		return r.hash(e, env)
+	case *ast.Index:
+		left, err := r.link(e.Left, env)
+		if err != nil {
+			return nil, err
+		}
+		if e.Optional && left == object.Null {
+			return nil, &optionalSignal{}
+		}
+		index, err := r.expression(e.Index, env)
+		if err != nil {
+			return nil, err
+		}
+		return r.index(e, left, index)
+	case *ast.Property:
+		left, err := r.link(e.Left, env)
+		if err != nil {
+			return nil, err
+		}
+		if e.Optional && left == object.Null {
+			return nil, &optionalSignal{}
+		}
+		return r.property(e, left)
+	case *ast.Assign:
+		return r.assign(e, env)
+	case *ast.Match:
+		return r.match(e, env)
+	}
+
+	return nil, NewError(errors.Errorf("unsupported expression %T", e), e.Pos())
+}
+
+// template joins the texts of a template literal with its interpolated
+// values, which are converted to strings like puts does. Their length is
+// checked against the memory limit before building the string.
+func (r *run) template(t *ast.TemplateLiteral, env *object.Environment) (object.Object, error) {
+	values := make([]object.Object, len(t.Parts))
+	length := int64(headerSize)
+	for i, part := range t.Parts {
+		if text, ok := part.(*ast.TemplateText); ok {
+			length += int64(len(text.Value))
+			continue
+		}
+		v, err := r.expression(part, env)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+		if s, ok := v.(*object.String); ok {
+			length += int64(len(s.Value))
+			continue
+		}
+		n, err := object.InspectLength(r.ctx, v, maxStringLength)
+		if err != nil {
+			return nil, NewError(r.contextErr(), part.Pos())
+		}
+		if n > maxStringLength {
+			return nil, NewError(errTooLong(v), part.Pos())
+		}
+		length += n
+	}
+	if err := r.fits(length); err != nil {
+		return nil, NewError(err, t.Pos())
+	}
+
+	var b strings.Builder
+	for i, part := range t.Parts {
+		if text, ok := part.(*ast.TemplateText); ok {
+			b.WriteString(text.Value)
+			continue
+		}
+		s, err := toString(values[i])
+		if err != nil {
+			return nil, NewError(err, part.Pos())
+		}
+		b.WriteString(s)
+	}
+	return &object.String{Value: b.String()}, nil
+}
+
+func (r *run) hash(h *ast.HashLiteral, env *object.Environment) (object.Object, error) {
+	o := object.NewHash()
+	for _, pair := range h.Pairs {
+		k, err := r.expression(pair.Key, env)
+		if err != nil {
+			return nil, err
+		}
+		key, err := hashKey(k, pair.Key.Pos())
+		if err != nil {
+			return nil, err
+		}
+		v, err := r.expression(pair.Value, env)
+		if err != nil {
+			return nil, err
+		}
+		o.Set(key, v)
+	}
+	return o, nil
+}
+
+// index returns the element of an array at an integer index, or the value
+// of a key in a hash, which is null if the hash doesn't have it.
+func (r *run) index(i *ast.Index, left, index object.Object) (object.Object, error) {
+	switch left := left.(type) {
+	case *object.Array:
+		n, err := arrayIndex(i, left, index)
+		if err != nil {
+			return nil, err
+		}
+		return left.Elements[n], nil
+	case *object.Hash:
+		key, err := hashKey(index, i.Index.Pos())
+		if err != nil {
+			return nil, err
+		}
+		if v, ok := left.Get(key); ok {
+			return v, nil
+		}
+		return object.Null, nil
+	}
+
+	return nil, NewError(errors.Errorf("index operator not supported: %s", left.Type()), i.Pos())
+}
+
+func hashKey(o object.Object, pos token.Position) (object.Hashable, error) {
+	key, ok := o.(object.Hashable)
+	if !ok {
+		return nil, NewError(errors.Errorf("unusable as hash key: %s", o.Type()), pos)
+	}
+	return key, nil
+}
+
+// property returns the value of the key named like the property in a
+// hash, which is null if the hash doesn't have it, or a property of an error.
+func (r *run) property(p *ast.Property, left object.Object) (object.Object, error) {
+	switch left := left.(type) {
+	case *object.Hash:
+		if v, ok := left.Get(&object.String{Value: p.Name.Value}); ok {
+			return v, nil
+		}
+		return object.Null, nil
+	case *object.Error:
+		v := errorProperty(left, p.Name.Value)
+		if err := r.alloc(v, p.Pos()); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+
+	return nil, NewError(errors.Errorf("property access not supported: %s", left.Type()), p.Pos())
+}
+
+// errorProperty returns the properties of errors: their message, value,
+// line, column and stack, or null for any other name.
+func errorProperty(e *object.Error, name string) object.Object {
+	switch name {
+	case "message":
+		return &object.String{Value: e.Message}
+	case "value":
+		return e.Value
+	case "line":
+		return &object.Integer{Value: int64(e.Pos.Line)}
+	case "column":
+		return &object.Integer{Value: int64(e.Pos.Column)}
+	case "stack":
+		frames := make([]object.Object, 0, len(e.Stack))
+		for _, f := range e.Stack {
+			frames = append(frames, &object.String{Value: f.String()})
+		}
+		return &object.Array{Elements: frames}
+	}
+	return object.Null
+}
+
+// arrayIndex checks that index is an integer within the bounds of a.
+func arrayIndex(i *ast.Index, a *object.Array, index object.Object) (int, error) {
+	n, ok := index.(*object.Integer)
+	if !ok {
+		return 0, NewError(errors.Errorf("array index must be %s, got %s", object.IntegerType, index.Type()), i.Index.Pos())
+	}
+	if n.Value < 0 || n.Value >= int64(len(a.Elements)) {
+		return 0, NewError(errors.Errorf("index out of range: %d with length %d", n.Value, len(a.Elements)), i.Index.Pos())
+	}
+	return int(n.Value), nil
+}
+
+// assign changes the value of a variable, or of an element of an array
+// or a hash in place, and returns the assigned value.
+func (r *run) assign(a *ast.Assign, env *object.Environment) (object.Object, error) {
+	switch target := a.Target.(type) {
+	case *ast.Identifier:
+		var current object.Object
+		if a.Operator != ast.PlainAssign {
+			var err error
+			if current, err = r.expression(target, env); err != nil {
+				return nil, err
+			}
+		}
+		v, err := r.assignedValue(a, current, env)
+		if err != nil {
+			return nil, err
+		}
+		if !env.Assign(target.Value, v) {
+			return nil, NewError(errors.Errorf("identifier not found: %s", target.Value), target.Pos())
+		}
+		return v, nil
+	case *ast.Index:
+		left, err := r.expression(target.Left, env)
+		if err != nil {
+			return nil, err
+		}
+		index, err := r.expression(target.Index, env)
+		if err != nil {
+			return nil, err
+		}
+		var current object.Object
+		if a.Operator != ast.PlainAssign {
+			if current, err = r.index(target, left, index); err != nil {
+				return nil, err
+			}
+		}
+		v, err := r.assignedValue(a, current, env)
+		if err != nil {
+			return nil, err
+		}
+		return v, r.setIndex(target, left, index, v)
+	case *ast.Property:
+		left, err := r.expression(target.Left, env)
+		if err != nil {
+			return nil, err
+		}
+		var current object.Object
+		if a.Operator != ast.PlainAssign {
+			if current, err = r.property(target, left); err != nil {
+				return nil, err
+			}
+		}
+		v, err := r.assignedValue(a, current, env)
+		if err != nil {
+			return nil, err
+		}
+		h, ok := left.(*object.Hash)
+		if !ok {
+			return nil, NewError(errors.Errorf("property access not supported: %s", left.Type()), target.Pos())
+		}
+		return v, r.setKey(h, &object.String{Value: target.Name.Value}, v, target.Pos())
+	}
+
+	return nil, NewError(errors.Errorf("cannot assign to %T", a.Target), a.Pos())
+}
+
+// assignedValue evaluates the value of an assignment, combining it
+// with the current value of the target for compound assignments.
+func (r *run) assignedValue(a *ast.Assign, current object.Object, env *object.Environment) (object.Object, error) {
+	v, err := r.expression(a.Value, env)
+	if err != nil || a.Operator == ast.PlainAssign {
+		return v, err
+	}
+
+	i := &ast.Infix{
+		Token:    a.Token,
+		Operator: ast.InfixOperator(strings.TrimSuffix(string(a.Operator), "=")),
+		Left:     a.Target,
+		Right:    a.Value,
+	}
+	if v, err = r.infix(i, current, v); err != nil {
+		return nil, err
+	}
+	if err := r.alloc(v, a.Pos()); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (r *run) setIndex(i *ast.Index, left, index, v object.Object) error {
+	switch left := left.(type) {
+	case *object.Array:
+		n, err := arrayIndex(i, left, index)
+		if err != nil {
+			return err
+		}
+		left.Elements[n] = v
+		return nil
+	case *object.Hash:
+		key, err := hashKey(index, i.Index.Pos())
+		if err != nil {
+			return err
+		}
+		return r.setKey(left, key, v, i.Pos())
+	}
+
+	return NewError(errors.Errorf("index operator not supported: %s", left.Type()), i.Pos())
+}
+
+// setKey sets the value of a key in a hash, accounting for the memory
+// of the new pair if the key wasn't there.
+func (r *run) setKey(h *object.Hash, key object.Hashable, v object.Object, pos token.Position) error {
+	if _, ok := h.Get(key); !ok {
+		if err := r.allocBytes(bindingSize); err != nil {
+			return NewError(err, pos)
+		}
+	}
+	h.Set(key, v)
+	return nil
+}
+
+func (r *run) prefix(p *ast.Prefix, right object.Object) (object.Object, error) {
+	switch p.Operator {
+	case ast.Not:
+		return object.NativeBool(!isTruthy(right)), nil
+	case ast.Negative:
+		if i, ok := right.(*object.Integer); ok {
+			return &object.Integer{Value: -i.Value}, nil
+		}
+	}
+
+	return nil, NewError(errors.Errorf("unknown operator: %s%s", p.Operator, right.Type()), p.Pos())
+}
+
+func (r *run) infix(i *ast.Infix, left, right object.Object) (object.Object, error) {
+	if l, ok := left.(*object.Integer); ok {
+		if r, ok := right.(*object.Integer); ok {
+			return integerInfix(i, l.Value, r.Value)
+		}
+	}
+	if l, ok := left.(*object.String); ok {
+		if r, ok := right.(*object.String); ok {
+			return stringInfix(i, l.Value, r.Value)
+		}
+	}
+
+	switch {
+	case (i.Operator == ast.Equal || i.Operator == ast.NotEqual) && (left == object.Null || right == object.Null):
+		// any value can be compared with null
+		return object.NativeBool((left == right) == (i.Operator == ast.Equal)), nil
+	case left.Type() != right.Type():
+		return nil, NewError(errors.Errorf("type mismatch: %s %s %s", left.Type(), i.Operator, right.Type()), i.Pos())
+	case i.Operator == ast.Equal:
+		return object.NativeBool(left == right), nil
+	case i.Operator == ast.NotEqual:
+		return object.NativeBool(left != right), nil
+	}
+
+	return nil, NewError(errors.Errorf("unknown operator: %s %s %s", left.Type(), i.Operator, right.Type()), i.Pos())
+}
+
+func integerInfix(i *ast.Infix, left, right int64) (object.Object, error) {
+	switch i.Operator {
+	case ast.Addition:
+		return &object.Integer{Value: left + right}, nil
+	case ast.Subtraction:
+		return &object.Integer{Value: left - right}, nil
+	case ast.Multiplication:
+		return &object.Integer{Value: left * right}, nil
+	case ast.Division:
+		if right == 0 {
+			return nil, NewError(errors.New("division by zero"), i.Pos())
+		}
+		return &object.Integer{Value: left / right}, nil
+	case ast.LessThan:
+		return object.NativeBool(left < right), nil
+	case ast.GreaterThan:
+		return object.NativeBool(left > right), nil
+	case ast.Equal:
+		return object.NativeBool(left == right), nil
+	case ast.NotEqual:
+		return object.NativeBool(left != right), nil
+	}
+
+	return nil, NewError(errors.Errorf("unknown operator: %s %s %s", object.IntegerType, i.Operator, object.IntegerType), i.Pos())
+}
+
+func stringInfix(i *ast.Infix, left, right string) (object.Object, error) {
+	switch i.Operator {
+	case ast.Addition:
+		return &object.String{Value: left + right}, nil
+	case ast.Equal:
+		return object.NativeBool(left == right), nil
+	case ast.NotEqual:
+		return object.NativeBool(left != right), nil
+	}
+
+	return nil, NewError(errors.Errorf("unknown operator: %s %s %s", object.StringType, i.Operator, object.StringType), i.Pos())
+}
+
+func (r *run) ifExpression(i *ast.If, env *object.Environment) (object.Object, error) {
+	condition, err := r.expression(i.Condition, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if isTruthy(condition) {
+		return r.statement(i.Consequence, env)
+	}
+	if i.Alternative != nil {
+		return r.statement(i.Alternative, env)
+	}
+
+	return object.Null, nil
+}
+
+// match evaluates the body of the first arm whose pattern matches the
+// subject and whose guard is true, with the names bound by the pattern
+// in a scope of their own. It fails if no arm matches.
+func (r *run) match(m *ast.Match, env *object.Environment) (object.Object, error) {
+	subject, err := r.expression(m.Subject, env)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, arm := range m.Arms {
+		if err := r.allocBytes(environmentSize); err != nil {
+			return nil, NewError(err, arm.Pos())
+		}
+		armEnv := object.NewEnclosedEnvironment(env)
+
+		ok, err := r.matchPattern(arm.Pattern, subject, armEnv)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		if arm.Guard != nil {
+			guard, err := r.expression(arm.Guard, armEnv)
+			if err != nil {
+				return nil, err
+			}
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+
+		return r.expression(arm.Body, armEnv)
+	}
+
+	return nil, NewError(errors.Errorf("no match for %s", describe(subject)), m.Pos())
+}
+
+// matchPattern reports whether a value matches a pattern, binding
+// the names of the pattern in env as it goes.
+func (r *run) matchPattern(p ast.Pattern, v object.Object, env *object.Environment) (bool, error) {
+	switch p := p.(type) {
+	case *ast.WildcardPattern:
+		return true, nil
+	case *ast.BindingPattern:
+		return true, r.bind(p.Name, v, env)
+	case *ast.LiteralPattern:
+		literal, err := r.expression(p.Value, env)
+		if err != nil {
+			return false, err
+		}
+		return equalLiteral(literal, v), nil
+	case *ast.ArrayPattern:
+		a, ok := v.(*object.Array)
+		if !ok {
+			return false, nil
+		}
+		return r.matchElements(p, a, env)
+	case *ast.HashPattern:
+		h, ok := v.(*object.Hash)
+		if !ok {
+			return false, nil
+		}
+		for _, pair := range p.Pairs {
+			ok, err := r.matchPair(pair, h, env)
+			if !ok || err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	case *ast.DefaultPattern:
+		// the value is there, so the default isn't needed
+		return r.matchPattern(p.Pattern, v, env)
+	}
+
+	return false, NewError(errors.Errorf("unsupported pattern %T", p), p.Pos())
+}
+
+// matchElements reports whether the elements of an array match an array
+// pattern. The array can only be shorter than the pattern if the missing
+// elements have default values, and longer if the pattern ends with a rest.
+func (r *run) matchElements(p *ast.ArrayPattern, a *object.Array, env *object.Environment) (bool, error) {
+	rest, _ := lastPattern(p.Elements).(*ast.RestPattern)
+	if rest == nil && len(a.Elements) > len(p.Elements) {
+		return false, nil
+	}
+
+	for i, e := range p.Elements {
+		if e == rest {
+			left := &object.Array{}
+			if i < len(a.Elements) {
+				left.Elements = append(left.Elements, a.Elements[i:]...)
+			}
+			if err := r.alloc(left, rest.Pos()); err != nil {
+				return false, err
+			}
+			return true, r.bind(rest.Name, left, env)
+		}
+
+		var v object.Object
+		if i < len(a.Elements) {
+			v = a.Elements[i]
+		}
+		if ok, err := r.matchOrDefault(e, v, env); !ok || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func lastPattern(patterns []ast.Pattern) ast.Pattern {
+	if len(patterns) == 0 {
+		return nil
+	}
+	return patterns[len(patterns)-1]
+}
+
+// matchOrDefault matches a value that can be missing, when it's nil,
+// which only matches a DefaultPattern with its default value.
+func (r *run) matchOrDefault(p ast.Pattern, v object.Object, env *object.Environment) (bool, error) {
+	if v != nil {
+		return r.matchPattern(p, v, env)
+	}
+
+	d, ok := p.(*ast.DefaultPattern)
+	if !ok {
+		return false, nil
+	}
+	v, err := r.expression(d.Default, env)
+	if err != nil {
+		return false, err
+	}
+	return r.matchPattern(d.Pattern, v, env)
+}
+
+// matchPair reports whether a hash has the key of a pair with a value
+// matching its pattern, or doesn't have it and the pattern has a default.
+func (r *run) matchPair(pair *ast.HashPatternPair, h *object.Hash, env *object.Environment) (bool, error) {
+	var key object.Object
+	switch k := pair.Key.(type) {
+	case nil:
+		name := pair.KeyName()
+		if name == nil {
+			return false, NewError(errors.New("shorthand pair without a name"), pair.Pos())
+		}
+		key = &object.String{Value: name.Value}
+	case *ast.Identifier:
+		key = &object.String{Value: k.Value}
+	default:
+		var err error
+		if key, err = r.expression(pair.Key, env); err != nil {
+			return false, err
+		}
+	}
+	k, err := hashKey(key, pair.Pos())
+	if err != nil {
+		return false, err
+	}
+
+	v, _ := h.Get(k)
+	return r.matchOrDefault(pair.Value, v, env)
+}
+
+// bind sets a name bound by a pattern in env.
+func (r *run) bind(name *ast.Identifier, v object.Object, env *object.Environment) error {
+	if err := r.allocBytes(bindingSize); err != nil {
+		return NewError(err, name.Pos())
+	}
+	env.Set(name.Value, v)
+	return nil
+}
+
+// equalLiteral reports whether a value is equal to the value of a literal pattern.
+func equalLiteral(literal, v object.Object) bool {
+	switch literal := literal.(type) {
+	case *object.Integer:
+		i, ok := v.(*object.Integer)
+		return ok && i.Value == literal.Value
+	case *object.String:
+		s, ok := v.(*object.String)
+		return ok && s.Value == literal.Value
+	}
+	// booleans and null are singletons
+	return literal == v
+}
+
+func (r *run) while(w *ast.While, env *object.Environment) (object.Object, error) {
+	for {
+		if err := r.step(w.Pos()); err != nil {
+			return nil, err
+		}
+
+		condition, err := r.expression(w.Condition, env)
+		if err != nil {
+			return nil, err
+		}
+		if !isTruthy(condition) {
+			return object.Null, nil
+		}
+
+		o, stop, err := r.loopBody(w.Body, object.NewEnclosedEnvironment(env))
+		if err != nil || stop {
+			return o, err
+		}
+	}
+}
+
+// forLoop runs the body of a for loop with each element of an array,
+// each key of a hash in sorted order or each character of a string.
+func (r *run) forLoop(f *ast.For, env *object.Environment) (object.Object, error) {
+	iterable, err := r.expression(f.Iterable, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var elements []object.Object
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		elements = iterable.Elements
+	case *object.Hash:
+		for _, p := range iterable.SortedPairs() {
+			elements = append(elements, p.Key)
+		}
+	case *object.String:
+		for _, c := range iterable.Value {
+			s := &object.String{Value: string(c)}
+			if err := r.alloc(s, f.Iterable.Pos()); err != nil {
+				return nil, err
+			}
+			elements = append(elements, s)
+		}
+	default:
+		return nil, NewError(errors.Errorf("cannot iterate over %s", iterable.Type()), f.Iterable.Pos())
+	}
+
+	for _, e := range elements {
+		if err := r.step(f.Pos()); err != nil {
+			return nil, err
+		}
+
+		// each iteration has its own variable, so closures capture its current value
+		if err := r.allocBytes(environmentSize + bindingSize); err != nil {
+			return nil, NewError(err, f.Pos())
+		}
+		iterationEnv := object.NewEnclosedEnvironment(env)
+		iterationEnv.Set(f.Variable.Value, e)
+
+		o, stop, err := r.loopBody(f.Body, iterationEnv)
+		if err != nil || stop {
+			return o, err
+		}
+	}
+
+	return object.Null, nil
+}
+
+// loopBody runs an iteration of a loop and reports whether the loop has
+// to stop, either because of a break or because the body returned, in
+// which case the ReturnValue is returned too.
+func (r *run) loopBody(body *ast.Block, env *object.Environment) (object.Object, bool, error) {
+	o, err := r.statements(body.Statements, env)
+	if signal, ok := err.(*loopSignal); ok {
+		_, stop := signal.statement.(*ast.Break)
+		return object.Null, stop, nil
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	if _, ok := o.(*object.ReturnValue); ok {
+		return o, true, nil
+	}
+	return object.Null, false, nil
+}
+
+func (r *run) call(fn object.Object, args []object.Object) (object.Object, error) {
+	if err := r.contextErr(); err != nil {
+		return nil, err
+	}
+
+	r.depth++
+	defer func() { r.depth-- }()
+	if r.limits.MaxDepth > 0 && r.depth > r.limits.MaxDepth {
+		return nil, ErrDepthLimit
+	}
+
+	switch fn := fn.(type) {
+	case *object.Function:
+		if len(args) != len(fn.Parameters) {
+			return nil, errors.Errorf("wrong number of arguments: want=%d, got=%d", len(fn.Parameters), len(args))
+		}
+
+		if err := r.allocBytes(environmentSize + int64(len(args))*bindingSize); err != nil {
+			return nil, err
+		}
+		env := object.NewEnclosedEnvironment(fn.Env)
+		for i, p := range fn.Parameters {
+			env.Set(p.Name.Value, args[i])
+		}
+
+		o, err := r.statements(fn.Body.Statements, env)
+		if err != nil {
+			return nil, err
+		}
+		return unwrapReturn(o), nil
+	case *object.Builtin:
+		if fn.Size != nil {
+			size, err := fn.Size(r.ctx, args...)
+			if err != nil {
+				if ctxErr := r.contextErr(); ctxErr != nil {
+					return nil, ctxErr
+				}
+				return nil, err
+			}
+			if err := r.allocBytes(size); err != nil {
+				return nil, err
+			}
+			return fn.Fn(args...)
+		}
+		o, err := fn.Fn(args...)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.allocBytes(sizeOf(o)); err != nil {
+			return nil, err
+		}
+		return o, nil
+	}
+
+	return nil, errors.Errorf("not a function: %s", fn.Type())
+}
+
+// throw raises the value of a throw statement as an Exception. Errors are
+// raised again as they are, keeping the position where they happened.
+func (r *run) throw(t *ast.Throw, env *object.Environment) error {
+	v, err := r.expression(t.Value, env)
+	if err != nil {
+		return err
+	}
+
+	e, ok := v.(*object.Error)
+	if !ok {
+		message := describe(v)
+		if s, ok := v.(*object.String); ok {
+			message = s.Value
+		}
+		e = &object.Error{Message: message, Value: v, Pos: t.Pos(), Stack: r.stack(t.Pos())}
+	}
+	return NewError(&Exception{Value: e}, e.Pos)
+}
+
+// try runs a try statement. Its value is the one of the body,
+// or of the catch block if the body fails.
+func (r *run) try(t *ast.Try, env *object.Environment) (object.Object, error) {
+	calls := len(r.calls)
+	o, err := r.statements(t.Body.Statements, object.NewEnclosedEnvironment(env))
+	if err != nil && t.Catch != nil {
+		if e := r.caught(err); e != nil {
+			r.calls = r.calls[:calls]
+			if err := r.allocBytes(environmentSize + bindingSize); err != nil {
+				return nil, NewError(err, t.Catch.Pos())
+			}
+			catchEnv := object.NewEnclosedEnvironment(env)
+			catchEnv.Set(t.Param.Value, e)
+			o, err = r.statements(t.Catch.Statements, catchEnv)
+		}
+	}
+	if t.Finally == nil || isFatal(err) {
+		return o, err
+	}
+
+	// the calls of an error that goes on are traced after the finally block
+	pending := append([]call{}, r.calls[calls:]...)
+	r.calls = r.calls[:calls]
+	f, finallyErr := r.statements(t.Finally.Statements, object.NewEnclosedEnvironment(env))
+	if finallyErr != nil {
+		return nil, finallyErr
+	}
+	if _, ok := f.(*object.ReturnValue); ok {
+		// a return in the finally block replaces the result, even an error
+		return f, nil
+	}
+	r.calls = append(r.calls, pending...)
+	return o, err
+}
+
+// caught returns the error value for an error a try statement can catch,
+// or nil for the ones that stop the program, like the limit errors.
+func (r *run) caught(err error) *object.Error {
+	var exception *Exception
+	if errors.As(err, &exception) {
+		return exception.Value
+	}
+
+	var e Error
+	if !errors.As(err, &e) || isFatal(err) {
+		return nil
+	}
+	return &object.Error{Message: e.err.Error(), Value: object.Null, Pos: e.pos, Stack: r.stack(e.pos)}
+}
+
+// isFatal reports whether an error stops the program, so it can't be caught.
+func isFatal(err error) bool {
+	for _, target := range []error{ErrStepLimit, ErrDepthLimit, ErrMemoryLimit, ErrTimeout, context.Canceled, context.DeadlineExceeded} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// stack returns the frames of the calls being run for an error raised at pos.
+func (r *run) stack(pos token.Position) []object.Frame {
+	frames := make([]object.Frame, 0, len(r.calls)+1)
+	for i := len(r.calls) - 1; i >= 0; i-- {
+		frames = append(frames, object.Frame{Function: r.calls[i].function, Pos: pos})
+		pos = r.calls[i].pos
+	}
+	return append(frames, object.Frame{Function: object.MainFunction, Pos: pos})
+}
+
+// calleeName returns the name a function is called by, for stack traces.
+func calleeName(e ast.Expression) string {
+	switch e := e.(type) {
+	case *ast.Identifier:
+		return e.Value
+	case *ast.Property:
+		return e.Name.Value
+	}
+	return "<anonymous>"
+}
+
+// isTruthy returns whether a value is considered true in a condition.
+// Only false and null are falsy.
+func isTruthy(o object.Object) bool {
+	switch o {
+	case object.False, object.Null:
+		return false
+	}
+	return true
+}
+
+// returnSignal unwinds the expressions that contain a return, like the
+// operands or arguments around an if, up to the enclosing statement,
+// which goes on unwinding the blocks with the ReturnValue.
+type returnSignal struct {
+	value *object.ReturnValue
+}
+
+func (s *returnSignal) Error() string {
+	return "return outside of a statement"
+}
+
+// optionalSignal unwinds an optional chain, like a?.b.c, from an optional
+// access on null up to the end of the chain, which is null.
+type optionalSignal struct{}
+
+func (s *optionalSignal) Error() string {
+	return "optional access on null"
+}
+
+// loopSignal unwinds the statements of a loop body, including the ones
+// in nested blocks, up to the loop on a break or a continue.
+type loopSignal struct {
+	statement ast.Statement
+}
+
+func (s *loopSignal) Error() string {
+	return s.statement.TokenLiteral() + " outside of a loop"
+}
+
+func unwrapReturn(o object.Object) object.Object {
+	if r, ok := o.(*object.ReturnValue); ok {
+		return r.Value
+	}
+	return o
+}
@@ -0,0 +1,708 @@
+package evaluator_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/evaluator"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lexer"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/object"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/parser"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+)
+
+func TestEvaluatorEval(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "arithmetic",
+			input: `(5 + 10 * 2 + 15 / 3) * 2 + -10`,
+			want:  "50",
+		},
+		{
+			name:  "comparisons",
+			input: `(1 < 2) == true != (3 > 4)`,
+			want:  "true",
+		},
+		{
+			name:  "bang",
+			input: `!!5`,
+			want:  "true",
+		},
+		{
+			name:  "let bindings",
+			input: `let a = 5; let b = a * 2; b - a`,
+			want:  "5",
+		},
+		{
+			name:  "if without else",
+			input: `if (1 > 2) { 10 }`,
+			want:  "null",
+		},
+		{
+			name:  "if else",
+			input: `if (1 > 2) { 10 } else { 20 }`,
+			want:  "20",
+		},
+		{
+			name:  "nested return",
+			input: `if (true) { if (true) { return 10; } return 1; } 2`,
+			want:  "10",
+		},
+		{
+			name:  "closures",
+			input: `let adder = fn(x) { fn(y) { x + y } }; let addTwo = adder(2); addTwo(3)`,
+			want:  "5",
+		},
+		{
+			name:  "recursion",
+			input: `let fib = fn(n) { if (n < 2) { return n; } fib(n - 1) + fib(n - 2) }; fib(10)`,
+			want:  "55",
+		},
+		{
+			name:  "return inside function doesn't stop the program",
+			input: `let f = fn() { return 1; 2 }; f() + 1`,
+			want:  "2",
+		},
+		{
+			name:  "closures keep their own environment",
+			input: `let adder = fn(x) { fn(y) { x + y } }; let addOne = adder(1); let addTen = adder(10); let x = 100; addOne(1) + addTen(1)`,
+			want:  "13",
+		},
+		{
+			name:  "closures capture the enclosing scopes",
+			input: `let a = 1; let f = fn(b) { fn(c) { fn(d) { a + b + c + d } } }; f(2)(3)(4)`,
+			want:  "10",
+		},
+		{
+			name:  "higher order functions",
+			input: `let twice = fn(f, x) { f(f(x)) }; let compose = fn(f, g) { fn(x) { g(f(x)) } }; twice(compose(fn(x) { x * 2 }, fn(x) { x + 1 }), 1)`,
+			want:  "7",
+		},
+		{
+			name:  "functions are values",
+			input: `let f = fn(x) { x }; let g = f; g == f`,
+			want:  "true",
+		},
+		{
+			name:  "return unwinds nested blocks",
+			input: `let f = fn(x) { if (x > 0) { if (x > 1) { return 2; } return 1; } 0 }; f(5) * 100 + f(1) * 10 + f(0)`,
+			want:  "210",
+		},
+		{
+			name:  "return from an if used as a value",
+			input: `let f = fn(x) { let y = if (x) { return 1; } else { 2 }; y * 10 }; f(true) + f(false)`,
+			want:  "21",
+		},
+		{
+			name:  "return from an operand",
+			input: `let f = fn() { 1 + if (true) { return 5; } }; f()`,
+			want:  "5",
+		},
+		{
+			name:  "return from an argument",
+			input: `let id = fn(x) { x }; let f = fn() { id(if (true) { return 5; }) + 1 }; f()`,
+			want:  "5",
+		},
+		{
+			name:  "top level return from an if used as a value",
+			input: `let x = if (true) { return 3; }; 4`,
+			want:  "3",
+		},
+		{
+			name:    "undefined identifier",
+			input:   `let x = 1; y`,
+			wantErr: "runtime error at 1:12: identifier not found: y",
+		},
+		{
+			name:    "type mismatch",
+			input:   `5 + true`,
+			wantErr: "runtime error at 1:3: type mismatch: INTEGER + BOOLEAN",
+		},
+		{
+			name:  "strings",
+			input: `let s = "a\tb"; s + "\"c\"" == "a\tb\"c\""`,
+			want:  "true",
+		},
+		{
+			name:  "string concatenation",
+			input: `"a\tb" + "c"`,
+			want:  `"a\tbc"`,
+		},
+		{
+			name:  "return from a while loop",
+			input: `let f = fn(n) { while (true) { if (n > 3) { return n; } return 0; } }; f(5) + f(1)`,
+			want:  "5",
+		},
+		{
+			name:  "return from a for loop",
+			input: `let find = fn(xs) { for (x in xs) { if (x * x > 10) { return x; } } -1 }; find(range(10)) + find(range(3))`,
+			want:  "3",
+		},
+		{
+			name:  "loops are null",
+			input: `for (x in range(3)) { x }`,
+			want:  "null",
+		},
+		{
+			name:  "loop variables are scoped to the body",
+			input: `let x = 10; for (x in range(3)) { x } x`,
+			want:  "10",
+		},
+		{
+			name:  "array and hash literals",
+			input: `let xs = [1, 2 + 3, "a"]; let h = {"a": xs, 1: true}; [xs[1], h["a"][2], h[1], h["missing"]]`,
+			want:  `[5, "a", true, null]`,
+		},
+		{
+			name:  "assignments",
+			input: `let x = 1; x = x + 1; x += 10; x *= 2; x -= 4; x /= 2; x`,
+			want:  "10",
+		},
+		{
+			name:  "assignment is right associative and has the assigned value",
+			input: `let a = 0; let b = 0; let c = (a = b = 3) + 1; [a, b, c]`,
+			want:  "[3, 3, 4]",
+		},
+		{
+			name:  "assignment changes the closest binding",
+			input: `let n = 0; let inc = fn() { n += 1 }; inc(); inc(); let f = fn() { let n = 10; n = 20 }; f(); n`,
+			want:  "2",
+		},
+		{
+			name:  "index assignments",
+			input: `let xs = [1, 2]; let h = {"k": 1}; let ys = xs; xs[0] = 10; h["k"] += 2; h["new"] = xs[0]; [ys, h]`,
+			want:  `[[10, 2], {"k": 3, "new": 10}]`,
+		},
+		{
+			name:  "string compound assignment",
+			input: `let s = "a"; s += "b"; s`,
+			want:  `"ab"`,
+		},
+		{
+			name:  "null",
+			input: `let x = null; [x, x == null, !null, if (null) { 1 } else { 2 }]`,
+			want:  "[null, true, true, 2]",
+		},
+		{
+			name:  "comparisons with null",
+			input: `let x = 1; let f = fn() {}; [x == null, x != null, null == "a", null != [], {} == null, f != null, null != null]`,
+			want:  "[false, true, false, true, false, true, false]",
+		},
+		{
+			name:    "arithmetic with null",
+			input:   `1 + null`,
+			wantErr: "runtime error at 1:3: type mismatch: INTEGER + NULL",
+		},
+		{
+			name:  "properties",
+			input: `let p = {"name": "ann", "age": 30}; p.age += 1; p.city = "rome"; [p.name, p.age, p.city, p.missing]`,
+			want:  `["ann", 31, "rome", null]`,
+		},
+		{
+			name:  "optional chaining",
+			input: `let p = null; let q = {"tags": ["a"], "f": fn(x) { x * 2 }}; [p?.name, p?[0], p?.(1), q?.tags?[0], q.f?.(2), q.g?.(3)]`,
+			want:  `[null, null, null, "a", 4, null]`,
+		},
+		{
+			name:  "optional chains short-circuit",
+			input: `let a = null; let f = null; let n = 0; [a?.b.c, a?.b[0], f?.(1)(2), a?.b.c(n = 1), n]`,
+			want:  `[null, null, null, null, 0]`,
+		},
+		{
+			name:  "optional calls don't evaluate their arguments",
+			input: `let n = 0; let f = null; f?.(n = 1); n`,
+			want:  "0",
+		},
+		{
+			name:  "null coalescing",
+			input: `let h = {"a": false}; [h.a ?? 1, h.b ?? 2, null ?? null ?? 3, 0 ?? undefined]`,
+			want:  "[false, 2, 3, 0]",
+		},
+		{
+			name:    "property of null",
+			input:   `let p = null; p.name`,
+			wantErr: "runtime error at 1:16: property access not supported: NULL",
+		},
+		{
+			name:    "optional access only checks its left side",
+			input:   `let p = {"a": null}; p?.a.b`,
+			wantErr: "runtime error at 1:26: property access not supported: NULL",
+		},
+		{
+			name:  "catching a thrown value",
+			input: `try { throw "boom"; 1 } catch (e) { [e.message, e.value, e.line, e.column] }`,
+			want:  `["boom", "boom", 1, 7]`,
+		},
+		{
+			name:  "thrown values that are not strings",
+			input: `try { throw {"code": 2} } catch (e) { [e.message, e.value.code] }`,
+			want:  `["{\"code\": 2}", 2]`,
+		},
+		{
+			name:  "catching runtime errors",
+			input: `let msgs = []; for (f in [fn() { 1 + "a" }, fn() { [1][3] }, fn() { y }]) { try { f() } catch (e) { msgs = push(msgs, e.message) } } msgs`,
+			want:  `["type mismatch: INTEGER + STRING", "index out of range: 3 with length 1", "identifier not found: y"]`,
+		},
+		{
+			name: "stack traces",
+			input: `let inner = fn() { 1 / 0 };
+let outer = fn() { inner() };
+try { outer() } catch (e) { e.stack }`,
+			want: `["inner at 1:22", "outer at 2:25", "<main> at 3:12"]`,
+		},
+		{
+			name: "stack traces of rethrown errors",
+			input: `let f = fn() { throw "x" };
+let g = fn() { try { f() } catch (e) { throw e } };
+try { g() } catch (e) { [e.line, e.stack] }`,
+			want: `[1, ["f at 1:16", "g at 2:23", "<main> at 3:8"]]`,
+		},
+		{
+			name:  "try value",
+			input: `let f = fn(x) { try { 10 / x } catch (e) { -1 } }; [f(2), f(0)]`,
+			want:  "[5, -1]",
+		},
+		{
+			name:  "finally runs in any case",
+			input: `let log = []; let f = fn(x) { try { if (x) { return 1; } throw "e" } catch (e) { log = push(log, "catch") } finally { log = push(log, "finally") } }; f(true); f(false); log`,
+			want:  `["finally", "catch", "finally"]`,
+		},
+		{
+			name:  "return in finally replaces the error",
+			input: `let f = fn() { try { throw "lost" } finally { return 2; } }; f()`,
+			want:  "2",
+		},
+		{
+			name:  "finally with break",
+			input: `let n = 0; while (true) { try { break; } finally { n += 1 } } n`,
+			want:  "1",
+		},
+		{
+			name:  "match literals",
+			input: `let name = fn(n) { match (n) { 0 => "zero", -1 => "minus one", "a" => "letter", true => "yes", null => "nothing", _ => "other" } }; [name(0), name(-1), name("a"), name(true), name(null), name(2)]`,
+			want:  `["zero", "minus one", "letter", "yes", "nothing", "other"]`,
+		},
+		{
+			name:  "match arrays and hashes",
+			input: `let f = fn(x) { match (x) { [] => 0, [a] => a, [a, [b, _]] => a + b, {name, "age": 1} => name, {name} => name + "!", n => n } }; [f([]), f([1]), f([1, [2, 3]]), f([1, [2]]), f({"name": "a", "age": 1}), f({"name": "b"}), f({})]`,
+			want:  `[0, 1, 3, [1, [2]], "a", "b!", {}]`,
+		},
+		{
+			name:  "match guards",
+			input: `let sign = fn(n) { match (n) { 0 => 0, x if x > 0 => 1, _ => -1 } }; [sign(0), sign(5), sign(-5)]`,
+			want:  "[0, 1, -1]",
+		},
+		{
+			name:  "match bindings are scoped to their arm",
+			input: `let x = 1; let y = match ([2]) { [x] if x > 5 => x, [z] => x + z }; x + y`,
+			want:  "4",
+		},
+		{
+			name:  "no match can be caught",
+			input: `try { match (3) { 1 => 1 } } catch (e) { e.message }`,
+			want:  `"no match for 3"`,
+		},
+		{
+			name:    "no match",
+			input:   `let x = [1, 2]; match (x) { [a] => a }`,
+			wantErr: "runtime error at 1:17: no match for [1, 2]",
+		},
+		{
+			name:  "array destructuring",
+			input: `let [a, [b, _], c = a + b, ...rest] = [1, [2, 3]]; let [first, ...others] = [4, 5, 6]; [a, b, c, rest, first, others]`,
+			want:  "[1, 2, 3, [], 4, [5, 6]]",
+		},
+		{
+			name:  "hash destructuring",
+			input: `let {name, "age": age, n: {x} = {"x": 0}, missing = null} = {"name": "a", "age": 1, "n": {"x": 2}}; [name, age, x, missing]`,
+			want:  `["a", 1, 2, null]`,
+		},
+		{
+			name:  "defaults are only evaluated when missing",
+			input: `let calls = 0; let f = fn() { calls += 1; 0 }; let [a = f(), b = f()] = [1]; let {c = f()} = {"c": 2}; [a, b, c, calls]`,
+			want:  "[1, 0, 2, 1]",
+		},
+		{
+			name:  "match with rest and defaults",
+			input: `let f = fn(xs) { match (xs) { [] => 0, [x, y = 10] => x + y, [x, ...ys] => len(ys) } }; [f([]), f([1]), f([1, 2]), f([1, 2, 3])]`,
+			want:  "[0, 11, 3, 2]",
+		},
+		{
+			name:    "destructuring an array too short",
+			input:   `let [a, b] = [1];`,
+			wantErr: "runtime error at 1:1: cannot destructure [1]",
+		},
+		{
+			name:    "destructuring a non hash",
+			input:   `let {a} = [1];`,
+			wantErr: "runtime error at 1:1: cannot destructure [1]",
+		},
+		{
+			name:  "template",
+			input: "let name = \"world\"; let xs = [1, 2]; `hello ${name}: ${xs} ${ {\"a\": 1}[\"a\"] + 1 } \\` \\${x} $ ${`nested ${null}`}`",
+			want:  `"hello world: [1, 2] 2 ` + "`" + ` ${x} $ nested null"`,
+		},
+		{
+			name:    "error in template",
+			input:   "let x = 1;\n`a ${x + true}`",
+			wantErr: "runtime error at 2:8: type mismatch: INTEGER + BOOLEAN",
+		},
+		{
+			name:    "uncaught exception",
+			input:   `let f = fn() { throw "boom"; }; try { f() } finally { 1 }`,
+			wantErr: "runtime error at 1:16: uncaught exception: boom",
+		},
+		{
+			name:    "error in catch",
+			input:   `try { throw 1 } catch (e) { e + 1 }`,
+			wantErr: "runtime error at 1:31: type mismatch: ERROR + INTEGER",
+		},
+		{
+			name:    "assignment to an undefined variable",
+			input:   `x = 1`,
+			wantErr: "runtime error at 1:1: identifier not found: x",
+		},
+		{
+			name:    "index out of range",
+			input:   `let xs = [1]; xs[1] = 2`,
+			wantErr: "runtime error at 1:18: index out of range: 1 with length 1",
+		},
+		{
+			name:    "unusable hash key",
+			input:   `{[1]: 2}`,
+			wantErr: "runtime error at 1:2: unusable as hash key: ARRAY",
+		},
+		{
+			name:    "index of a non collection",
+			input:   `let n = 1; n[0]`,
+			wantErr: "runtime error at 1:13: index operator not supported: INTEGER",
+		},
+		{
+			name:    "compound assignment type mismatch",
+			input:   `let x = 1; x += "a"`,
+			wantErr: "runtime error at 1:14: type mismatch: INTEGER + STRING",
+		},
+		{
+			name:    "iterating over a non iterable",
+			input:   `for (x in 1) {}`,
+			wantErr: "runtime error at 1:11: cannot iterate over INTEGER",
+		},
+		{
+			name:    "imports without importer",
+			input:   `import "lib";`,
+			wantErr: `runtime error at 1:1: imports are not supported`,
+		},
+		{
+			name:    "unknown operator",
+			input:   `-true`,
+			wantErr: "runtime error at 1:1: unknown operator: -BOOLEAN",
+		},
+		{
+			name:    "division by zero",
+			input:   `let f = fn(x) { 10 / x }; f(0)`,
+			wantErr: "runtime error at 1:20: division by zero",
+		},
+		{
+			name:    "wrong number of arguments",
+			input:   `let f = fn(x) { x }; f(1, 2)`,
+			wantErr: "runtime error at 1:23: wrong number of arguments: want=1, got=2",
+		},
+		{
+			name:    "wrong number of arguments to a closure",
+			input:   `let adder = fn(x) { fn(y) { x + y } }; adder(1)()`,
+			wantErr: "runtime error at 1:48: wrong number of arguments: want=1, got=0",
+		},
+		{
+			name:    "captured variables are not visible to the caller",
+			input:   `let f = fn(x) { fn() { x } }; f(1)(); x`,
+			wantErr: "runtime error at 1:39: identifier not found: x",
+		},
+		{
+			name:    "calling a non function",
+			input:   `let x = 1; x()`,
+			wantErr: "runtime error at 1:13: not a function: INTEGER",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			l := lexer.New(lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(tc.input))))
+			root, err := parser.New(l).Parse()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			got, err := evaluator.New().Eval(context.Background(), root, object.NewEnvironment())
+			if tc.wantErr != "" {
+				g.Expect(err).To(MatchError(tc.wantErr))
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got.Inspect()).To(Equal(tc.want))
+		})
+	}
+}
+
+func TestEvaluatorEvalLoops(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "for over an array",
+			input: `for (x in range(3)) { puts(x * 2) }`,
+			want:  "0\n2\n4\n",
+		},
+		{
+			name:  "for over a string",
+			input: `for (c in "añb") { puts(c) }`,
+			want:  "a\nñ\nb\n",
+		},
+		{
+			name:  "for over a hash",
+			input: `for (k in h) { puts(k) }`,
+			want:  "a\nb\n",
+		},
+		{
+			name:  "break",
+			input: `for (x in range(10)) { if (x == 2) { break; } puts(x) } puts("done")`,
+			want:  "0\n1\ndone\n",
+		},
+		{
+			name:  "continue",
+			input: `for (x in range(4)) { if (x == 1) { continue; } puts(x) }`,
+			want:  "0\n2\n3\n",
+		},
+		{
+			name:  "break only stops the innermost loop",
+			input: `for (x in range(2)) { for (y in range(5)) { if (y > x) { break; } puts(str(x) + str(y)) } }`,
+			want:  "00\n10\n11\n",
+		},
+		{
+			name:  "while with break",
+			input: `while (true) { puts("once"); break; puts("never") }`,
+			want:  "once\n",
+		},
+		{
+			name:  "break from an if used as a value",
+			input: `for (x in range(3)) { let y = if (x == 1) { break; } else { x }; puts(y) }`,
+			want:  "0\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			l := lexer.New(lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(tc.input))))
+			root, err := parser.New(l).Parse()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			out := &bytes.Buffer{}
+			e := evaluator.New()
+			e.Builtins = evaluator.DefaultBuiltins(out)
+			env := object.NewEnvironment()
+			h := object.NewHash()
+			h.Set(&object.String{Value: "b"}, &object.Integer{Value: 2})
+			h.Set(&object.String{Value: "a"}, &object.Integer{Value: 1})
+			env.Set("h", h)
+
+			_, err = e.Eval(context.Background(), root, env)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(out.String()).To(Equal(tc.want))
+		})
+	}
+}
+
+// mapImporter imports the modules from a map of their exports by path.
+type mapImporter map[string]map[string]object.Object
+
+func (m mapImporter) Import(_ context.Context, path string) (map[string]object.Object, error) {
+	exports, ok := m[path]
+	if !ok {
+		return nil, evaluator.NewError(errors.New("module not found"), token.Position{Line: 7, Column: 1})
+	}
+	return exports, nil
+}
+
+func TestEvaluatorEvalImports(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "imported names",
+			input: `import "lib"; let x = two + 1; export let y = x * two; y`,
+			want:  "6",
+		},
+		{
+			name:    "import error",
+			input:   "let a = 1;\nimport \"missing\";",
+			wantErr: `runtime error at 2:1: importing "missing": runtime error at 7:1: module not found`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			l := lexer.New(lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(tc.input))))
+			root, err := parser.New(l).Parse()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			e := evaluator.New()
+			e.Importer = mapImporter{"lib": {"two": &object.Integer{Value: 2}}}
+			got, err := e.Eval(context.Background(), root, object.NewEnvironment())
+			if tc.wantErr != "" {
+				g.Expect(err).To(MatchError(tc.wantErr))
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got.Inspect()).To(Equal(tc.want))
+		})
+	}
+}
+
+func TestEvaluatorEvalCancelled(t *testing.T) {
+	g := NewWithT(t)
+	input := `let loop = fn(n) { loop(n + 1) }; loop(0)`
+	l := lexer.New(lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))))
+	root, err := parser.New(l).Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = evaluator.New().Eval(ctx, root, object.NewEnvironment())
+	g.Expect(err).To(MatchError(context.Canceled))
+}
+
+func TestEvaluatorEvalLimits(t *testing.T) {
+	loop := `let loop = fn(n) { loop(n + 1) }; loop(0)`
+	// the Inspect form of a has 2^20 ones, but a only 21 arrays
+	shared := `let a = [1]; let i = 0; while (i < 20) { a = [a, a]; i += 1; } `
+	testCases := []struct {
+		name    string
+		input   string
+		limits  evaluator.Limits
+		wantErr error
+	}{
+		{
+			name:    "steps",
+			input:   loop,
+			limits:  evaluator.Limits{MaxSteps: 1000},
+			wantErr: evaluator.ErrStepLimit,
+		},
+		{
+			name:    "steps of an empty loop",
+			input:   `for (x in range(100000)) {}`,
+			limits:  evaluator.Limits{MaxSteps: 1000},
+			wantErr: evaluator.ErrStepLimit,
+		},
+		{
+			name:    "infinite loop",
+			input:   `while (true) {}`,
+			limits:  evaluator.Limits{Timeout: 10 * time.Millisecond},
+			wantErr: evaluator.ErrTimeout,
+		},
+		{
+			name:    "depth",
+			input:   loop,
+			limits:  evaluator.Limits{MaxDepth: 100},
+			wantErr: evaluator.ErrDepthLimit,
+		},
+		{
+			name:    "memory",
+			input:   loop,
+			limits:  evaluator.Limits{MaxMemory: 1 << 16},
+			wantErr: evaluator.ErrMemoryLimit,
+		},
+		{
+			name:    "default depth",
+			input:   `let f = fn() { f() }; f()`,
+			limits:  evaluator.DefaultLimits,
+			wantErr: evaluator.ErrDepthLimit,
+		},
+		{
+			name:    "memory of a builtin",
+			input:   `range(1000000)`,
+			limits:  evaluator.Limits{MaxMemory: 1 << 20},
+			wantErr: evaluator.ErrMemoryLimit,
+		},
+		{
+			name:   "memory of the elements of an existing array",
+			input:  `let a = range(10000); rest(a); first(a); last(a)`,
+			limits: evaluator.Limits{MaxMemory: 1 << 19},
+		},
+		{
+			name:    "memory of str of arrays sharing elements",
+			input:   shared + `len(str(a))`,
+			limits:  evaluator.Limits{MaxMemory: 1 << 20, Timeout: 2 * time.Second},
+			wantErr: evaluator.ErrMemoryLimit,
+		},
+		{
+			name:    "memory of puts of arrays sharing elements",
+			input:   shared + `puts(a)`,
+			limits:  evaluator.Limits{MaxMemory: 1 << 20, Timeout: 2 * time.Second},
+			wantErr: evaluator.ErrMemoryLimit,
+		},
+		{
+			name:    "memory of a template of arrays sharing elements",
+			input:   shared + "`${a}`",
+			limits:  evaluator.Limits{MaxMemory: 1 << 20, Timeout: 2 * time.Second},
+			wantErr: evaluator.ErrMemoryLimit,
+		},
+		{
+			name:    "timeout of str of arrays sharing elements",
+			input:   `let a = [1]; a[0] = a; let i = 0; while (i < 40) { a = [a, a]; i += 1; } str(a)`,
+			limits:  evaluator.Limits{Timeout: 50 * time.Millisecond},
+			wantErr: evaluator.ErrTimeout,
+		},
+		{
+			name:    "timeout",
+			input:   loop,
+			limits:  evaluator.Limits{Timeout: 10 * time.Millisecond, MaxDepth: 1 << 20},
+			wantErr: evaluator.ErrTimeout,
+		},
+		{
+			name:    "limits can't be caught",
+			input:   `let loop = fn(n) { loop(n + 1) }; try { loop(0) } catch (e) { 1 } finally { while (true) {} }`,
+			limits:  evaluator.Limits{MaxDepth: 100, MaxSteps: 10000},
+			wantErr: evaluator.ErrDepthLimit,
+		},
+		{
+			name:   "within limits",
+			input:  `let f = fn(n) { if (n < 1) { return 0; } f(n - 1) }; f(10)`,
+			limits: evaluator.Limits{MaxSteps: 1000, MaxDepth: 11, MaxMemory: 1 << 12, Timeout: time.Minute},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			l := lexer.New(lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(tc.input))))
+			root, err := parser.New(l).Parse()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			e := evaluator.New()
+			e.Limits = tc.limits
+			_, err = e.Eval(context.Background(), root, object.NewEnvironment())
+			if tc.wantErr == nil {
+				g.Expect(err).NotTo(HaveOccurred())
+				return
+			}
+
+			g.Expect(err).To(MatchError(tc.wantErr))
+			g.Expect(err).To(BeAssignableToTypeOf(evaluator.Error{}))
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package evaluator
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/object"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+)
+
+// Limits bounds the resources a program can use. A zero value disables a limit.
+type Limits struct {
+	// MaxSteps is the number of statements and expressions that can be evaluated.
+	MaxSteps int64
+	// MaxDepth is the number of nested function calls.
+	MaxDepth int
+	// MaxMemory is the number of bytes that can be allocated for objects.
+	// Memory is never given back, so this bounds the total allocations,
+	// and sizes are estimates of what the objects take in memory.
+	MaxMemory int64
+	// Timeout is the wall-clock time a program can run for.
+	Timeout time.Duration
+}
+
+// DefaultLimits only bounds the depth of function calls, far above what
+// most programs use, so runaway recursion can't exhaust the stack. Along
+// with the nesting depth bounded by parser.DefaultLimits, it keeps the
+// stack well below the Go maximum.
+var DefaultLimits = Limits{MaxDepth: 1000}
+
+var (
+	ErrStepLimit   = errors.New("step limit exceeded")
+	ErrDepthLimit  = errors.New("call depth limit exceeded")
+	ErrMemoryLimit = errors.New("memory limit exceeded")
+	ErrTimeout     = errors.New("timeout exceeded")
+)
+
+// Estimated sizes in bytes of objects and environments.
+const (
+	headerSize      = 16
+	wordSize        = 8
+	environmentSize = 48
+	bindingSize     = 32
+)
+
+// step accounts for the evaluation of the node at pos and checks whether
+// the program can go on.
+func (r *run) step(pos token.Position) error {
+	if err := r.contextErr(); err != nil {
+		return NewError(err, pos)
+	}
+
+	r.steps++
+	if r.limits.MaxSteps > 0 && r.steps > r.limits.MaxSteps {
+		return NewError(ErrStepLimit, pos)
+	}
+	return nil
+}
+
+// contextErr returns the context error, replacing it by ErrTimeout
+// when the deadline is the one set by Limits.Timeout.
+func (r *run) contextErr() error {
+	err := r.ctx.Err()
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, context.DeadlineExceeded) && r.parent.Err() == nil {
+		return ErrTimeout
+	}
+	return err
+}
+
+func (r *run) alloc(o object.Object, pos token.Position) error {
+	if err := r.allocBytes(sizeOf(o)); err != nil {
+		return NewError(err, pos)
+	}
+	return nil
+}
+
+// fits checks that n more bytes are within the memory limit, without
+// accounting for them, before building an object accounted for later.
+func (r *run) fits(n int64) error {
+	if r.limits.MaxMemory > 0 && r.memory+n > r.limits.MaxMemory {
+		return ErrMemoryLimit
+	}
+	return nil
+}
+
+func (r *run) allocBytes(n int64) error {
+	r.memory += n
+	if r.limits.MaxMemory > 0 && r.memory > r.limits.MaxMemory {
+		return ErrMemoryLimit
+	}
+	return nil
+}
+
+// sizeOf estimates the memory used by an object and the objects it holds.
+// Booleans and null are shared, so they don't use any.
+func sizeOf(o object.Object) int64 {
+	return size(o, map[object.Object]bool{})
+}
+
+// size estimates the memory used by an object, skipping the arrays and
+// hashes already counted, which are shared or contain themselves.
+func size(o object.Object, counted map[object.Object]bool) int64 {
+	switch o := o.(type) {
+	case *object.Integer:
+		return headerSize + wordSize
+	case *object.String:
+		return headerSize + int64(len(o.Value))
+	case *object.Array:
+		if counted[o] {
+			return 0
+		}
+		counted[o] = true
+		n := int64(headerSize)
+		for _, e := range o.Elements {
+			n += wordSize + size(e, counted)
+		}
+		return n
+	case *object.Hash:
+		if counted[o] {
+			return 0
+		}
+		counted[o] = true
+		n := int64(headerSize)
+		for _, p := range o.Pairs {
+			n += bindingSize + size(p.Key, counted) + size(p.Value, counted)
+		}
+		return n
+	case *object.Function:
+		return headerSize + 3*wordSize
+	case *object.Boolean, *object.NullValue:
+		return 0
+	}
+	return headerSize
+}
@@ -0,0 +1,41 @@
+// Package format prints Monkey programs in their canonical form.
+package format
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lexer"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/parser"
+)
+
+// Node writes the canonical form of a program to w, including its comments.
+func Node(w io.Writer, root *ast.Root) error {
+	p := newPrinter(root.Comments)
+	p.program(root)
+	_, err := w.Write(p.buf.Bytes())
+	return err
+}
+
+// Source formats a Monkey program. It returns an error if the program
+// can't be parsed, since it's not possible to format it without losing code.
+func Source(src []byte) ([]byte, error) {
+	l := lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(bytes.NewReader(src))),
+	)
+
+	root, err := parser.New(l).Parse()
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing source")
+	}
+
+	b := &bytes.Buffer{}
+	if err := Node(b, root); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,299 @@
+package format_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/format"
+)
+
+func TestSource(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "empty",
+			input: ``,
+			want:  ``,
+		},
+		{
+			name:  "spacing and semicolons",
+			input: `let x=5+  3*2   let y =x;x==y`,
+			want: `let x = 5 + 3 * 2;
+let y = x;
+x == y;
+`,
+		},
+		{
+			name:  "parentheses",
+			input: `(a + b) * c; a + (b * c); a - (b - c); (a - b) - c; -(a + b); !(-a); (-f)(x); (a < b) == true`,
+			want: `(a + b) * c;
+a + b * c;
+a - (b - c);
+a - b - c;
+-(a + b);
+!-a;
+(-f)(x);
+a < b == true;
+`,
+		},
+		{
+			name: "functions and blocks",
+			input: `let add = fn(a,b){a+b};
+let max=fn(a : int, b: int) :int { if(a>b){return a;}else{ return b } };
+let noop = fn() {};
+add(1,max(2,3))`,
+			want: `let add = fn(a, b) {
+	a + b;
+};
+let max = fn(a: int, b: int): int {
+	if (a > b) {
+		return a;
+	} else {
+		return b;
+	}
+};
+let noop = fn() {};
+add(1, max(2, 3));
+`,
+		},
+		{
+			name:  "type annotations",
+			input: `let f : fn( [int] , {string:bool} ):int = g;`,
+			want: `let f: fn([int], {string: bool}): int = g;
+`,
+		},
+		{
+			name: "loops",
+			input: `while(x<3){if(x==1){break}continue}
+for ( i  in range(3) ) { puts(i) }`,
+			want: `while (x < 3) {
+	if (x == 1) {
+		break;
+	}
+	continue;
+}
+for (i in range(3)) {
+	puts(i);
+}
+`,
+		},
+		{
+			name:  "collections and assignments",
+			input: `let xs=[1,2 ,[ ]];let h={ "a":xs[0] ,1:{} };x=y=2;xs[1]+=(h["a"]=3)*2;(-f)[0];-xs[0]`,
+			want: `let xs = [1, 2, []];
+let h = {"a": xs[0], 1: {}};
+x = y = 2;
+xs[1] += (h["a"] = 3) * 2;
+(-f)[0];
+-xs[0];
+`,
+		},
+		{
+			name:  "null and optional chaining",
+			input: `a?.b.c??null;(a??b)==c;a??(b==c);xs ?[0];f?.(1) ;p.name=-q.n`,
+			want: `a?.b.c ?? null;
+(a ?? b) == c;
+a ?? b == c;
+xs?[0];
+f?.(1);
+p.name = -q.n;
+`,
+		},
+		{
+			name: "exceptions",
+			input: `try{throw   "boom"}catch(e){puts(e.message)}finally{done()}
+try { f() } finally { }`,
+			want: `try {
+	throw "boom";
+} catch (e) {
+	puts(e.message);
+} finally {
+	done();
+}
+try {
+	f();
+} finally {}
+`,
+		},
+		{
+			name: "match",
+			input: `let s = match(x){0=>"zero",[a,_]if a>0=>str(a),{name,"n":-1}=>name,_=>match (y) {}};
+match (b) { true => 1 }`,
+			want: `let s = match (x) {
+	0 => "zero",
+	[a, _] if a > 0 => str(a),
+	{name, "n": -1} => name,
+	_ => match (y) {},
+};
+match (b) {
+	true => 1,
+}
+`,
+		},
+		{
+			name:  "destructuring",
+			input: `let[a,_=1,...rest]=xs;let {name,age=0,"k":[b],n:{c}}:{string:int}=p`,
+			want: `let [a, _ = 1, ...rest] = xs;
+let {name, age = 0, "k": [b], n: {c}}: {string: int} = p;
+`,
+		},
+		{
+			name:  "templates",
+			input: "let s=`a  ${ b+1 }\n\\t${ `${c}` }`;puts( s )",
+			want:  "let s = `a  ${b + 1}\n\\t${`${c}`}`;\nputs(s);\n",
+		},
+		{
+			name:  "multiline template",
+			input: "let s = `a\n\nb`; let t = 1",
+			want:  "let s = `a\n\nb`;\nlet t = 1;\n",
+		},
+		{
+			name: "modules",
+			input: `import   "lib/strings"
+export let   greet=fn(name){"hi\t" + name}`,
+			want: `import "lib/strings";
+export let greet = fn(name) {
+	"hi\t" + name;
+};
+`,
+		},
+		{
+			name: "comments and blank lines",
+			input: `// header
+
+
+let x = 1; // one
+let y = 2;
+
+
+
+// about f
+let f = fn() {
+	// inside
+	x // trailing x
+
+	// before y
+	y
+	// last
+};
+// end`,
+			want: `// header
+
+let x = 1; // one
+let y = 2;
+
+// about f
+let f = fn() {
+	// inside
+	x; // trailing x
+
+	// before y
+	y;
+	// last
+};
+// end
+`,
+		},
+		{
+			name:  "trailing comment after a block",
+			input: "if (x) { 1 } else { 2 } // c\nlet y = 1;",
+			want: `if (x) {
+	1;
+} else {
+	2;
+} // c
+let y = 1;
+`,
+		},
+		{
+			name: "comments in lists",
+			input: `let h = {
+	"a": 1, // one
+	// before b
+	"b": 2,
+};
+f(
+	1, // one
+	[2, // two
+	3]
+);
+let e = {
+	// nothing
+};
+y;`,
+			want: `let h = {
+	"a": 1, // one
+	// before b
+	"b": 2
+};
+f(
+	1, // one
+	[
+		2, // two
+		3
+	]
+);
+let e = {
+	// nothing
+};
+y;
+`,
+		},
+		{
+			name: "comments in a match",
+			input: `match (x) {
+	// first
+	1 => 2, // one
+	_ => 3
+	// last
+}`,
+			want: `match (x) {
+	// first
+	1 => 2, // one
+	_ => 3,
+	// last
+}
+`,
+		},
+		{
+			name:  "comment inside an expression",
+			input: "let x = 1 + // c\n\t2;\ny;",
+			want: `let x = 1 + 2; // c
+y;
+`,
+		},
+		{
+			name:  "nested negations",
+			input: `-(-x); -(-(-x)); !(!x); -(!x)`,
+			want: `-(-x);
+-(-(-x));
+!!x;
+-!x;
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := format.Source([]byte(tc.input))
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(string(got)).To(Equal(tc.want))
+
+			again, err := format.Source(got)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(string(again)).To(Equal(string(got)), "formatting is not idempotent")
+		})
+	}
+}
+
+func TestSourceParseError(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := format.Source([]byte(`let x = ;`))
+	g.Expect(err).To(MatchError(ContainSubstring("parsing source")))
+}
@@ -0,0 +1,55 @@
+package format
+
+import "github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+
+// precedence mirrors the precedences used by the parser,
+// so the printer knows when an expression needs parentheses.
+type precedence int
+
+const (
+	_ precedence = iota
+	lowest
+	assign
+	coalesce
+	equals
+	lessGreater
+	sum
+	product
+	prefix
+	call
+	index
+	// primary is the precedence of expressions that never need parentheses.
+	primary
+)
+
+var infixPrecedences = map[ast.InfixOperator]precedence{
+	ast.NullCoalesce:   coalesce,
+	ast.Equal:          equals,
+	ast.NotEqual:       equals,
+	ast.LessThan:       lessGreater,
+	ast.GreaterThan:    lessGreater,
+	ast.Addition:       sum,
+	ast.Subtraction:    sum,
+	ast.Multiplication: product,
+	ast.Division:       product,
+}
+
+func precedenceOf(e ast.Expression) precedence {
+	switch e := e.(type) {
+	case *ast.Infix:
+		return infixPrecedences[e.Operator]
+	case *ast.Prefix:
+		return prefix
+	case *ast.Call:
+		return call
+	case *ast.Index, *ast.Property:
+		return index
+	case *ast.Assign:
+		return assign
+	case *ast.If, *ast.FunctionLiteral, *ast.Match:
+		// they would swallow any operator that follows them
+		return lowest
+	}
+
+	return primary
+}
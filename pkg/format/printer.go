@@ -0,0 +1,531 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+)
+
+type printer struct {
+	buf    bytes.Buffer
+	indent int
+	// comments not printed yet, in source order
+	comments []*ast.Comment
+	// lastLine is the source line of the last statement or comment printed,
+	// used to keep blank lines between them.
+	lastLine int
+}
+
+func newPrinter(comments []*ast.Comment) *printer {
+	return &printer{comments: comments}
+}
+
+func (p *printer) write(s ...string) {
+	for _, e := range s {
+		p.buf.WriteString(e)
+	}
+}
+
+func (p *printer) newline() {
+	p.newlines(1)
+}
+
+// newlines ends the current line and leaves n-1 blank lines, indenting the next one.
+func (p *printer) newlines(n int) {
+	p.buf.WriteString(strings.Repeat("\n", n))
+	p.buf.WriteString(strings.Repeat("\t", p.indent))
+}
+
+func (p *printer) program(root *ast.Root) {
+	p.statements(root.Statements, token.Position{Offset: -1})
+	if p.buf.Len() > 0 {
+		p.buf.WriteByte('\n')
+	}
+}
+
+// statements prints a list of statements, each one in its own line,
+// together with the comments that go before end.
+// An invalid end position means the end of the program.
+func (p *printer) statements(statements []ast.Statement, end token.Position) {
+	first := true
+	separate := func(line int) {
+		if !first {
+			// keep one blank line if there was at least one in the source
+			if line > p.lastLine+1 {
+				p.newlines(2)
+			} else {
+				p.newline()
+			}
+		}
+		first = false
+	}
+
+	comment := func() {
+		c := p.nextComment()
+		separate(c.Pos().Line)
+		p.write(c.TokenLiteral())
+		// a comment left inside the previous statement doesn't move
+		// the line back, which would add a blank line after it
+		p.lastLine = max(p.lastLine, c.Pos().Line)
+	}
+
+	for _, s := range statements {
+		for p.hasCommentBefore(s.Pos().Offset) {
+			comment()
+		}
+
+		separate(s.Pos().Line)
+		p.statement(s)
+		p.lastLine = endLine(s)
+
+		// trailing comments stay in the same line, as well as a comment
+		// inside the statement that none of its nodes could keep
+		if len(p.comments) > 0 && p.comments[0].Pos().Line <= p.lastLine &&
+			(!end.IsValid() || p.hasCommentBefore(end.Offset)) {
+			p.write(" ", p.nextComment().TokenLiteral())
+		}
+	}
+
+	for (!end.IsValid() && len(p.comments) > 0) || (end.IsValid() && p.hasCommentBefore(end.Offset)) {
+		comment()
+	}
+}
+
+func (p *printer) hasCommentBefore(offset int) bool {
+	return len(p.comments) > 0 && p.comments[0].Pos().Offset < offset
+}
+
+func (p *printer) nextComment() *ast.Comment {
+	c := p.comments[0]
+	p.comments = p.comments[1:]
+	return c
+}
+
+func (p *printer) statement(s ast.Statement) {
+	switch s := s.(type) {
+	case *ast.Let:
+		p.let(s)
+	case *ast.Return:
+		p.write("return ")
+		p.expression(s.Value, lowest)
+		p.write(";")
+	case *ast.ExpressionStatement:
+		p.expression(s.Expression, lowest)
+		switch s.Expression.(type) {
+		case *ast.If, *ast.Match:
+		default:
+			p.write(";")
+		}
+	case *ast.Block:
+		p.block(s)
+	case *ast.Import:
+		p.write("import ", s.Path.Token.Literal, ";")
+	case *ast.Export:
+		p.write("export ")
+		p.let(s.Let)
+	case *ast.While:
+		p.write("while (")
+		p.expression(s.Condition, lowest)
+		p.write(") ")
+		p.block(s.Body)
+	case *ast.For:
+		p.write("for (", s.Variable.Value, " in ")
+		p.expression(s.Iterable, lowest)
+		p.write(") ")
+		p.block(s.Body)
+	case *ast.Break:
+		p.write("break;")
+	case *ast.Continue:
+		p.write("continue;")
+	case *ast.Throw:
+		p.write("throw ")
+		p.expression(s.Value, lowest)
+		p.write(";")
+	case *ast.Try:
+		p.write("try ")
+		p.block(s.Body)
+		if s.Catch != nil {
+			p.write(" catch (", s.Param.Value, ") ")
+			p.block(s.Catch)
+		}
+		if s.Finally != nil {
+			p.write(" finally ")
+			p.block(s.Finally)
+		}
+	}
+}
+
+func (p *printer) let(l *ast.Let) {
+	p.write("let ")
+	if l.Pattern != nil {
+		p.pattern(l.Pattern)
+	} else {
+		p.write(l.Name.Value)
+	}
+	if l.Type != nil {
+		p.write(": ")
+		p.typeExpression(l.Type)
+	}
+	p.write(" = ")
+	p.expression(l.Value, lowest)
+	p.write(";")
+}
+
+func (p *printer) block(b *ast.Block) {
+	p.write("{")
+	if len(b.Statements) == 0 && !p.hasCommentBefore(b.End.Offset) {
+		p.write("}")
+		return
+	}
+
+	p.indent++
+	p.newline()
+	p.lastLine = b.Pos().Line
+	p.statements(b.Statements, b.End)
+	p.indent--
+	p.newline()
+	p.write("}")
+	p.lastLine = b.End.Line
+}
+
+// expression prints an expression, wrapping it in parentheses
+// if its precedence is lower than the one required by its parent.
+func (p *printer) expression(e ast.Expression, parent precedence) {
+	if precedenceOf(e) < parent {
+		p.write("(")
+		defer p.write(")")
+	}
+
+	switch e := e.(type) {
+	case *ast.Identifier:
+		p.write(e.Value)
+	case *ast.Literal:
+		p.write(e.Token.Literal)
+	case *ast.StringLiteral:
+		p.write(e.Token.Literal)
+	case *ast.TemplateLiteral:
+		p.template(e)
+	case *ast.Boolean:
+		p.write(e.Token.Literal)
+	case *ast.Prefix:
+		p.write(string(e.Operator))
+		if r, ok := e.Right.(*ast.Prefix); ok && r.Operator == ast.Negative && e.Operator == ast.Negative {
+			// keep the parentheses of -(-x), since --x reads as a decrement
+			p.expression(e.Right, call)
+			break
+		}
+		p.expression(e.Right, prefix)
+	case *ast.Infix:
+		pre := precedenceOf(e)
+		p.expression(e.Left, pre)
+		p.write(" ", string(e.Operator), " ")
+		// operators are left associative, so the right side needs
+		// parentheses if it has the same precedence
+		p.expression(e.Right, pre+1)
+	case *ast.If:
+		p.write("if (")
+		p.expression(e.Condition, lowest)
+		p.write(") ")
+		p.block(e.Consequence)
+		if e.Alternative != nil {
+			p.write(" else ")
+			p.block(e.Alternative)
+		}
+	case *ast.FunctionLiteral:
+		p.write("fn(")
+		for i, param := range e.Parameters {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.write(param.Name.Value)
+			if param.Type != nil {
+				p.write(": ")
+				p.typeExpression(param.Type)
+			}
+		}
+		p.write(")")
+		if e.ReturnType != nil {
+			p.write(": ")
+			p.typeExpression(e.ReturnType)
+		}
+		p.write(" ")
+		p.block(e.Body)
+	case *ast.Null:
+		p.write(e.Token.Literal)
+	case *ast.Call:
+		p.expression(e.Function, call)
+		if e.Optional {
+			p.write("?.")
+		}
+		p.write("(")
+		p.expressions(e.Arguments, e.Pos(), e.End)
+		p.write(")")
+	case *ast.ArrayLiteral:
+		p.write("[")
+		p.expressions(e.Elements, e.Pos(), e.End)
+		p.write("]")
+	case *ast.HashLiteral:
+		p.write("{")
+		list(p, e.Pairs, e.Pos(), e.End, func(pair *ast.HashPair) {
+			p.expression(pair.Key, lowest)
+			p.write(": ")
+			p.expression(pair.Value, lowest)
+		})
+		p.write("}")
+	case *ast.Index:
+		p.expression(e.Left, call)
+		if e.Optional {
+			p.write("?")
+		}
+		p.write("[")
+		p.expression(e.Index, lowest)
+		p.write("]")
+	case *ast.Property:
+		p.expression(e.Left, call)
+		if e.Optional {
+			p.write("?")
+		}
+		p.write(".", e.Name.Value)
+	case *ast.Assign:
+		p.expression(e.Target, call)
+		p.write(" ", string(e.Operator), " ")
+		p.expression(e.Value, lowest)
+	case *ast.Match:
+		p.match(e)
+	}
+}
+
+// template prints the texts of a template literal as written in the source,
+// so their whitespace and escape sequences are kept.
+func (p *printer) template(t *ast.TemplateLiteral) {
+	p.write("`")
+	for _, part := range t.Parts {
+		if text, ok := part.(*ast.TemplateText); ok {
+			p.write(text.Token.Literal)
+			continue
+		}
+		p.write("${")
+		p.expression(part, lowest)
+		p.write("}")
+	}
+	p.write("`")
+}
+
+// match prints each arm of a match in its own line, ending with a comma.
+func (p *printer) match(m *ast.Match) {
+	p.write("match (")
+	p.expression(m.Subject, lowest)
+	p.write(") {")
+	if len(m.Arms) == 0 && !p.hasCommentBefore(m.End.Offset) {
+		p.write("}")
+		return
+	}
+
+	lines(p, m.Arms, m.End, true, func(arm *ast.MatchArm) {
+		p.pattern(arm.Pattern)
+		if arm.Guard != nil {
+			p.write(" if ")
+			p.expression(arm.Guard, lowest)
+		}
+		p.write(" => ")
+		p.expression(arm.Body, lowest)
+	})
+	p.newline()
+	p.write("}")
+}
+
+func (p *printer) pattern(pattern ast.Pattern) {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		p.write("_")
+	case *ast.BindingPattern:
+		p.write(pattern.Name.Value)
+	case *ast.LiteralPattern:
+		p.expression(pattern.Value, lowest)
+	case *ast.ArrayPattern:
+		p.write("[")
+		for i, e := range pattern.Elements {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.pattern(e)
+		}
+		p.write("]")
+	case *ast.HashPattern:
+		p.write("{")
+		for i, pair := range pattern.Pairs {
+			if i > 0 {
+				p.write(", ")
+			}
+			// the shorthand is just its value
+			if pair.Key != nil {
+				p.expression(pair.Key, lowest)
+				p.write(": ")
+			}
+			p.pattern(pair.Value)
+		}
+		p.write("}")
+	case *ast.RestPattern:
+		p.write("...", pattern.Name.Value)
+	case *ast.DefaultPattern:
+		p.pattern(pattern.Pattern)
+		p.write(" = ")
+		p.expression(pattern.Default, lowest)
+	}
+}
+
+// expressions prints a comma separated list of expressions
+// enclosed by the tokens at start and end.
+func (p *printer) expressions(expressions []ast.Expression, start, end token.Position) {
+	list(p, expressions, start, end, func(e ast.Expression) {
+		p.expression(e, lowest)
+	})
+}
+
+// list prints a comma separated list of items enclosed by the tokens at
+// start and end. The items go in a single line, unless there are comments
+// between them, which need each item in its own line to stay next to it.
+func list[T ast.Node](p *printer, items []T, start, end token.Position, item func(T)) {
+	if !hasOwnComments(p, items, start, end) {
+		for i, it := range items {
+			if i > 0 {
+				p.write(", ")
+			}
+			item(it)
+		}
+		return
+	}
+
+	lines(p, items, end, false, item)
+	p.newline()
+}
+
+// lines prints each item in its own line, one level deeper, together
+// with the comments that go before the token at end. The items are
+// separated by commas, and the last one is followed by another one
+// if trailingComma is set.
+func lines[T ast.Node](p *printer, items []T, end token.Position, trailingComma bool, item func(T)) {
+	p.indent++
+	defer func() { p.indent-- }()
+
+	for i, it := range items {
+		for p.hasCommentBefore(it.Pos().Offset) {
+			p.newline()
+			p.write(p.nextComment().TokenLiteral())
+		}
+		p.newline()
+		item(it)
+
+		next := end
+		if i+1 < len(items) {
+			next = items[i+1].Pos()
+		}
+		if i+1 < len(items) || trailingComma {
+			p.write(",")
+		}
+		// the comments after an item in its last line stay there
+		if p.hasCommentBefore(next.Offset) && p.comments[0].Pos().Line <= endLine(it) {
+			p.write(" ", p.nextComment().TokenLiteral())
+		}
+	}
+	for p.hasCommentBefore(end.Offset) {
+		p.newline()
+		p.write(p.nextComment().TokenLiteral())
+	}
+}
+
+// hasOwnComments reports whether there are comments between the tokens at
+// start and end that are not inside a node of items printing its own ones.
+func hasOwnComments[T ast.Node](p *printer, items []T, start, end token.Position) bool {
+	for _, c := range p.comments {
+		offset := c.Pos().Offset
+		if offset >= end.Offset {
+			return false
+		}
+		if offset > start.Offset && !insideNested(items, offset) {
+			return true
+		}
+	}
+	return false
+}
+
+// insideNested reports whether offset is inside a node of items that prints
+// the comments it encloses: a block, a match or another list.
+func insideNested[T ast.Node](items []T, offset int) bool {
+	inside := false
+	for _, it := range items {
+		ast.Inspect(it, func(n ast.Node) bool {
+			if _, ok := n.(*ast.TemplateLiteral); ok {
+				return true
+			}
+			if end, ok := closing(n); ok && n.Pos().Offset < offset && offset < end.Offset {
+				inside = true
+			}
+			return !inside
+		})
+	}
+	return inside
+}
+
+func (p *printer) typeExpression(t ast.TypeExpression) {
+	switch t := t.(type) {
+	case *ast.NamedType:
+		p.write(t.Name)
+	case *ast.ArrayType:
+		p.write("[")
+		p.typeExpression(t.Element)
+		p.write("]")
+	case *ast.HashType:
+		p.write("{")
+		p.typeExpression(t.Key)
+		p.write(": ")
+		p.typeExpression(t.Value)
+		p.write("}")
+	case *ast.FunctionType:
+		p.write("fn(")
+		for i, param := range t.Parameters {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.typeExpression(param)
+		}
+		p.write("): ")
+		p.typeExpression(t.Return)
+	}
+}
+
+// endLine returns the source line where a node ends.
+func endLine(n ast.Node) int {
+	line := 0
+	ast.Inspect(n, func(c ast.Node) bool {
+		if end, ok := closing(c); ok && end.Line > line {
+			line = end.Line
+		}
+		if c.Pos().Line > line {
+			line = c.Pos().Line
+		}
+		return true
+	})
+	return line
+}
+
+// closing returns the position of the token closing a node, if it has one.
+func closing(n ast.Node) (token.Position, bool) {
+	switch n := n.(type) {
+	case *ast.Block:
+		return n.End, true
+	case *ast.Match:
+		return n.End, true
+	case *ast.TemplateLiteral:
+		return n.End, true
+	case *ast.ArrayLiteral:
+		return n.End, true
+	case *ast.HashLiteral:
+		return n.End, true
+	case *ast.Call:
+		return n.End, true
+	}
+	return token.Position{}, false
+}
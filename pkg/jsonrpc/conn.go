@@ -0,0 +1,102 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// MaxMessageSize is the size of the largest message body Read accepts,
+// so a bad header can't make it allocate an arbitrary amount of memory.
+const MaxMessageSize = 64 << 20
+
+// Conn reads and writes JSON-RPC messages framed with a Content-Length header,
+// as used by the Language Server Protocol.
+type Conn struct {
+	reader *textproto.Reader
+
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func NewConn(r io.Reader, w io.Writer) *Conn {
+	return &Conn{
+		reader: textproto.NewReader(bufio.NewReader(r)),
+		writer: w,
+	}
+}
+
+// DecodeError is returned by Read when the body of a message isn't valid
+// JSON. Unlike the errors reading the header or the body, the connection
+// can still be used, since the next message starts after the body.
+type DecodeError struct {
+	err error
+}
+
+func (e *DecodeError) Error() string {
+	return "decoding message: " + e.err.Error()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.err
+}
+
+// Read blocks until the next message is available.
+// It returns io.EOF when the other side closes the connection, and
+// a *DecodeError if the message body can't be decoded.
+func (c *Conn) Read() (*Message, error) {
+	header, err := c.reader.ReadMIMEHeader()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading message header")
+	}
+
+	value := header.Get("Content-Length")
+	if value == "" {
+		return nil, errors.New("missing Content-Length header")
+	}
+	length, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid Content-Length header")
+	}
+	if length < 0 || length > MaxMessageSize {
+		return nil, errors.Errorf("invalid Content-Length header: %d is not between 0 and %d", length, MaxMessageSize)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.reader.R, body); err != nil {
+		return nil, errors.Wrap(err, "reading message body")
+	}
+
+	m := &Message{}
+	if err := json.Unmarshal(body, m); err != nil {
+		return nil, &DecodeError{err: err}
+	}
+
+	return m, nil
+}
+
+// Write sends a message. It's safe to call it from multiple goroutines.
+func (c *Conn) Write(m *Message) error {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return errors.Wrap(err, "encoding message")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := fmt.Fprintf(c.writer, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = c.writer.Write(body)
+	return err
+}
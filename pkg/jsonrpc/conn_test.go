@@ -0,0 +1,118 @@
+package jsonrpc_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/jsonrpc"
+)
+
+func TestConnRead(t *testing.T) {
+	body := `{"jsonrpc":"2.0","method":"initialized"}`
+	testCases := []struct {
+		name    string
+		input   string
+		want    *jsonrpc.Message
+		wantErr string
+	}{
+		{
+			name:  "valid",
+			input: fmt.Sprintf("Content-Length: %d\r\nContent-Type: application/vscode-jsonrpc\r\n\r\n%s", len(body), body),
+			want:  &jsonrpc.Message{JSONRPC: "2.0", Method: "initialized"},
+		},
+		{
+			name:    "truncated body",
+			input:   fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(body)+10, body),
+			wantErr: "reading message body: unexpected EOF",
+		},
+		{
+			name:    "missing length",
+			input:   "Content-Type: application/vscode-jsonrpc\r\n\r\n" + body,
+			wantErr: "missing Content-Length header",
+		},
+		{
+			name:    "invalid length",
+			input:   "Content-Length: ten\r\n\r\n" + body,
+			wantErr: "invalid Content-Length header",
+		},
+		{
+			name:    "negative length",
+			input:   "Content-Length: -1\r\n\r\n" + body,
+			wantErr: "invalid Content-Length header: -1 is not between 0 and 67108864",
+		},
+		{
+			name:    "oversized length",
+			input:   "Content-Length: 1099511627776\r\n\r\n" + body,
+			wantErr: "invalid Content-Length header: 1099511627776 is not between 0 and 67108864",
+		},
+		{
+			name:    "invalid body",
+			input:   "Content-Length: 2\r\n\r\n{]",
+			wantErr: "decoding message",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := jsonrpc.NewConn(strings.NewReader(tc.input), io.Discard)
+			got, err := c.Read()
+			if tc.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.wantErr)))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tc.want))
+		})
+	}
+}
+
+func TestConnReadAfterDecodeError(t *testing.T) {
+	g := NewWithT(t)
+
+	body := `{"jsonrpc":"2.0","method":"exit"}`
+	c := jsonrpc.NewConn(strings.NewReader(fmt.Sprintf("Content-Length: 9\r\n\r\n{bad jsonContent-Length: %d\r\n\r\n%s", len(body), body)), io.Discard)
+	_, err := c.Read()
+	decodeErr := &jsonrpc.DecodeError{}
+	g.Expect(errors.As(err, &decodeErr)).To(BeTrue())
+
+	got, err := c.Read()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(Equal(&jsonrpc.Message{JSONRPC: "2.0", Method: "exit"}))
+}
+
+func TestConnReadEOF(t *testing.T) {
+	g := NewWithT(t)
+
+	c := jsonrpc.NewConn(strings.NewReader(""), io.Discard)
+	_, err := c.Read()
+	g.Expect(err).To(Equal(io.EOF))
+}
+
+func TestConnWriteRead(t *testing.T) {
+	g := NewWithT(t)
+
+	m, err := jsonrpc.NewRequest(1, "shutdown", nil)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	b := &bytes.Buffer{}
+	w := jsonrpc.NewConn(nil, b)
+	g.Expect(w.Write(m)).To(Succeed())
+	g.Expect(w.Write(m)).To(Succeed())
+	g.Expect(b.String()).To(HavePrefix("Content-Length: "))
+
+	r := jsonrpc.NewConn(b, io.Discard)
+	for range 2 {
+		got, err := r.Read()
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(got).To(Equal(m))
+	}
+	_, err = r.Read()
+	g.Expect(err).To(Equal(io.EOF))
+}
@@ -0,0 +1,88 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const version = "2.0"
+
+// Standard error codes defined by the JSON-RPC specification.
+const (
+	ParseError     = -32700
+	InvalidRequest = -32600
+	MethodNotFound = -32601
+	InvalidParams  = -32602
+	InternalError  = -32603
+)
+
+// Message is a JSON-RPC request, response or notification.
+// Requests have both ID and Method, notifications only Method
+// and responses only ID.
+type Message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *Error           `json:"error,omitempty"`
+}
+
+// IsRequest reports whether the message expects a response.
+func (m *Message) IsRequest() bool {
+	return m.ID != nil && m.Method != ""
+}
+
+// IsNotification reports whether the message is a notification.
+func (m *Message) IsNotification() bool {
+	return m.ID == nil && m.Method != ""
+}
+
+// Error is the error object of a failed response.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+// NewRequest builds a request message with the given id.
+func NewRequest(id int, method string, params any) (*Message, error) {
+	raw := json.RawMessage(fmt.Sprintf("%d", id))
+	m, err := NewNotification(method, params)
+	if err != nil {
+		return nil, err
+	}
+	m.ID = &raw
+	return m, nil
+}
+
+// NewNotification builds a notification message.
+func NewNotification(method string, params any) (*Message, error) {
+	p, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	return &Message{JSONRPC: version, Method: method, Params: p}, nil
+}
+
+// NewResponse builds the response to the request with the given id.
+func NewResponse(id *json.RawMessage, result any) (*Message, error) {
+	r, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return &Message{JSONRPC: version, ID: id, Result: r}, nil
+}
+
+// NewErrorResponse builds a failed response to the request with the given id.
+// A nil id, for a request whose id couldn't be read, is sent as null.
+func NewErrorResponse(id *json.RawMessage, code int, message string) *Message {
+	if id == nil {
+		null := json.RawMessage("null")
+		id = &null
+	}
+	return &Message{JSONRPC: version, ID: id, Error: &Error{Code: code, Message: message}}
+}
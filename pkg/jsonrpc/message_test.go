@@ -0,0 +1,23 @@
+package jsonrpc_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/jsonrpc"
+)
+
+func TestNewErrorResponse(t *testing.T) {
+	g := NewWithT(t)
+
+	id := json.RawMessage("7")
+	b, err := json.Marshal(jsonrpc.NewErrorResponse(&id, jsonrpc.InternalError, "failed"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(b)).To(MatchJSON(`{"jsonrpc": "2.0", "id": 7, "error": {"code": -32603, "message": "failed"}}`))
+
+	b, err = json.Marshal(jsonrpc.NewErrorResponse(nil, jsonrpc.ParseError, "bad json"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(b)).To(MatchJSON(`{"jsonrpc": "2.0", "id": null, "error": {"code": -32700, "message": "bad json"}}`))
+}
@@ -0,0 +1,65 @@
+package lexer_test
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lexer"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+)
+
+func FuzzLexerNextToken(f *testing.F) {
+	for _, seed := range []string{
+		";",
+		"=+(){},;",
+		"let x: [int] = y;",
+		"// leading comment\nlet x = 5 / 2; // trailing comment  \n//",
+		"let add = fn(x,y) {\n\tx + y;\n};\nlet result = add(five, ten);",
+		"!-/*5;\n5 < 10 > 5;\nif (5 < 5) {\n\treturn true;\n} else {\n\treturn false;\n}",
+		"10 == 10;\n10 != 9;",
+		"let x = 5;\n  x == 10;\né!",
+		"a\x00b",
+		"\xff\xfe",
+		"`a ${b + {c: 1}[\"c\"]} \\` $`",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		l := lexer.New(
+			lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+		)
+
+		var previous token.Position
+		// every token takes at least a byte, so there can't be more tokens than bytes
+		for i := 0; i <= len(input); i++ {
+			tok, err := l.NextToken()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !tok.Pos.IsValid() {
+				t.Fatalf("token %v has no position", tok)
+			}
+			if tok.Pos.Offset > len(input) {
+				t.Fatalf("token %v is past the end of the input", tok)
+			}
+			if i > 0 && tok.Pos.Offset <= previous.Offset {
+				t.Fatalf("token %v doesn't come after the previous one at %s", tok, previous)
+			}
+			if tok.Type != token.EOF && tok.Literal == "" {
+				t.Fatalf("token %v has no literal", tok)
+			}
+			previous = tok.Pos
+
+			if tok.Type == token.EOF {
+				if tok.Pos.Offset != len(input) {
+					t.Fatalf("EOF at offset %d, want %d", tok.Pos.Offset, len(input))
+				}
+				return
+			}
+		}
+		t.Fatalf("no EOF after %d tokens", len(input)+1)
+	})
+}
@@ -2,25 +2,85 @@ package lexer
 
 import (
 	"io"
+	"strings"
 	"unicode"
 
 	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
 )
 
 type Lexer struct {
+	// Trivia makes the lexer keep the whitespace and comments around each
+	// token, available through LastTrivia, instead of skipping them.
+	// Comments are then returned as trivia and not as tokens.
+	Trivia bool
+
 	peeker RunePeeker
+	// pos is the position of the next rune to be read.
+	pos token.Position
+	// leading and trailing are the trivia of the last token.
+	leading, trailing []token.Trivia
+	// pending is a token read while looking for trailing trivia or
+	// the end of the text of a template.
+	pending *Item
+	// inText is set when the next token is in the text of a template.
+	inText bool
+	// interpolations has, for each template interpolation being lexed,
+	// the number of braces opened in it and not yet closed, to tell
+	// apart the brace that ends it.
+	interpolations []int
 }
 
 func New(peeker RunePeeker) *Lexer {
+	return NewAt(peeker, token.Position{Line: 1, Column: 1})
+}
+
+// NewAt returns a lexer for input that starts at pos of a larger source,
+// so the positions of the tokens are relative to that source.
+func NewAt(peeker RunePeeker, pos token.Position) *Lexer {
 	return &Lexer{
 		peeker: peeker,
+		pos:    pos,
 	}
 }
 
 func (r *Lexer) NextToken() (token.Token, error) {
-	r.skipAllWhiteSpace()
+	if r.Trivia {
+		return r.nextTokenWithTrivia()
+	}
+
+	if r.pending != nil {
+		t, err := r.pending.Token, r.pending.Err
+		r.pending = nil
+		return t, err
+	}
+
+	if !r.inText {
+		r.skipAllWhiteSpace()
+	}
 
-	rune, _, err := r.peeker.ReadRune()
+	t, err := r.lex()
+	if t.Type == token.Comment {
+		t.Literal = strings.TrimRight(t.Literal, commentTrailingSpace)
+	}
+	return t, err
+}
+
+// lex reads the token that starts at the next rune.
+func (r *Lexer) lex() (token.Token, error) {
+	pos := r.pos
+	var t token.Token
+	var err error
+	if r.inText {
+		t, err = r.lexText()
+	} else {
+		t, err = r.nextToken()
+	}
+	t.Pos = pos
+	return t, err
+}
+
+func (r *Lexer) nextToken() (token.Token, error) {
+	rune, err := r.readRune()
 	if err == io.EOF {
 		return token.Token{Type: token.EOF}, nil
 	}
@@ -28,7 +88,6 @@ func (r *Lexer) NextToken() (token.Token, error) {
 		return token.Token{}, err
 	}
 
-
 	// TODO: this can probably be simplified by using a lookup table
 	switch rune {
 	case '=':
@@ -36,25 +95,46 @@ func (r *Lexer) NextToken() (token.Token, error) {
 	case '!':
 		return r.parseEqualsBang()
 	case '-':
-		return token.Token{Type: token.Minus, Literal: string(rune)}, nil
+		return r.parseAssignSuffix(token.Token{Type: token.Minus, Literal: "-"}, token.MinusAssign)
 	case '+':
-		return token.Token{Type: token.Plus, Literal: string(rune)}, nil
+		return r.parseAssignSuffix(token.Token{Type: token.Plus, Literal: "+"}, token.PlusAssign)
 	case ',':
 		return token.Token{Type: token.Comma, Literal: string(rune)}, nil
 	case ';':
 		return token.Token{Type: token.Semicolon, Literal: string(rune)}, nil
+	case ':':
+		return token.Token{Type: token.Colon, Literal: string(rune)}, nil
+	case '.':
+		return r.parseDotStart()
+	case '?':
+		return r.parseQuestionStart()
+	case '_':
+		return token.Token{Type: token.Underscore, Literal: string(rune)}, nil
+	case '[':
+		return token.Token{Type: token.LBracket, Literal: string(rune)}, nil
+	case ']':
+		return token.Token{Type: token.RBracket, Literal: string(rune)}, nil
 	case '(':
 		return token.Token{Type: token.LParen, Literal: string(rune)}, nil
 	case ')':
 		return token.Token{Type: token.RParen, Literal: string(rune)}, nil
 	case '{':
+		if n := len(r.interpolations); n > 0 {
+			r.interpolations[n-1]++
+		}
 		return token.Token{Type: token.LBrace, Literal: string(rune)}, nil
 	case '}':
+		r.closeBrace()
 		return token.Token{Type: token.RBrace, Literal: string(rune)}, nil
+	case '`':
+		r.inText = true
+		return token.Token{Type: token.Backtick, Literal: string(rune)}, nil
 	case '*':
-		return token.Token{Type: token.Asterisk, Literal: string(rune)}, nil
+		return r.parseAssignSuffix(token.Token{Type: token.Asterisk, Literal: "*"}, token.AsteriskAssign)
 	case '/':
-		return token.Token{Type: token.Slash, Literal: string(rune)}, nil
+		return r.parseSlashStart()
+	case '"':
+		return r.parseString()
 	case '<':
 		return token.Token{Type: token.LowerThan, Literal: string(rune)}, nil
 	case '>':
@@ -66,8 +146,26 @@ func (r *Lexer) NextToken() (token.Token, error) {
 
 func (r *Lexer) skipAllWhiteSpace() {
 	for ru, err := r.peeker.PeekRune(); err == nil && unicode.IsSpace(ru); ru, err = r.peeker.PeekRune() {
-		r.peeker.ReadRune()
+		r.readRune()
+	}
+}
+
+// readRune consumes the next rune from the input, keeping track of its position.
+func (r *Lexer) readRune() (rune, error) {
+	ru, size, err := r.peeker.ReadRune()
+	if err != nil {
+		return ru, err
+	}
+
+	r.pos.Offset += size
+	if ru == '\n' {
+		r.pos.Line++
+		r.pos.Column = 1
+	} else {
+		r.pos.Column++
 	}
+
+	return ru, nil
 }
 
 func (r *Lexer) parseMultiCharSymbol(currentRune rune) (token.Token, error) {
@@ -85,7 +183,7 @@ func (r *Lexer) parseWord(currentRune rune) (token.Token, error) {
 	ru, err := r.peeker.PeekRune()
 	for ; err == nil && unicode.IsLetter(ru); ru, err = r.peeker.PeekRune() {
 		letterRunes = append(letterRunes, ru)
-		r.peeker.ReadRune()
+		r.readRune()
 	}
 
 	word := string(letterRunes)
@@ -102,7 +200,7 @@ func (r *Lexer) parseNumber(currentRune rune) (token.Token, error) {
 	ru, err := r.peeker.PeekRune()
 	for ; err == nil && unicode.IsDigit(ru); ru, err = r.peeker.PeekRune() {
 		digitRunes = append(digitRunes, ru)
-		r.peeker.ReadRune()
+		r.readRune()
 	}
 
 	return token.Token{Type: token.Int, Literal: string(digitRunes)}, nil
@@ -110,18 +208,184 @@ func (r *Lexer) parseNumber(currentRune rune) (token.Token, error) {
 
 func (r *Lexer) parseEqualsStart() (token.Token, error) {
 	if ru, err := r.peeker.PeekRune(); err == nil && ru == '=' {
-		r.peeker.ReadRune()
+		r.readRune()
 		return token.Token{Type: token.Equal, Literal: "=="}, nil
 	}
+	if ru, err := r.peeker.PeekRune(); err == nil && ru == '>' {
+		r.readRune()
+		return token.Token{Type: token.FatArrow, Literal: "=>"}, nil
+	}
 
 	return token.Token{Type: token.Assign, Literal: "="}, nil
 }
 
 func (r *Lexer) parseEqualsBang() (token.Token, error) {
 	if ru, err := r.peeker.PeekRune(); err == nil && ru == '=' {
-		r.peeker.ReadRune()
+		r.readRune()
 		return token.Token{Type: token.NotEqual, Literal: "!="}, nil
 	}
 
 	return token.Token{Type: token.Bang, Literal: "!"}, nil
 }
+
+// parseDotStart returns a dot or an ellipsis. Two dots alone are illegal.
+func (r *Lexer) parseDotStart() (token.Token, error) {
+	if ru, err := r.peeker.PeekRune(); err != nil || ru != '.' {
+		return token.Token{Type: token.Dot, Literal: "."}, nil
+	}
+	r.readRune()
+
+	if ru, err := r.peeker.PeekRune(); err != nil || ru != '.' {
+		return token.Token{Type: token.Illegal, Literal: ".."}, nil
+	}
+	r.readRune()
+	return token.Token{Type: token.Ellipsis, Literal: "..."}, nil
+}
+
+// parseQuestionStart returns the operators starting with ?, which
+// can't be used alone.
+func (r *Lexer) parseQuestionStart() (token.Token, error) {
+	ru, err := r.peeker.PeekRune()
+	if err != nil {
+		return token.Token{Type: token.Illegal, Literal: "?"}, nil
+	}
+
+	var t token.Type
+	switch ru {
+	case '.':
+		t = token.QuestionDot
+	case '[':
+		t = token.QuestionBracket
+	case '?':
+		t = token.NullCoalesce
+	default:
+		return token.Token{Type: token.Illegal, Literal: "?"}, nil
+	}
+	r.readRune()
+	return token.Token{Type: t, Literal: "?" + string(ru)}, nil
+}
+
+// parseAssignSuffix returns the compound assignment of an operator,
+// like += for +, if it's followed by =, or the operator otherwise.
+func (r *Lexer) parseAssignSuffix(operator token.Token, assign token.Type) (token.Token, error) {
+	if ru, err := r.peeker.PeekRune(); err == nil && ru == '=' {
+		r.readRune()
+		return token.Token{Type: assign, Literal: operator.Literal + "="}, nil
+	}
+
+	return operator, nil
+}
+
+func (r *Lexer) parseSlashStart() (token.Token, error) {
+	if ru, err := r.peeker.PeekRune(); err != nil || ru != '/' {
+		return r.parseAssignSuffix(token.Token{Type: token.Slash, Literal: "/"}, token.SlashAssign)
+	}
+
+	// a comment goes until the end of the line, without including it
+	commentRunes := []rune{'/'}
+	ru, err := r.peeker.PeekRune()
+	for ; err == nil && ru != '\n'; ru, err = r.peeker.PeekRune() {
+		commentRunes = append(commentRunes, ru)
+		r.readRune()
+	}
+
+	return token.Token{Type: token.Comment, Literal: string(commentRunes)}, nil
+}
+
+// parseString reads a string literal, keeping its quotes and escape
+// sequences in the literal. A string that isn't closed before the end
+// of the line is illegal.
+func (r *Lexer) parseString() (token.Token, error) {
+	runes := []rune{'"'}
+	escaped := false
+	for {
+		ru, err := r.peeker.PeekRune()
+		if err == io.EOF || (err == nil && ru == '\n') {
+			return token.Token{Type: token.Illegal, Literal: string(runes)}, nil
+		}
+		if err != nil {
+			return token.Token{}, err
+		}
+
+		r.readRune()
+		runes = append(runes, ru)
+		switch {
+		case escaped:
+			escaped = false
+		case ru == '\\':
+			escaped = true
+		case ru == '"':
+			return token.Token{Type: token.String, Literal: string(runes)}, nil
+		}
+	}
+}
+
+// closeBrace keeps track of a closing brace, which goes back to the text
+// of the template if it ends an interpolation.
+func (r *Lexer) closeBrace() {
+	n := len(r.interpolations)
+	if n == 0 {
+		return
+	}
+	if r.interpolations[n-1] > 0 {
+		r.interpolations[n-1]--
+		return
+	}
+	r.interpolations = r.interpolations[:n-1]
+	r.inText = true
+}
+
+// lexText reads the text of a template literal until the closing backtick
+// or the next interpolation, keeping its escape sequences in the literal.
+// The backtick or ${ are returned on their own when there's no text before
+// them. The text is ended by EOF if the template isn't closed.
+func (r *Lexer) lexText() (token.Token, error) {
+	var runes []rune
+	text := func() token.Token {
+		return token.Token{Type: token.TemplateText, Literal: string(runes)}
+	}
+
+	escaped := false
+	for {
+		pos := r.pos
+		ru, err := r.peeker.PeekRune()
+		if err == io.EOF {
+			if len(runes) == 0 {
+				return token.Token{Type: token.EOF}, nil
+			}
+			return text(), nil
+		}
+		if err != nil {
+			return token.Token{}, err
+		}
+
+		if !escaped && ru == '`' {
+			if len(runes) > 0 {
+				return text(), nil
+			}
+			r.readRune()
+			r.inText = false
+			return token.Token{Type: token.Backtick, Literal: string(ru)}, nil
+		}
+
+		r.readRune()
+		if !escaped && ru == '$' {
+			// telling ${ apart from a $ in the text requires reading it, so
+			// it's kept as pending if there's text to return first
+			if next, err := r.peeker.PeekRune(); err == nil && next == '{' {
+				r.readRune()
+				r.inText = false
+				r.interpolations = append(r.interpolations, 0)
+				interpolation := token.Token{Type: token.DollarBrace, Literal: "${", Pos: pos}
+				if len(runes) == 0 {
+					return interpolation, nil
+				}
+				r.pending = &Item{Token: interpolation}
+				return text(), nil
+			}
+		}
+
+		runes = append(runes, ru)
+		escaped = !escaped && ru == '\\'
+	}
+}
@@ -40,6 +40,260 @@ func TestLexerNextToken(t *testing.T) {
 				{Type: token.EOF},
 			},
 		},
+		{
+			name:  "type annotation symbols",
+			input: "let x: [int] = y;",
+			wantSequence: []token.Token{
+				{Type: token.Let, Literal: "let"},
+				{Type: token.Ident, Literal: "x"},
+				{Type: token.Colon, Literal: ":"},
+				{Type: token.LBracket, Literal: "["},
+				{Type: token.Ident, Literal: "int"},
+				{Type: token.RBracket, Literal: "]"},
+				{Type: token.Assign, Literal: "="},
+				{Type: token.Ident, Literal: "y"},
+				{Type: token.Semicolon, Literal: ";"},
+				{Type: token.EOF},
+			},
+		},
+		{
+			name: "comments",
+			input: `// leading comment
+let x = 5 / 2; // trailing comment  
+//`,
+			wantSequence: []token.Token{
+				{Type: token.Comment, Literal: "// leading comment"},
+				{Type: token.Let, Literal: "let"},
+				{Type: token.Ident, Literal: "x"},
+				{Type: token.Assign, Literal: "="},
+				{Type: token.Int, Literal: "5"},
+				{Type: token.Slash, Literal: "/"},
+				{Type: token.Int, Literal: "2"},
+				{Type: token.Semicolon, Literal: ";"},
+				{Type: token.Comment, Literal: "// trailing comment"},
+				{Type: token.Comment, Literal: "//"},
+				{Type: token.EOF},
+			},
+		},
+		{
+			name:  "NUL is not skipped",
+			input: "a\x00b",
+			wantSequence: []token.Token{
+				{Type: token.Ident, Literal: "a"},
+				{Type: token.Illegal, Literal: "\x00"},
+				{Type: token.Ident, Literal: "b"},
+				{Type: token.EOF},
+			},
+		},
+		{
+			name:  "strings",
+			input: `"" "a \"b\" \\" "c`,
+			wantSequence: []token.Token{
+				{Type: token.String, Literal: `""`},
+				{Type: token.String, Literal: `"a \"b\" \\"`},
+				{Type: token.Illegal, Literal: `"c`},
+				{Type: token.EOF},
+			},
+		},
+		{
+			name:  "unterminated string ends at the line",
+			input: "\"a\nb",
+			wantSequence: []token.Token{
+				{Type: token.Illegal, Literal: `"a`},
+				{Type: token.Ident, Literal: "b"},
+				{Type: token.EOF},
+			},
+		},
+		{
+			name:  "modules",
+			input: `import "math"; export let x = 1;`,
+			wantSequence: []token.Token{
+				{Type: token.Import, Literal: "import"},
+				{Type: token.String, Literal: `"math"`},
+				{Type: token.Semicolon, Literal: ";"},
+				{Type: token.Export, Literal: "export"},
+				{Type: token.Let, Literal: "let"},
+				{Type: token.Ident, Literal: "x"},
+				{Type: token.Assign, Literal: "="},
+				{Type: token.Int, Literal: "1"},
+				{Type: token.Semicolon, Literal: ";"},
+				{Type: token.EOF},
+			},
+		},
+		{
+			name:  "assignments",
+			input: `x = 1; x += 2; x -= 3; x *= 4; x /= 5; x/ /=y`,
+			wantSequence: []token.Token{
+				{Type: token.Ident, Literal: "x"},
+				{Type: token.Assign, Literal: "="},
+				{Type: token.Int, Literal: "1"},
+				{Type: token.Semicolon, Literal: ";"},
+				{Type: token.Ident, Literal: "x"},
+				{Type: token.PlusAssign, Literal: "+="},
+				{Type: token.Int, Literal: "2"},
+				{Type: token.Semicolon, Literal: ";"},
+				{Type: token.Ident, Literal: "x"},
+				{Type: token.MinusAssign, Literal: "-="},
+				{Type: token.Int, Literal: "3"},
+				{Type: token.Semicolon, Literal: ";"},
+				{Type: token.Ident, Literal: "x"},
+				{Type: token.AsteriskAssign, Literal: "*="},
+				{Type: token.Int, Literal: "4"},
+				{Type: token.Semicolon, Literal: ";"},
+				{Type: token.Ident, Literal: "x"},
+				{Type: token.SlashAssign, Literal: "/="},
+				{Type: token.Int, Literal: "5"},
+				{Type: token.Semicolon, Literal: ";"},
+				{Type: token.Ident, Literal: "x"},
+				{Type: token.Slash, Literal: "/"},
+				{Type: token.SlashAssign, Literal: "/="},
+				{Type: token.Ident, Literal: "y"},
+				{Type: token.EOF},
+			},
+		},
+		{
+			name:  "null and optional chaining",
+			input: `a?.b.c ?? null; xs?[0]; f?.(); a ? b`,
+			wantSequence: []token.Token{
+				{Type: token.Ident, Literal: "a"},
+				{Type: token.QuestionDot, Literal: "?."},
+				{Type: token.Ident, Literal: "b"},
+				{Type: token.Dot, Literal: "."},
+				{Type: token.Ident, Literal: "c"},
+				{Type: token.NullCoalesce, Literal: "??"},
+				{Type: token.Null, Literal: "null"},
+				{Type: token.Semicolon, Literal: ";"},
+				{Type: token.Ident, Literal: "xs"},
+				{Type: token.QuestionBracket, Literal: "?["},
+				{Type: token.Int, Literal: "0"},
+				{Type: token.RBracket, Literal: "]"},
+				{Type: token.Semicolon, Literal: ";"},
+				{Type: token.Ident, Literal: "f"},
+				{Type: token.QuestionDot, Literal: "?."},
+				{Type: token.LParen, Literal: "("},
+				{Type: token.RParen, Literal: ")"},
+				{Type: token.Semicolon, Literal: ";"},
+				{Type: token.Ident, Literal: "a"},
+				{Type: token.Illegal, Literal: "?"},
+				{Type: token.Ident, Literal: "b"},
+				{Type: token.EOF},
+			},
+		},
+		{
+			name:  "exceptions",
+			input: `try throw catch finally`,
+			wantSequence: []token.Token{
+				{Type: token.Try, Literal: "try"},
+				{Type: token.Throw, Literal: "throw"},
+				{Type: token.Catch, Literal: "catch"},
+				{Type: token.Finally, Literal: "finally"},
+				{Type: token.EOF},
+			},
+		},
+		{
+			name:  "match",
+			input: `match (x) { _ => a, [y] if y >= 0 => b }`,
+			wantSequence: []token.Token{
+				{Type: token.Match, Literal: "match"},
+				{Type: token.LParen, Literal: "("},
+				{Type: token.Ident, Literal: "x"},
+				{Type: token.RParen, Literal: ")"},
+				{Type: token.LBrace, Literal: "{"},
+				{Type: token.Underscore, Literal: "_"},
+				{Type: token.FatArrow, Literal: "=>"},
+				{Type: token.Ident, Literal: "a"},
+				{Type: token.Comma, Literal: ","},
+				{Type: token.LBracket, Literal: "["},
+				{Type: token.Ident, Literal: "y"},
+				{Type: token.RBracket, Literal: "]"},
+				{Type: token.If, Literal: "if"},
+				{Type: token.Ident, Literal: "y"},
+				{Type: token.GreaterThan, Literal: ">"},
+				{Type: token.Assign, Literal: "="},
+				{Type: token.Int, Literal: "0"},
+				{Type: token.FatArrow, Literal: "=>"},
+				{Type: token.Ident, Literal: "b"},
+				{Type: token.RBrace, Literal: "}"},
+				{Type: token.EOF},
+			},
+		},
+		{
+			name:  "destructuring",
+			input: `let [a = 1, ...b] = c; x..y`,
+			wantSequence: []token.Token{
+				{Type: token.Let, Literal: "let"},
+				{Type: token.LBracket, Literal: "["},
+				{Type: token.Ident, Literal: "a"},
+				{Type: token.Assign, Literal: "="},
+				{Type: token.Int, Literal: "1"},
+				{Type: token.Comma, Literal: ","},
+				{Type: token.Ellipsis, Literal: "..."},
+				{Type: token.Ident, Literal: "b"},
+				{Type: token.RBracket, Literal: "]"},
+				{Type: token.Assign, Literal: "="},
+				{Type: token.Ident, Literal: "c"},
+				{Type: token.Semicolon, Literal: ";"},
+				{Type: token.Ident, Literal: "x"},
+				{Type: token.Illegal, Literal: ".."},
+				{Type: token.Ident, Literal: "y"},
+				{Type: token.EOF},
+			},
+		},
+		{
+			name:  "template",
+			input: "`a ${b + `${c}`} $ \\${ ${ {d: 1}[\"d\"] }\n`",
+			wantSequence: []token.Token{
+				{Type: token.Backtick, Literal: "`"},
+				{Type: token.TemplateText, Literal: "a "},
+				{Type: token.DollarBrace, Literal: "${"},
+				{Type: token.Ident, Literal: "b"},
+				{Type: token.Plus, Literal: "+"},
+				{Type: token.Backtick, Literal: "`"},
+				{Type: token.DollarBrace, Literal: "${"},
+				{Type: token.Ident, Literal: "c"},
+				{Type: token.RBrace, Literal: "}"},
+				{Type: token.Backtick, Literal: "`"},
+				{Type: token.RBrace, Literal: "}"},
+				{Type: token.TemplateText, Literal: " $ \\${ "},
+				{Type: token.DollarBrace, Literal: "${"},
+				{Type: token.LBrace, Literal: "{"},
+				{Type: token.Ident, Literal: "d"},
+				{Type: token.Colon, Literal: ":"},
+				{Type: token.Int, Literal: "1"},
+				{Type: token.RBrace, Literal: "}"},
+				{Type: token.LBracket, Literal: "["},
+				{Type: token.String, Literal: `"d"`},
+				{Type: token.RBracket, Literal: "]"},
+				{Type: token.RBrace, Literal: "}"},
+				{Type: token.TemplateText, Literal: "\n"},
+				{Type: token.Backtick, Literal: "`"},
+				{Type: token.EOF},
+			},
+		},
+		{
+			name:  "unterminated template",
+			input: "`a ${b}",
+			wantSequence: []token.Token{
+				{Type: token.Backtick, Literal: "`"},
+				{Type: token.TemplateText, Literal: "a "},
+				{Type: token.DollarBrace, Literal: "${"},
+				{Type: token.Ident, Literal: "b"},
+				{Type: token.RBrace, Literal: "}"},
+				{Type: token.EOF},
+			},
+		},
+		{
+			name:  "loops",
+			input: `while for in break continue`,
+			wantSequence: []token.Token{
+				{Type: token.While, Literal: "while"},
+				{Type: token.For, Literal: "for"},
+				{Type: token.In, Literal: "in"},
+				{Type: token.Break, Literal: "break"},
+				{Type: token.Continue, Literal: "continue"},
+				{Type: token.EOF},
+			},
+		},
 		{
 			name: "actual code",
 			input: `let five = 5;
@@ -158,6 +412,7 @@ if (5 < 5) {
 			var err error
 			var got []token.Token
 			for tok, err = l.NextToken(); err == nil; tok, err = l.NextToken() {
+				tok.Pos = token.Position{}
 				got = append(got, tok)
 				if tok.Type == token.EOF {
 					break
@@ -173,3 +428,38 @@ if (5 < 5) {
 		})
 	}
 }
+
+func TestLexerNextTokenPositions(t *testing.T) {
+	input := `let x = 5;
+  x == 10;
+é!`
+	want := []token.Token{
+		{Type: token.Let, Literal: "let", Pos: token.Position{Offset: 0, Line: 1, Column: 1}},
+		{Type: token.Ident, Literal: "x", Pos: token.Position{Offset: 4, Line: 1, Column: 5}},
+		{Type: token.Assign, Literal: "=", Pos: token.Position{Offset: 6, Line: 1, Column: 7}},
+		{Type: token.Int, Literal: "5", Pos: token.Position{Offset: 8, Line: 1, Column: 9}},
+		{Type: token.Semicolon, Literal: ";", Pos: token.Position{Offset: 9, Line: 1, Column: 10}},
+		{Type: token.Ident, Literal: "x", Pos: token.Position{Offset: 13, Line: 2, Column: 3}},
+		{Type: token.Equal, Literal: "==", Pos: token.Position{Offset: 15, Line: 2, Column: 5}},
+		{Type: token.Int, Literal: "10", Pos: token.Position{Offset: 18, Line: 2, Column: 8}},
+		{Type: token.Semicolon, Literal: ";", Pos: token.Position{Offset: 20, Line: 2, Column: 10}},
+		{Type: token.Ident, Literal: "é", Pos: token.Position{Offset: 22, Line: 3, Column: 1}},
+		{Type: token.Bang, Literal: "!", Pos: token.Position{Offset: 24, Line: 3, Column: 2}},
+		{Type: token.EOF, Pos: token.Position{Offset: 25, Line: 3, Column: 3}},
+	}
+
+	l := lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)
+	var got []token.Token
+	for {
+		tok, err := l.NextToken()
+		assert.Nil(t, err)
+		got = append(got, tok)
+		if tok.Type == token.EOF {
+			break
+		}
+	}
+
+	assert.Equal(t, want, got)
+}
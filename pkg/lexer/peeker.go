@@ -10,6 +10,9 @@ type RunePeeker interface {
 
 type runePeeker struct {
 	reader io.RuneReader
+	// peeked tells whether peek holds a rune read ahead. The rune itself
+	// can't be used for this, since NUL is valid in the input.
+	peeked bool
 	peek   rune
 	size   int
 	err    error
@@ -20,21 +23,21 @@ func NewRunePeeker(reader io.RuneReader) RunePeeker {
 }
 
 func (p *runePeeker) PeekRune() (rune, error) {
-	if p.peek == 0 {
+	if !p.peeked {
 		p.peek, p.size, p.err = p.reader.ReadRune()
-		return p.peek, p.err
+		p.peeked = true
 	}
 
-	return p.peek, nil
+	return p.peek, p.err
 }
 
 func (p *runePeeker) ReadRune() (r rune, size int, err error) {
-	if p.peek == 0 {
+	if !p.peeked {
 		return p.reader.ReadRune()
 	}
 
 	r, s, err := p.peek, p.size, p.err
-	p.peek, p.size, p.err = 0, 0, nil
+	p.peeked, p.peek, p.size, p.err = false, 0, 0, nil
 
 	return r, s, err
 }
@@ -0,0 +1,102 @@
+package lexer
+
+import (
+	"context"
+	"iter"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+)
+
+// TokenReader is a source of tokens, like a Lexer or a Stream.
+type TokenReader interface {
+	NextToken() (token.Token, error)
+}
+
+var (
+	_ TokenReader = &Lexer{}
+	_ TokenReader = &Stream{}
+)
+
+// Tokens returns an iterator over the remaining tokens, ending with EOF.
+// If the lexer fails, the error is yielded and the iteration stops.
+func (r *Lexer) Tokens() iter.Seq2[token.Token, error] {
+	return func(yield func(token.Token, error) bool) {
+		for {
+			t, err := r.NextToken()
+			if !yield(t, err) || err != nil || t.Type == token.EOF {
+				return
+			}
+		}
+	}
+}
+
+// All returns the remaining tokens, ending with EOF.
+func (r *Lexer) All() ([]token.Token, error) {
+	var tokens []token.Token
+	for t, err := range r.Tokens() {
+		if err != nil {
+			return tokens, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, nil
+}
+
+// Item is a result of lexing sent by a Stream.
+type Item struct {
+	Token token.Token
+	Err   error
+}
+
+// Stream lexes on its own goroutine, sending the tokens over a bounded
+// channel, so lexing can run ahead of a consumer like the parser.
+type Stream struct {
+	ctx   context.Context
+	items <-chan Item
+	// last is the EOF or error that ended the stream.
+	last *Item
+}
+
+// Stream starts lexing the remaining tokens on a new goroutine, buffering
+// up to size tokens. The lexer must not be used while the stream runs.
+// Cancelling ctx stops the goroutine.
+func (r *Lexer) Stream(ctx context.Context, size int) *Stream {
+	items := make(chan Item, size)
+	go func() {
+		defer close(items)
+		for t, err := range r.Tokens() {
+			select {
+			case items <- Item{Token: t, Err: err}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return &Stream{ctx: ctx, items: items}
+}
+
+// Items returns the channel the tokens are sent over. It's closed after
+// EOF, after an error or when the context is cancelled. A stream should be
+// read either from this channel or with NextToken, but not both.
+func (s *Stream) Items() <-chan Item {
+	return s.items
+}
+
+// NextToken returns the next token from the stream. Once the stream has
+// sent EOF or an error, it keeps returning it. If the stream is stopped
+// by its context, it returns the context error.
+func (s *Stream) NextToken() (token.Token, error) {
+	item, ok := <-s.items
+	if !ok {
+		if s.last != nil {
+			return s.last.Token, s.last.Err
+		}
+		return token.Token{}, s.ctx.Err()
+	}
+
+	if item.Err != nil || item.Token.Type == token.EOF {
+		s.last = &item
+	}
+	return item.Token, item.Err
+}
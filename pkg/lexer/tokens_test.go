@@ -0,0 +1,195 @@
+package lexer_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lexer"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+)
+
+func newLexer(input io.Reader) *lexer.Lexer {
+	return lexer.New(lexer.NewRunePeeker(bufio.NewReader(input)))
+}
+
+func tokenTypes(tokens []token.Token) []token.Type {
+	types := make([]token.Type, 0, len(tokens))
+	for _, t := range tokens {
+		types = append(types, t.Type)
+	}
+	return types
+}
+
+func TestLexerTokens(t *testing.T) {
+	l := newLexer(strings.NewReader("let x = 5;"))
+
+	var got []token.Token
+	for tok, err := range l.Tokens() {
+		assert.Nil(t, err)
+		got = append(got, tok)
+		if tok.Type == token.Ident {
+			break
+		}
+	}
+	assert.Equal(t, []token.Type{token.Let, token.Ident}, tokenTypes(got))
+
+	// the iteration continues where the previous one stopped
+	rest, err := l.All()
+	assert.Nil(t, err)
+	assert.Equal(t, []token.Type{token.Assign, token.Int, token.Semicolon, token.EOF}, tokenTypes(rest))
+}
+
+func TestLexerAllError(t *testing.T) {
+	readErr := errors.New("read failed")
+	l := newLexer(io.MultiReader(strings.NewReader("x + "), iotest.ErrReader(readErr)))
+
+	got, err := l.All()
+	assert.ErrorIs(t, err, readErr)
+	assert.Equal(t, []token.Type{token.Ident, token.Plus}, tokenTypes(got))
+}
+
+func TestLexerStream(t *testing.T) {
+	input := strings.Repeat("let x = 5; ", 100)
+	want, err := newLexer(strings.NewReader(input)).All()
+	assert.Nil(t, err)
+
+	var got []token.Token
+	for item := range newLexer(strings.NewReader(input)).Stream(context.Background(), 4).Items() {
+		assert.Nil(t, item.Err)
+		got = append(got, item.Token)
+	}
+	assert.Equal(t, want, got)
+
+	s := newLexer(strings.NewReader(input)).Stream(context.Background(), 4)
+	got = nil
+	for tok, err := s.NextToken(); tok.Type != token.EOF; tok, err = s.NextToken() {
+		assert.Nil(t, err)
+		got = append(got, tok)
+	}
+	assert.Equal(t, want[:len(want)-1], got)
+
+	// EOF keeps being returned once the stream is over
+	tok, err := s.NextToken()
+	assert.Nil(t, err)
+	assert.Equal(t, want[len(want)-1], tok)
+}
+
+func TestLexerStreamCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := newLexer(strings.NewReader(strings.Repeat("x ", 1000))).Stream(ctx, 1)
+
+	tok, err := s.NextToken()
+	assert.Nil(t, err)
+	assert.Equal(t, token.Ident, tok.Type)
+
+	cancel()
+	// the tokens already buffered can still be read
+	for err == nil {
+		_, err = s.NextToken()
+	}
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestLexerStreamError(t *testing.T) {
+	readErr := errors.New("read failed")
+	s := newLexer(io.MultiReader(strings.NewReader("x"), iotest.ErrReader(readErr))).Stream(context.Background(), 0)
+
+	tok, err := s.NextToken()
+	assert.Nil(t, err)
+	assert.Equal(t, token.Ident, tok.Type)
+
+	for i := 0; i < 2; i++ {
+		_, err = s.NextToken()
+		assert.ErrorIs(t, err, readErr)
+	}
+}
+
+func TestLexerTrivia(t *testing.T) {
+	l := newLexer(strings.NewReader("// doc\nx  / y // note \n\tz"))
+	l.Trivia = true
+
+	type tokenWithTrivia struct {
+		Literal           string
+		Leading, Trailing []token.Trivia
+	}
+	var got []tokenWithTrivia
+	for tok, err := range l.Tokens() {
+		assert.Nil(t, err)
+		leading, trailing := l.LastTrivia()
+		got = append(got, tokenWithTrivia{Literal: tok.Literal, Leading: leading, Trailing: trailing})
+	}
+
+	pos := func(offset, line, column int) token.Position {
+		return token.Position{Offset: offset, Line: line, Column: column}
+	}
+	assert.Equal(t, []tokenWithTrivia{
+		{
+			Literal: "x",
+			Leading: []token.Trivia{
+				{Type: token.LineComment, Text: "// doc", Pos: pos(0, 1, 1)},
+				{Type: token.Whitespace, Text: "\n", Pos: pos(6, 1, 7)},
+			},
+			Trailing: []token.Trivia{{Type: token.Whitespace, Text: "  ", Pos: pos(8, 2, 2)}},
+		},
+		{
+			Literal:  "/",
+			Trailing: []token.Trivia{{Type: token.Whitespace, Text: " ", Pos: pos(11, 2, 5)}},
+		},
+		{
+			Literal: "y",
+			Trailing: []token.Trivia{
+				{Type: token.Whitespace, Text: " ", Pos: pos(13, 2, 7)},
+				{Type: token.LineComment, Text: "// note", Pos: pos(14, 2, 8)},
+				{Type: token.Whitespace, Text: " ", Pos: pos(21, 2, 15)},
+			},
+		},
+		{
+			Literal: "z",
+			Leading: []token.Trivia{{Type: token.Whitespace, Text: "\n\t", Pos: pos(22, 2, 16)}},
+		},
+		{Literal: ""},
+	}, got)
+}
+
+func TestLexerTriviaTemplate(t *testing.T) {
+	l := newLexer(strings.NewReader("` a ${ b } `"))
+	l.Trivia = true
+
+	type tokenWithTrivia struct {
+		Token             token.Token
+		Leading, Trailing []token.Trivia
+	}
+	var got []tokenWithTrivia
+	for tok, err := range l.Tokens() {
+		assert.Nil(t, err)
+		leading, trailing := l.LastTrivia()
+		got = append(got, tokenWithTrivia{Token: tok, Leading: leading, Trailing: trailing})
+	}
+
+	pos := func(offset, line, column int) token.Position {
+		return token.Position{Offset: offset, Line: line, Column: column}
+	}
+	assert.Equal(t, []tokenWithTrivia{
+		{Token: token.Token{Type: token.Backtick, Literal: "`", Pos: pos(0, 1, 1)}},
+		{Token: token.Token{Type: token.TemplateText, Literal: " a ", Pos: pos(1, 1, 2)}},
+		{
+			Token:    token.Token{Type: token.DollarBrace, Literal: "${", Pos: pos(4, 1, 5)},
+			Trailing: []token.Trivia{{Type: token.Whitespace, Text: " ", Pos: pos(6, 1, 7)}},
+		},
+		{
+			Token:    token.Token{Type: token.Ident, Literal: "b", Pos: pos(7, 1, 8)},
+			Trailing: []token.Trivia{{Type: token.Whitespace, Text: " ", Pos: pos(8, 1, 9)}},
+		},
+		{Token: token.Token{Type: token.RBrace, Literal: "}", Pos: pos(9, 1, 10)}},
+		{Token: token.Token{Type: token.TemplateText, Literal: " ", Pos: pos(10, 1, 11)}},
+		{Token: token.Token{Type: token.Backtick, Literal: "`", Pos: pos(11, 1, 12)}},
+		{Token: token.Token{Type: token.EOF, Pos: pos(12, 1, 13)}},
+	}, got)
+}
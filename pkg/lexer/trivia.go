@@ -0,0 +1,125 @@
+package lexer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+)
+
+// commentTrailingSpace is the whitespace removed from the end of comments.
+const commentTrailingSpace = " \t\r"
+
+// TriviaReader is a TokenReader that keeps the trivia around tokens.
+type TriviaReader interface {
+	TokenReader
+	// LastTrivia returns the trivia around the last token returned by NextToken.
+	LastTrivia() (leading, trailing []token.Trivia)
+}
+
+var _ TriviaReader = &Lexer{}
+
+// LastTrivia returns the trivia around the last token, when Trivia is set.
+// The trailing trivia are the whitespace and comment that follow the token
+// up to the end of its line, and the leading trivia everything else since
+// the previous token. Together with the tokens, they reproduce the input,
+// as long as it's valid UTF-8.
+func (r *Lexer) LastTrivia() (leading, trailing []token.Trivia) {
+	return r.leading, r.trailing
+}
+
+func (r *Lexer) nextTokenWithTrivia() (token.Token, error) {
+	r.leading, r.trailing = nil, nil
+
+	var t token.Token
+	var err error
+	for {
+		if r.pending != nil {
+			t, err = r.pending.Token, r.pending.Err
+			r.pending = nil
+		} else {
+			// whitespace in the text of a template is part of the text
+			if !r.inText {
+				r.leading = appendWhitespace(r.leading, r.readWhitespace(false))
+			}
+			t, err = r.lex()
+		}
+		if err != nil || t.Type != token.Comment {
+			break
+		}
+		r.leading = appendComment(r.leading, t)
+	}
+
+	// a token followed by the text of a template, or by a pending ${ that
+	// ends it, has no trailing trivia
+	if err != nil || t.Type == token.EOF || r.inText || r.pending != nil {
+		return t, err
+	}
+
+	r.readTrailingTrivia()
+	return t, nil
+}
+
+// readTrailingTrivia reads the whitespace and comment after a token until
+// the end of the line. If it finds another token, it's kept as pending.
+func (r *Lexer) readTrailingTrivia() {
+	for {
+		r.trailing = appendWhitespace(r.trailing, r.readWhitespace(true))
+
+		// only a comment can follow in the trailing trivia, but telling it
+		// apart from a slash requires reading it
+		if ru, err := r.peeker.PeekRune(); err != nil || ru != '/' {
+			return
+		}
+
+		t, err := r.lex()
+		if err != nil || t.Type != token.Comment {
+			r.pending = &Item{Token: t, Err: err}
+			return
+		}
+		r.trailing = appendComment(r.trailing, t)
+	}
+}
+
+// readWhitespace reads whitespace, stopping before a newline if sameLine is set.
+func (r *Lexer) readWhitespace(sameLine bool) token.Trivia {
+	t := token.Trivia{Type: token.Whitespace, Pos: r.pos}
+
+	var text strings.Builder
+	for ru, err := r.peeker.PeekRune(); err == nil && unicode.IsSpace(ru); ru, err = r.peeker.PeekRune() {
+		if sameLine && ru == '\n' {
+			break
+		}
+		text.WriteRune(ru)
+		r.readRune()
+	}
+
+	t.Text = text.String()
+	return t
+}
+
+func appendWhitespace(trivia []token.Trivia, whitespace token.Trivia) []token.Trivia {
+	if whitespace.Text == "" {
+		return trivia
+	}
+	return append(trivia, whitespace)
+}
+
+// appendComment adds a comment token as trivia. The whitespace at the end
+// of the comment is split into its own trivia, since it's not part of the
+// comment literal in the tokens.
+func appendComment(trivia []token.Trivia, comment token.Token) []token.Trivia {
+	text := strings.TrimRight(comment.Literal, commentTrailingSpace)
+	trivia = append(trivia, token.Trivia{Type: token.LineComment, Text: text, Pos: comment.Pos})
+
+	return appendWhitespace(trivia, token.Trivia{
+		Type: token.Whitespace,
+		Text: comment.Literal[len(text):],
+		Pos: token.Position{
+			Offset: comment.Pos.Offset + len(text),
+			Line:   comment.Pos.Line,
+			Column: comment.Pos.Column + utf8.RuneCountInString(text),
+		},
+	})
+}
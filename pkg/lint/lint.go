@@ -0,0 +1,88 @@
+// Package lint finds suspicious constructs in Monkey programs.
+package lint
+
+import (
+	"sort"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+)
+
+// Rule checks a program for one kind of problem.
+type Rule interface {
+	// Name identifies the rule in configuration and suppression comments.
+	Name() string
+	// Description explains what the rule reports.
+	Description() string
+	// DefaultSeverity is used unless the configuration overrides it.
+	DefaultSeverity() Severity
+	Check(root *ast.Root) []Issue
+}
+
+// Issue is a problem found by a rule.
+type Issue struct {
+	Pos     token.Position
+	Message string
+}
+
+// Diagnostic is an issue reported by the linter, with the rule that found it.
+type Diagnostic struct {
+	Rule     string
+	Severity Severity
+	Pos      token.Position
+	Message  string
+}
+
+// Config customizes which rules run and how their issues are reported.
+type Config struct {
+	// Severities overrides the default severity of rules by name.
+	// SeverityOff disables a rule.
+	Severities map[string]Severity
+}
+
+type Linter struct {
+	registry *Registry
+	config   Config
+}
+
+func New(registry *Registry, config Config) *Linter {
+	return &Linter{
+		registry: registry,
+		config:   config,
+	}
+}
+
+// Lint runs all the enabled rules and returns the issues that are not suppressed,
+// sorted by position.
+func (l *Linter) Lint(root *ast.Root) []Diagnostic {
+	suppressions := newSuppressions(root)
+
+	var diagnostics []Diagnostic
+	for _, rule := range l.registry.Rules() {
+		severity := rule.DefaultSeverity()
+		if s, ok := l.config.Severities[rule.Name()]; ok {
+			severity = s
+		}
+		if severity == SeverityOff {
+			continue
+		}
+
+		for _, issue := range rule.Check(root) {
+			if suppressions.suppressed(rule.Name(), issue.Pos) {
+				continue
+			}
+			diagnostics = append(diagnostics, Diagnostic{
+				Rule:     rule.Name(),
+				Severity: severity,
+				Pos:      issue.Pos,
+				Message:  issue.Message,
+			})
+		}
+	}
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Pos.Offset < diagnostics[j].Pos.Offset
+	})
+
+	return diagnostics
+}
@@ -0,0 +1,173 @@
+package lint_test
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lexer"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lint"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/parser"
+)
+
+func TestLinterLint(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		config lint.Config
+		want   []string
+	}{
+		{
+			name:  "clean program",
+			input: `let x = 1; let f = fn(a) { a + x }; f(2);`,
+		},
+		{
+			name:  "unused let",
+			input: `let x = 1; let f = fn(a) { let y = a; a }; f(x);`,
+			want:  []string{"1:32 warning unused-let: y is declared but never used"},
+		},
+		{
+			name:  "unused destructured names",
+			input: `let [a, b] = [1, 2]; let {c} = {"c": a}; c;`,
+			want:  []string{"1:9 warning unused-let: b is declared but never used"},
+		},
+		{
+			name:  "exported let is used by other modules",
+			input: `import "lib"; export let x = 1; let y = 2;`,
+			want:  []string{"1:37 warning unused-let: y is declared but never used"},
+		},
+		{
+			name:  "self comparison",
+			input: `let x = 1; x == x; -x < -x; x + 1 != x + 1; x == 1; x + x;`,
+			want: []string{
+				"1:14 warning self-comparison: both operands of == are the same expression",
+				"1:23 warning self-comparison: both operands of < are the same expression",
+				"1:35 warning self-comparison: both operands of != are the same expression",
+			},
+		},
+		{
+			name:  "double negation",
+			input: `!!true; !!!false; !true;`,
+			want: []string{
+				"1:1 info double-negation: double negation has no effect on booleans",
+				"1:9 info double-negation: double negation has no effect on booleans",
+			},
+		},
+		{
+			name:  "division by zero",
+			input: `let x = 10 / 0; x / 2;`,
+			want:  []string{"1:12 error division-by-zero: division by zero"},
+		},
+		{
+			name: "redefined name",
+			input: `let x = 1;
+let f = fn(x) { let x = 2; x };
+let x = f(x);
+x;`,
+			want: []string{
+				"2:21 warning redefined-name: x redeclared in this scope, previous declaration at 2:12",
+				"3:5 warning redefined-name: x redeclared in this scope, previous declaration at 1:5",
+			},
+		},
+		{
+			name: "non exhaustive match",
+			input: `let b = 1 > 2;
+match (b) { true => 1 };
+match (b) { true => 1, x if x => 2 };
+match (b) { true => 1, false => 2 };
+match (b) { false => 1, _ => 2 };
+match (1) { 1 => 1 };`,
+			want: []string{
+				"2:1 warning non-exhaustive-match: match on a bool doesn't cover false",
+				"3:1 warning non-exhaustive-match: match on a bool doesn't cover false",
+			},
+		},
+		{
+			name:  "severity configuration",
+			input: `let x = 10 / 0; !!x;`,
+			config: lint.Config{Severities: map[string]lint.Severity{
+				"division-by-zero": lint.SeverityWarning,
+				"double-negation":  lint.SeverityOff,
+			}},
+			want: []string{"1:12 warning division-by-zero: division by zero"},
+		},
+		{
+			name: "suppression comments",
+			input: `let unused = 1; // nolint: unused-let
+// nolint
+let x = 1 / 0 == 1 / 0;
+let y = 1 / 0; // nolint:unused-let
+x == x; // nolint:self-comparison,double-negation
+// nolintable
+!!x;`,
+			want: []string{
+				"4:11 error division-by-zero: division by zero",
+				"7:1 info double-negation: double negation has no effect on booleans",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			l := lint.New(lint.DefaultRegistry(), tc.config)
+
+			var got []string
+			for _, d := range l.Lint(parse(g, tc.input)) {
+				got = append(got, fmt.Sprintf("%s %s %s: %s", d.Pos, d.Severity, d.Rule, d.Message))
+			}
+			g.Expect(got).To(Equal(tc.want))
+		})
+	}
+}
+
+type noCalls struct{}
+
+func (noCalls) Name() string                   { return "no-calls" }
+func (noCalls) Description() string            { return "calls are forbidden" }
+func (noCalls) DefaultSeverity() lint.Severity { return lint.SeverityError }
+func (noCalls) Check(root *ast.Root) []lint.Issue {
+	var issues []lint.Issue
+	ast.Inspect(root, func(n ast.Node) bool {
+		if c, ok := n.(*ast.Call); ok {
+			issues = append(issues, lint.Issue{Pos: c.Pos(), Message: "call"})
+		}
+		return true
+	})
+	return issues
+}
+
+func TestRegistry(t *testing.T) {
+	g := NewWithT(t)
+	r := lint.NewRegistry()
+	g.Expect(r.Register(noCalls{})).To(Succeed())
+	g.Expect(r.Register(noCalls{})).To(MatchError("rule no-calls is already registered"))
+	g.Expect(r.Get("no-calls")).To(Equal(noCalls{}))
+	g.Expect(r.Get("unused-let")).To(BeNil())
+
+	diagnostics := lint.New(r, lint.Config{}).Lint(parse(g, `f(1);`))
+	g.Expect(diagnostics).To(HaveLen(1))
+	g.Expect(diagnostics[0].Rule).To(Equal("no-calls"))
+}
+
+func TestParseSeverity(t *testing.T) {
+	g := NewWithT(t)
+	s, err := lint.ParseSeverity("warning")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(s).To(Equal(lint.SeverityWarning))
+
+	_, err = lint.ParseSeverity("fatal")
+	g.Expect(err).To(MatchError(`invalid severity "fatal"`))
+}
+
+func parse(g *WithT, input string) *ast.Root {
+	l := lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)
+	root, err := parser.New(l).Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+	return root
+}
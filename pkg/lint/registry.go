@@ -0,0 +1,56 @@
+package lint
+
+import "github.com/pkg/errors"
+
+// Registry holds the rules available to the linter.
+type Registry struct {
+	rules map[string]Rule
+	// names keeps the registration order, so rules always run in the same order.
+	names []string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{rules: map[string]Rule{}}
+}
+
+// DefaultRegistry returns a registry with all the rules in this package.
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	for _, rule := range []Rule{
+		unusedLet{},
+		selfComparison{},
+		doubleNegation{},
+		divisionByZero{},
+		redefinition{},
+		nonExhaustiveMatch{},
+	} {
+		// names are unique, so this can't fail
+		_ = r.Register(rule)
+	}
+	return r
+}
+
+// Register adds a rule. It fails if there is already a rule with the same name.
+func (r *Registry) Register(rule Rule) error {
+	if _, ok := r.rules[rule.Name()]; ok {
+		return errors.Errorf("rule %s is already registered", rule.Name())
+	}
+
+	r.rules[rule.Name()] = rule
+	r.names = append(r.names, rule.Name())
+	return nil
+}
+
+// Get returns the rule with the given name or nil if it's not registered.
+func (r *Registry) Get(name string) Rule {
+	return r.rules[name]
+}
+
+// Rules returns all the registered rules in registration order.
+func (r *Registry) Rules() []Rule {
+	rules := make([]Rule, 0, len(r.names))
+	for _, name := range r.names {
+		rules = append(rules, r.rules[name])
+	}
+	return rules
+}
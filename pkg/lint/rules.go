@@ -0,0 +1,229 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/scope"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/types"
+)
+
+// unusedLet reports let bindings that are never referenced.
+// Exported bindings are skipped, since they can be used by other modules.
+type unusedLet struct{}
+
+func (unusedLet) Name() string { return "unused-let" }
+
+func (unusedLet) Description() string { return "let bindings that are never used" }
+
+func (unusedLet) DefaultSeverity() Severity { return SeverityWarning }
+
+func (unusedLet) Check(root *ast.Root) []Issue {
+	var issues []Issue
+	for _, s := range scope.Resolve(root).Symbols {
+		if _, ok := s.Declaration.(*ast.Let); !ok || s.Exported || len(s.Uses) > 0 {
+			continue
+		}
+		issues = append(issues, Issue{
+			Pos:     s.Name.Pos(),
+			Message: fmt.Sprintf("%s is declared but never used", s.Name.Value),
+		})
+	}
+	return issues
+}
+
+// selfComparison reports comparisons of an expression with itself, like x == x.
+type selfComparison struct{}
+
+func (selfComparison) Name() string { return "self-comparison" }
+
+func (selfComparison) Description() string {
+	return "comparisons whose operands are the same expression"
+}
+
+func (selfComparison) DefaultSeverity() Severity { return SeverityWarning }
+
+func (selfComparison) Check(root *ast.Root) []Issue {
+	var issues []Issue
+	ast.Inspect(root, func(n ast.Node) bool {
+		infix, ok := n.(*ast.Infix)
+		if !ok {
+			return true
+		}
+		switch infix.Operator {
+		case ast.Equal, ast.NotEqual, ast.LessThan, ast.GreaterThan:
+		default:
+			return true
+		}
+
+		if sameExpression(infix.Left, infix.Right) {
+			issues = append(issues, Issue{
+				Pos:     infix.Pos(),
+				Message: fmt.Sprintf("both operands of %s are the same expression", infix.Operator),
+			})
+		}
+		return true
+	})
+	return issues
+}
+
+// sameExpression reports whether two expressions always produce the same value.
+// Calls are never considered the same since they can have side effects.
+func sameExpression(a, b ast.Expression) bool {
+	switch a := a.(type) {
+	case *ast.Identifier:
+		b, ok := b.(*ast.Identifier)
+		return ok && a.Value == b.Value
+	case *ast.Literal:
+		b, ok := b.(*ast.Literal)
+		return ok && a.Value == b.Value
+	case *ast.Boolean:
+		b, ok := b.(*ast.Boolean)
+		return ok && a.Value == b.Value
+	case *ast.StringLiteral:
+		b, ok := b.(*ast.StringLiteral)
+		return ok && a.Value == b.Value
+	case *ast.Prefix:
+		b, ok := b.(*ast.Prefix)
+		return ok && a.Operator == b.Operator && sameExpression(a.Right, b.Right)
+	case *ast.Infix:
+		b, ok := b.(*ast.Infix)
+		return ok && a.Operator == b.Operator && sameExpression(a.Left, b.Left) && sameExpression(a.Right, b.Right)
+	}
+	return false
+}
+
+// doubleNegation reports negations of negations, like !!x.
+type doubleNegation struct{}
+
+func (doubleNegation) Name() string { return "double-negation" }
+
+func (doubleNegation) Description() string { return "boolean negations applied twice" }
+
+func (doubleNegation) DefaultSeverity() Severity { return SeverityInfo }
+
+func (doubleNegation) Check(root *ast.Root) []Issue {
+	var issues []Issue
+	ast.Inspect(root, func(n ast.Node) bool {
+		outer, ok := n.(*ast.Prefix)
+		if !ok || outer.Operator != ast.Not {
+			return true
+		}
+		if inner, ok := outer.Right.(*ast.Prefix); ok && inner.Operator == ast.Not {
+			issues = append(issues, Issue{
+				Pos:     outer.Pos(),
+				Message: "double negation has no effect on booleans",
+			})
+			// don't report !!! twice
+			return false
+		}
+		return true
+	})
+	return issues
+}
+
+// divisionByZero reports divisions by the literal 0.
+type divisionByZero struct{}
+
+func (divisionByZero) Name() string { return "division-by-zero" }
+
+func (divisionByZero) Description() string { return "divisions by a literal zero" }
+
+func (divisionByZero) DefaultSeverity() Severity { return SeverityError }
+
+func (divisionByZero) Check(root *ast.Root) []Issue {
+	var issues []Issue
+	ast.Inspect(root, func(n ast.Node) bool {
+		infix, ok := n.(*ast.Infix)
+		if !ok || infix.Operator != ast.Division {
+			return true
+		}
+		if l, ok := infix.Right.(*ast.Literal); ok && l.Value == 0 {
+			issues = append(issues, Issue{
+				Pos:     infix.Pos(),
+				Message: "division by zero",
+			})
+		}
+		return true
+	})
+	return issues
+}
+
+// redefinition reports names declared twice in the same scope.
+type redefinition struct{}
+
+func (redefinition) Name() string { return "redefined-name" }
+
+func (redefinition) Description() string { return "names declared more than once in the same scope" }
+
+func (redefinition) DefaultSeverity() Severity { return SeverityWarning }
+
+func (redefinition) Check(root *ast.Root) []Issue {
+	var issues []Issue
+	for _, s := range scope.Resolve(root).Symbols {
+		if s.Redefines == nil {
+			continue
+		}
+		issues = append(issues, Issue{
+			Pos:     s.Name.Pos(),
+			Message: fmt.Sprintf("%s redeclared in this scope, previous declaration at %s", s.Name.Value, s.Redefines.Name.Pos()),
+		})
+	}
+	return issues
+}
+
+// nonExhaustiveMatch reports matches on booleans that don't cover both
+// true and false. Arms with guards don't count, since they may not match.
+type nonExhaustiveMatch struct{}
+
+func (nonExhaustiveMatch) Name() string { return "non-exhaustive-match" }
+
+func (nonExhaustiveMatch) Description() string {
+	return "matches on booleans missing a case for true or false"
+}
+
+func (nonExhaustiveMatch) DefaultSeverity() Severity { return SeverityWarning }
+
+func (nonExhaustiveMatch) Check(root *ast.Root) []Issue {
+	// the types are inferred even if the program has type errors
+	info, _ := types.New().Check(root)
+
+	var issues []Issue
+	ast.Inspect(root, func(n ast.Node) bool {
+		m, ok := n.(*ast.Match)
+		if !ok || info.TypeOf(m.Subject) != types.Bool {
+			return true
+		}
+
+		covered := map[bool]bool{}
+		for _, arm := range m.Arms {
+			if arm.Guard != nil {
+				continue
+			}
+			switch p := arm.Pattern.(type) {
+			case *ast.WildcardPattern, *ast.BindingPattern:
+				return true
+			case *ast.LiteralPattern:
+				if b, ok := p.Value.(*ast.Boolean); ok {
+					covered[b.Value] = true
+				}
+			}
+		}
+
+		var missing []string
+		for _, b := range []bool{true, false} {
+			if !covered[b] {
+				missing = append(missing, fmt.Sprint(b))
+			}
+		}
+		if len(missing) > 0 {
+			issues = append(issues, Issue{
+				Pos:     m.Pos(),
+				Message: fmt.Sprintf("match on a bool doesn't cover %s", strings.Join(missing, " or ")),
+			})
+		}
+		return true
+	})
+	return issues
+}
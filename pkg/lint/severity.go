@@ -0,0 +1,58 @@
+package lint
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+type Severity int
+
+const (
+	SeverityOff Severity = iota
+	SeverityInfo
+	SeverityWarning
+	SeverityError
+)
+
+var severityStrings = []string{
+	"off",
+	"info",
+	"warning",
+	"error",
+}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityStrings) {
+		return "unknown"
+	}
+	return severityStrings[s]
+}
+
+// ParseSeverity returns the severity with the given name.
+func ParseSeverity(name string) (Severity, error) {
+	for i, s := range severityStrings {
+		if s == name {
+			return Severity(i), nil
+		}
+	}
+	return SeverityOff, errors.Errorf("invalid severity %q", name)
+}
+
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *Severity) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err != nil {
+		return err
+	}
+
+	parsed, err := ParseSeverity(name)
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
@@ -0,0 +1,88 @@
+package lint
+
+import (
+	"strings"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+)
+
+// suppressionPrefix starts the comments that disable rules.
+// `// nolint` disables all the rules and `// nolint: rule-a, rule-b` only the listed ones.
+// A trailing comment applies to its own line, a comment in its own line applies to the next one.
+const suppressionPrefix = "nolint"
+
+// suppressions maps lines to the rules disabled on them.
+// An empty set of rules means all of them.
+type suppressions map[int]map[string]struct{}
+
+func newSuppressions(root *ast.Root) suppressions {
+	s := suppressions{}
+	if len(root.Comments) == 0 {
+		return s
+	}
+
+	// lines with code before a comment, to tell trailing comments apart
+	codeBefore := map[int]int{}
+	ast.Inspect(root, func(n ast.Node) bool {
+		pos := n.Pos()
+		if current, ok := codeBefore[pos.Line]; pos.IsValid() && (!ok || pos.Offset < current) {
+			codeBefore[pos.Line] = pos.Offset
+		}
+		return true
+	})
+
+	for _, c := range root.Comments {
+		text := strings.TrimSpace(strings.TrimPrefix(c.Token.Literal, "//"))
+		if !strings.HasPrefix(text, suppressionPrefix) {
+			continue
+		}
+		text = strings.TrimPrefix(text, suppressionPrefix)
+		if text != "" && !strings.HasPrefix(text, ":") && !strings.HasPrefix(text, " ") {
+			// a different word like nolintable
+			continue
+		}
+
+		rules := map[string]struct{}{}
+		if list := strings.TrimPrefix(strings.TrimSpace(text), ":"); strings.TrimSpace(list) != "" {
+			for _, rule := range strings.Split(list, ",") {
+				rules[strings.TrimSpace(rule)] = struct{}{}
+			}
+		}
+
+		line := c.Pos().Line
+		if first, ok := codeBefore[line]; !ok || first > c.Pos().Offset {
+			line++
+		}
+		s.add(line, rules)
+	}
+
+	return s
+}
+
+func (s suppressions) add(line int, rules map[string]struct{}) {
+	current, ok := s[line]
+	if ok && len(current) == 0 {
+		// already suppressing everything
+		return
+	}
+	if !ok || len(rules) == 0 {
+		s[line] = rules
+		return
+	}
+	for r := range rules {
+		current[r] = struct{}{}
+	}
+}
+
+func (s suppressions) suppressed(rule string, pos token.Position) bool {
+	rules, ok := s[pos.Line]
+	if !ok {
+		return false
+	}
+	if len(rules) == 0 {
+		return true
+	}
+	_, ok = rules[rule]
+	return ok
+}
@@ -0,0 +1,177 @@
+package lsp
+
+import (
+	"bufio"
+	"errors"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lexer"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/parser"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/scope"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/types"
+)
+
+// document is an open text document and the result of analyzing it.
+type document struct {
+	uri  string
+	text string
+	// lines holds the byte offset where each line starts.
+	lines []int
+
+	tokens []token.Token
+
+	root        *ast.Root
+	parseErrors []parser.Error
+	types       *types.Info
+	scopes      *scope.Info
+}
+
+func newDocument(uri, text string) *document {
+	d := &document{
+		uri:   uri,
+		text:  text,
+		lines: []int{0},
+	}
+	for i, c := range text {
+		if c == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+
+	d.lex()
+	d.parse()
+
+	// types are informative, errors are already reported by the parser or left to the checker users
+	d.types, _ = types.New().Check(d.root)
+	d.scopes = scope.Resolve(d.root)
+
+	return d
+}
+
+func newLexer(text string) *lexer.Lexer {
+	return lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(text))),
+	)
+}
+
+func (d *document) lex() {
+	l := newLexer(d.text)
+	for {
+		t, err := l.NextToken()
+		if err != nil || t.Type == token.EOF {
+			break
+		}
+		d.tokens = append(d.tokens, t)
+	}
+}
+
+func (d *document) parse() {
+	p := parser.New(newLexer(d.text))
+	d.root, _ = p.Parse()
+	for _, err := range p.Errors() {
+		var perr parser.Error
+		if errors.As(err, &perr) {
+			d.parseErrors = append(d.parseErrors, perr)
+		}
+	}
+}
+
+// position converts a byte offset to an LSP position.
+func (d *document) position(offset int) Position {
+	if offset > len(d.text) {
+		offset = len(d.text)
+	}
+	line := sort.Search(len(d.lines), func(i int) bool { return d.lines[i] > offset }) - 1
+
+	return Position{
+		Line:      line,
+		Character: utf16Len(d.text[d.lines[line]:offset]),
+	}
+}
+
+// offset converts an LSP position to a byte offset.
+func (d *document) offset(p Position) int {
+	if p.Line >= len(d.lines) {
+		return len(d.text)
+	}
+	if p.Line < 0 {
+		return 0
+	}
+
+	offset := d.lines[p.Line]
+	for units := 0; units < p.Character && offset < len(d.text); {
+		r, size := utf8.DecodeRuneInString(d.text[offset:])
+		if r == '\n' {
+			break
+		}
+		units += len(utf16.Encode([]rune{r}))
+		offset += size
+	}
+
+	return offset
+}
+
+func (d *document) tokenRange(t token.Token) Range {
+	return Range{
+		Start: d.position(t.Pos.Offset),
+		End:   d.position(t.Pos.Offset + len(t.Literal)),
+	}
+}
+
+func (d *document) nodeRange(n ast.Node) Range {
+	return Range{
+		Start: d.position(start(n)),
+		End:   d.position(ast.End(n)),
+	}
+}
+
+// start returns the offset where a node begins.
+// Nodes are built from their operator token, so the
+// leftmost token might not be the node's one.
+func start(n ast.Node) int {
+	s := n.Pos().Offset
+	ast.Inspect(n, func(c ast.Node) bool {
+		if c.Pos().IsValid() && c.Pos().Offset < s {
+			s = c.Pos().Offset
+		}
+		return true
+	})
+	return s
+}
+
+// identifierAt returns the identifier that contains the offset, if any.
+func (d *document) identifierAt(offset int) *ast.Identifier {
+	for _, id := range d.scopes.Identifiers {
+		if id.Pos().Offset <= offset && offset <= id.Pos().Offset+len(id.Value) {
+			return id
+		}
+	}
+	return nil
+}
+
+// typeOf returns the inferred type of an identifier as a string or an empty string if unknown.
+func (d *document) typeOf(id *ast.Identifier) string {
+	t := d.types.TypeOf(id)
+	if t == nil {
+		if s, ok := d.scopes.Uses[id]; ok {
+			t = d.types.TypeOf(s.Name)
+		}
+	}
+	if t == nil {
+		return ""
+	}
+	return t.String()
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += len(utf16.Encode([]rune{r}))
+	}
+	return n
+}
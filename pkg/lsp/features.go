@@ -0,0 +1,233 @@
+package lsp
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/types"
+)
+
+const source = "monkey"
+
+func (d *document) diagnostics() []Diagnostic {
+	diagnostics := []Diagnostic{}
+	for _, e := range d.parseErrors {
+		message := e.Error()
+		if cause := errors.Unwrap(e); cause != nil {
+			message = cause.Error()
+		}
+
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    d.tokenRange(e.Token()),
+			Severity: SeverityError,
+			Source:   source,
+			Message:  message,
+		})
+	}
+	return diagnostics
+}
+
+// semanticTokenTypes is the legend of the semantic tokens.
+// The index of each type is the value used in the encoded tokens.
+var semanticTokenTypes = []string{"keyword", "variable", "number", "operator", "comment", "string"}
+
+const (
+	semanticKeyword = iota
+	semanticVariable
+	semanticNumber
+	semanticOperator
+	semanticComment
+	semanticString
+)
+
+func semanticTokenType(t token.Type) (int, bool) {
+	switch t {
+	case token.Function, token.Let, token.True, token.False, token.Null, token.If, token.Else, token.Return,
+		token.Import, token.Export, token.While, token.For, token.In, token.Break, token.Continue,
+		token.Throw, token.Try, token.Catch, token.Finally, token.Match:
+		return semanticKeyword, true
+	case token.Ident:
+		return semanticVariable, true
+	case token.Int:
+		return semanticNumber, true
+	case token.Comment:
+		return semanticComment, true
+	case token.String, token.Backtick, token.TemplateText:
+		return semanticString, true
+	case token.Assign, token.Plus, token.Minus, token.Bang, token.Asterisk, token.Slash,
+		token.PlusAssign, token.MinusAssign, token.AsteriskAssign, token.SlashAssign,
+		token.Equal, token.NotEqual, token.LowerThan, token.GreaterThan,
+		token.NullCoalesce, token.QuestionDot, token.QuestionBracket, token.FatArrow, token.Ellipsis:
+		return semanticOperator, true
+	}
+	return 0, false
+}
+
+// semanticTokens encodes the tokens of the document as a list of
+// line delta, start delta, length, type and modifiers.
+func (d *document) semanticTokens() SemanticTokens {
+	data := []int{}
+	var previous Position
+	for _, t := range d.tokens {
+		tokenType, ok := semanticTokenType(t.Type)
+		if !ok {
+			continue
+		}
+
+		// semantic tokens can't span lines, like the text of a template can
+		for _, t := range splitLines(t) {
+			r := d.tokenRange(t)
+			deltaStart := r.Start.Character
+			if r.Start.Line == previous.Line {
+				deltaStart -= previous.Character
+			}
+			data = append(data,
+				r.Start.Line-previous.Line,
+				deltaStart,
+				r.End.Character-r.Start.Character,
+				tokenType,
+				0,
+			)
+			previous = r.Start
+		}
+	}
+
+	return SemanticTokens{Data: data}
+}
+
+// splitLines splits a token into a token for each of its lines,
+// leaving out the empty ones.
+func splitLines(t token.Token) []token.Token {
+	if !strings.Contains(t.Literal, "\n") {
+		return []token.Token{t}
+	}
+
+	var tokens []token.Token
+	pos := t.Pos
+	for i, line := range strings.Split(t.Literal, "\n") {
+		if i > 0 {
+			pos = token.Position{Offset: pos.Offset + 1, Line: pos.Line + 1, Column: 1}
+		}
+		if line != "" {
+			tokens = append(tokens, token.Token{Type: t.Type, Literal: line, Pos: pos})
+		}
+		pos.Offset += len(line)
+		pos.Column += utf8.RuneCountInString(line)
+	}
+	return tokens
+}
+
+func (d *document) documentSymbols() []DocumentSymbol {
+	return d.letSymbols(d.root.Statements)
+}
+
+// letSymbols returns a symbol for each name declared by a let statement,
+// including the ones declared inside function bodies as children.
+func (d *document) letSymbols(statements []ast.Statement) []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	for _, s := range statements {
+		l, ok := s.(*ast.Let)
+		if !ok {
+			continue
+		}
+		if l.Pattern != nil {
+			for _, name := range l.Names() {
+				symbols = append(symbols, DocumentSymbol{
+					Name:           name.Value,
+					Detail:         d.typeOf(name),
+					Kind:           SymbolKindVariable,
+					Range:          d.nodeRange(l),
+					SelectionRange: d.tokenRange(name.Token),
+				})
+			}
+			continue
+		}
+
+		symbol := DocumentSymbol{
+			Name:           l.Name.Value,
+			Detail:         d.typeOf(l.Name),
+			Kind:           SymbolKindVariable,
+			Range:          d.nodeRange(l),
+			SelectionRange: d.tokenRange(l.Name.Token),
+		}
+		if f, ok := l.Value.(*ast.FunctionLiteral); ok {
+			symbol.Kind = SymbolKindFunction
+			symbol.Children = d.letSymbols(f.Body.Statements)
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+func (d *document) definition(p Position) *Location {
+	id := d.identifierAt(d.offset(p))
+	if id == nil {
+		return nil
+	}
+
+	sym, ok := d.scopes.Uses[id]
+	if !ok {
+		return nil
+	}
+
+	return &Location{
+		URI:   d.uri,
+		Range: d.tokenRange(sym.Name.Token),
+	}
+}
+
+func (d *document) hover(p Position) *Hover {
+	id := d.identifierAt(d.offset(p))
+	if id == nil {
+		return nil
+	}
+
+	t := d.typeOf(id)
+	if t == "" {
+		return nil
+	}
+
+	return &Hover{
+		Contents: MarkupContent{
+			Kind:  "markdown",
+			Value: fmt.Sprintf("```monkey\n%s: %s\n```", id.Value, t),
+		},
+		Range: d.tokenRange(id.Token),
+	}
+}
+
+func (d *document) completion(p Position) []CompletionItem {
+	items := []CompletionItem{}
+	visible := d.scopes.VisibleAt(d.offset(p))
+	for _, name := range types.BuiltinNames() {
+		// a declaration with the same name hides the builtin
+		if _, ok := visible[name]; !ok {
+			items = append(items, CompletionItem{
+				Label:  name,
+				Kind:   CompletionKindFunction,
+				Detail: "builtin",
+			})
+		}
+	}
+	for name, sym := range visible {
+		item := CompletionItem{
+			Label:  name,
+			Kind:   CompletionKindVariable,
+			Detail: d.typeOf(sym.Name),
+		}
+		if sym.Function {
+			item.Kind = CompletionKindFunction
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Label < items[j].Label
+	})
+	return items
+}
@@ -0,0 +1,208 @@
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/jsonrpc"
+)
+
+// Server is a Language Server Protocol server for Monkey.
+// It keeps the open documents in memory and analyzes them on each change.
+type Server struct {
+	conn      *jsonrpc.Conn
+	documents map[string]*document
+	shutdown  bool
+}
+
+func NewServer(conn *jsonrpc.Conn) *Server {
+	return &Server{
+		conn:      conn,
+		documents: map[string]*document{},
+	}
+}
+
+// errExit signals the client asked the server to exit.
+var errExit = errors.New("exit")
+
+// Serve handles messages until the client sends the exit notification or closes the connection.
+// Messages that aren't valid JSON get a parse error response, since the next ones can still be read.
+func (s *Server) Serve() error {
+	for {
+		m, err := s.conn.Read()
+		if err == io.EOF {
+			return nil
+		}
+		decodeErr := &jsonrpc.DecodeError{}
+		if errors.As(err, &decodeErr) {
+			if err := s.conn.Write(jsonrpc.NewErrorResponse(nil, jsonrpc.ParseError, decodeErr.Error())); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := s.handle(m); err == errExit {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(m *jsonrpc.Message) error {
+	if m.IsNotification() {
+		return s.handleNotification(m.Method, m.Params)
+	}
+
+	if !m.IsRequest() {
+		// the server doesn't send requests, so it doesn't expect responses
+		return nil
+	}
+
+	result, err := s.handleRequest(m.Method, m.Params)
+	if err != nil {
+		rpcErr := &jsonrpc.Error{}
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &jsonrpc.Error{Code: jsonrpc.InternalError, Message: err.Error()}
+		}
+		return s.conn.Write(jsonrpc.NewErrorResponse(m.ID, rpcErr.Code, rpcErr.Message))
+	}
+
+	response, err := jsonrpc.NewResponse(m.ID, result)
+	if err != nil {
+		return err
+	}
+	return s.conn.Write(response)
+}
+
+func (s *Server) handleRequest(method string, params json.RawMessage) (any, error) {
+	if s.shutdown {
+		return nil, &jsonrpc.Error{Code: jsonrpc.InvalidRequest, Message: "server is shutting down"}
+	}
+
+	switch method {
+	case "initialize":
+		return s.initialize(), nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/semanticTokens/full":
+		p := SemanticTokensParams{}
+		d, err := s.document(params, &p, &p.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return d.semanticTokens(), nil
+	case "textDocument/documentSymbol":
+		p := DocumentSymbolParams{}
+		d, err := s.document(params, &p, &p.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return d.documentSymbols(), nil
+	case "textDocument/definition":
+		p := TextDocumentPositionParams{}
+		d, err := s.document(params, &p, &p.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return d.definition(p.Position), nil
+	case "textDocument/hover":
+		p := TextDocumentPositionParams{}
+		d, err := s.document(params, &p, &p.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return d.hover(p.Position), nil
+	case "textDocument/completion":
+		p := TextDocumentPositionParams{}
+		d, err := s.document(params, &p, &p.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return d.completion(p.Position), nil
+	}
+
+	return nil, &jsonrpc.Error{Code: jsonrpc.MethodNotFound, Message: "method not supported: " + method}
+}
+
+func (s *Server) handleNotification(method string, params json.RawMessage) error {
+	switch method {
+	case "exit":
+		return errExit
+	case "textDocument/didOpen":
+		p := DidOpenTextDocumentParams{}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil
+		}
+		return s.update(p.TextDocument.URI, p.TextDocument.Text)
+	case "textDocument/didChange":
+		p := DidChangeTextDocumentParams{}
+		if err := json.Unmarshal(params, &p); err != nil || len(p.ContentChanges) == 0 {
+			return nil
+		}
+		return s.update(p.TextDocument.URI, p.ContentChanges[len(p.ContentChanges)-1].Text)
+	case "textDocument/didClose":
+		p := DidCloseTextDocumentParams{}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil
+		}
+		delete(s.documents, p.TextDocument.URI)
+	}
+
+	// unknown notifications are ignored, as the protocol requires
+	return nil
+}
+
+func (s *Server) initialize() InitializeResult {
+	return InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:       textDocumentSyncFull,
+			HoverProvider:          true,
+			DefinitionProvider:     true,
+			DocumentSymbolProvider: true,
+			CompletionProvider:     &CompletionOptions{},
+			SemanticTokensProvider: &SemanticTokensOptions{
+				Legend: SemanticTokensLegend{
+					TokenTypes:     semanticTokenTypes,
+					TokenModifiers: []string{},
+				},
+				Full: true,
+			},
+		},
+		ServerInfo: ServerInfo{Name: "monkey-lsp"},
+	}
+}
+
+// update analyzes the new content of a document and publishes its diagnostics.
+func (s *Server) update(uri, text string) error {
+	d := newDocument(uri, text)
+	s.documents[uri] = d
+
+	n, err := jsonrpc.NewNotification("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: d.diagnostics(),
+	})
+	if err != nil {
+		return err
+	}
+	return s.conn.Write(n)
+}
+
+// document decodes the request params into p and returns the document identified by id.
+func (s *Server) document(params json.RawMessage, p any, id *TextDocumentIdentifier) (*document, error) {
+	if err := json.Unmarshal(params, p); err != nil {
+		return nil, &jsonrpc.Error{Code: jsonrpc.InvalidParams, Message: err.Error()}
+	}
+
+	d, ok := s.documents[id.URI]
+	if !ok {
+		return nil, &jsonrpc.Error{Code: jsonrpc.InvalidParams, Message: "unknown document: " + id.URI}
+	}
+	return d, nil
+}
@@ -0,0 +1,388 @@
+package lsp_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/jsonrpc"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lsp"
+)
+
+const uri = "file:///test.monkey"
+
+const program = `let add = fn(a, b) {
+	let sum = a + b;
+	sum
+};
+let five = 5;
+add(five, 1);
+`
+
+// client is an in-process LSP client talking to a server running in a goroutine.
+type client struct {
+	t    *testing.T
+	conn *jsonrpc.Conn
+	// raw writes to the server without framing the messages
+	raw           io.Writer
+	nextID        int
+	notifications []*jsonrpc.Message
+	done          chan error
+}
+
+func newClient(t *testing.T) *client {
+	serverReader, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+
+	c := &client{
+		t:    t,
+		conn: jsonrpc.NewConn(clientReader, clientWriter),
+		raw:  clientWriter,
+		done: make(chan error, 1),
+	}
+
+	go func() {
+		c.done <- lsp.NewServer(jsonrpc.NewConn(serverReader, serverWriter)).Serve()
+		serverWriter.Close()
+	}()
+
+	t.Cleanup(func() {
+		clientWriter.Close()
+		<-c.done
+	})
+
+	return c
+}
+
+// call sends a request and waits for its response, storing any notification received meanwhile.
+func (c *client) call(method string, params, result any) *jsonrpc.Error {
+	c.t.Helper()
+	c.nextID++
+	m, err := jsonrpc.NewRequest(c.nextID, method, params)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if err := c.conn.Write(m); err != nil {
+		c.t.Fatal(err)
+	}
+
+	for {
+		response, err := c.conn.Read()
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		if response.Method != "" {
+			c.notifications = append(c.notifications, response)
+			continue
+		}
+		if string(*response.ID) != fmt.Sprintf("%d", c.nextID) {
+			c.t.Fatalf("unexpected response id %s", *response.ID)
+		}
+		if response.Error != nil {
+			return response.Error
+		}
+		if err := json.Unmarshal(response.Result, result); err != nil {
+			c.t.Fatal(err)
+		}
+		return nil
+	}
+}
+
+func (c *client) notify(method string, params any) {
+	c.t.Helper()
+	m, err := jsonrpc.NewNotification(method, params)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if err := c.conn.Write(m); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// readNotification waits for the next notification from the server.
+func (c *client) readNotification(params any) string {
+	c.t.Helper()
+	m, err := c.conn.Read()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if err := json.Unmarshal(m.Params, params); err != nil {
+		c.t.Fatal(err)
+	}
+	return m.Method
+}
+
+func (c *client) open(text string) lsp.PublishDiagnosticsParams {
+	c.t.Helper()
+	c.notify("textDocument/didOpen", lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{URI: uri, LanguageID: "monkey", Version: 1, Text: text},
+	})
+	diagnostics := lsp.PublishDiagnosticsParams{}
+	if method := c.readNotification(&diagnostics); method != "textDocument/publishDiagnostics" {
+		c.t.Fatalf("unexpected notification %s", method)
+	}
+	return diagnostics
+}
+
+func position(uri string, line, character int) lsp.TextDocumentPositionParams {
+	return lsp.TextDocumentPositionParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+		Position:     lsp.Position{Line: line, Character: character},
+	}
+}
+
+func textRange(startLine, startChar, endLine, endChar int) lsp.Range {
+	return lsp.Range{
+		Start: lsp.Position{Line: startLine, Character: startChar},
+		End:   lsp.Position{Line: endLine, Character: endChar},
+	}
+}
+
+func TestServerInitialize(t *testing.T) {
+	g := NewWithT(t)
+	c := newClient(t)
+
+	result := lsp.InitializeResult{}
+	g.Expect(c.call("initialize", map[string]any{}, &result)).To(BeNil())
+	g.Expect(result.Capabilities.HoverProvider).To(BeTrue())
+	g.Expect(result.Capabilities.SemanticTokensProvider.Legend.TokenTypes).To(
+		Equal([]string{"keyword", "variable", "number", "operator", "comment", "string"}),
+	)
+
+	var shutdown any
+	g.Expect(c.call("shutdown", nil, &shutdown)).To(BeNil())
+	c.notify("exit", nil)
+	g.Expect(<-c.done).To(Succeed())
+	c.done <- nil
+}
+
+func TestServerUnknownMethod(t *testing.T) {
+	g := NewWithT(t)
+	c := newClient(t)
+
+	var result any
+	err := c.call("workspace/symbol", map[string]any{}, &result)
+	g.Expect(err).NotTo(BeNil())
+	g.Expect(err.Code).To(Equal(jsonrpc.MethodNotFound))
+}
+
+func TestServerMalformedMessage(t *testing.T) {
+	g := NewWithT(t)
+	c := newClient(t)
+
+	body := "{bad json"
+	_, err := fmt.Fprintf(c.raw, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	response, err := c.conn.Read()
+	g.Expect(err).NotTo(HaveOccurred())
+	// the null id is decoded as a nil one
+	g.Expect(response.ID).To(BeNil())
+	g.Expect(response.Error).NotTo(BeNil())
+	g.Expect(response.Error.Code).To(Equal(jsonrpc.ParseError))
+
+	result := lsp.InitializeResult{}
+	g.Expect(c.call("initialize", map[string]any{}, &result)).To(BeNil())
+	g.Expect(result.Capabilities.HoverProvider).To(BeTrue())
+}
+
+func TestServerDiagnostics(t *testing.T) {
+	g := NewWithT(t)
+	c := newClient(t)
+
+	diagnostics := c.open("let x = 5;\nlet = 10;")
+	g.Expect(diagnostics.URI).To(Equal(uri))
+	g.Expect(diagnostics.Diagnostics).NotTo(BeEmpty())
+	g.Expect(diagnostics.Diagnostics[0]).To(Equal(lsp.Diagnostic{
+		Range:    textRange(1, 4, 1, 5),
+		Severity: lsp.SeverityError,
+		Source:   "monkey",
+		Message:  "expected token type IDENT but got ASSIGN",
+	}))
+
+	c.notify("textDocument/didChange", lsp.DidChangeTextDocumentParams{
+		TextDocument:   lsp.TextDocumentIdentifier{URI: uri},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{{Text: "let x = 5;\nlet y = 10;"}},
+	})
+	c.readNotification(&diagnostics)
+	g.Expect(diagnostics.Diagnostics).To(BeEmpty())
+}
+
+func TestServerSemanticTokens(t *testing.T) {
+	g := NewWithT(t)
+	c := newClient(t)
+	c.open("let x = 5; // five\n  x")
+
+	tokens := lsp.SemanticTokens{}
+	g.Expect(c.call("textDocument/semanticTokens/full", lsp.SemanticTokensParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+	}, &tokens)).To(BeNil())
+	g.Expect(tokens.Data).To(Equal([]int{
+		0, 0, 3, 0, 0, // let
+		0, 4, 1, 1, 0, // x
+		0, 2, 1, 3, 0, // =
+		0, 2, 1, 2, 0, // 5
+		0, 3, 7, 4, 0, // comment
+		1, 2, 1, 1, 0, // x
+	}))
+}
+
+func TestServerSemanticTokensTemplate(t *testing.T) {
+	g := NewWithT(t)
+	c := newClient(t)
+	c.open("`a\nb ${x}`")
+
+	tokens := lsp.SemanticTokens{}
+	g.Expect(c.call("textDocument/semanticTokens/full", lsp.SemanticTokensParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+	}, &tokens)).To(BeNil())
+	g.Expect(tokens.Data).To(Equal([]int{
+		0, 0, 1, 5, 0, // `
+		0, 1, 1, 5, 0, // a
+		1, 0, 2, 5, 0, // b
+		0, 4, 1, 1, 0, // x
+		0, 2, 1, 5, 0, // `
+	}))
+}
+
+func TestServerDocumentSymbols(t *testing.T) {
+	g := NewWithT(t)
+	c := newClient(t)
+	c.open(program)
+
+	symbols := []lsp.DocumentSymbol{}
+	g.Expect(c.call("textDocument/documentSymbol", lsp.DocumentSymbolParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+	}, &symbols)).To(BeNil())
+	g.Expect(symbols).To(Equal([]lsp.DocumentSymbol{
+		{
+			Name:           "add",
+			Detail:         "fn(int, int): int",
+			Kind:           lsp.SymbolKindFunction,
+			Range:          textRange(0, 0, 3, 1),
+			SelectionRange: textRange(0, 4, 0, 7),
+			Children: []lsp.DocumentSymbol{
+				{
+					Name:           "sum",
+					Detail:         "int",
+					Kind:           lsp.SymbolKindVariable,
+					Range:          textRange(1, 1, 1, 16),
+					SelectionRange: textRange(1, 5, 1, 8),
+				},
+			},
+		},
+		{
+			Name:           "five",
+			Detail:         "int",
+			Kind:           lsp.SymbolKindVariable,
+			Range:          textRange(4, 0, 4, 12),
+			SelectionRange: textRange(4, 4, 4, 8),
+		},
+	}))
+}
+
+func TestServerDefinition(t *testing.T) {
+	g := NewWithT(t)
+	c := newClient(t)
+	c.open(program)
+
+	location := &lsp.Location{}
+	g.Expect(c.call("textDocument/definition", position(uri, 5, 5), location)).To(BeNil())
+	g.Expect(location).To(Equal(&lsp.Location{URI: uri, Range: textRange(4, 4, 4, 8)}))
+
+	g.Expect(c.call("textDocument/definition", position(uri, 1, 12), location)).To(BeNil())
+	g.Expect(location).To(Equal(&lsp.Location{URI: uri, Range: textRange(0, 13, 0, 14)}))
+
+	location = &lsp.Location{}
+	g.Expect(c.call("textDocument/definition", position(uri, 5, 10), &location)).To(BeNil())
+	g.Expect(location).To(BeNil())
+}
+
+func TestServerHover(t *testing.T) {
+	g := NewWithT(t)
+	c := newClient(t)
+	c.open(program)
+
+	hover := &lsp.Hover{}
+	g.Expect(c.call("textDocument/hover", position(uri, 5, 1), hover)).To(BeNil())
+	g.Expect(hover).To(Equal(&lsp.Hover{
+		Contents: lsp.MarkupContent{Kind: "markdown", Value: "```monkey\nadd: fn(int, int): int\n```"},
+		Range:    textRange(5, 0, 5, 3),
+	}))
+}
+
+func TestServerCompletion(t *testing.T) {
+	g := NewWithT(t)
+	c := newClient(t)
+	c.open(program)
+
+	items := []lsp.CompletionItem{}
+	g.Expect(c.call("textDocument/completion", position(uri, 2, 1), &items)).To(BeNil())
+	g.Expect(withoutBuiltins(items)).To(Equal([]lsp.CompletionItem{
+		{Label: "a", Kind: lsp.CompletionKindVariable, Detail: "int"},
+		{Label: "add", Kind: lsp.CompletionKindFunction, Detail: "fn(int, int): int"},
+		{Label: "b", Kind: lsp.CompletionKindVariable, Detail: "int"},
+		{Label: "sum", Kind: lsp.CompletionKindVariable, Detail: "int"},
+	}))
+
+	g.Expect(c.call("textDocument/completion", position(uri, 5, 0), &items)).To(BeNil())
+	g.Expect(withoutBuiltins(items)).To(Equal([]lsp.CompletionItem{
+		{Label: "add", Kind: lsp.CompletionKindFunction, Detail: "fn(int, int): int"},
+		{Label: "five", Kind: lsp.CompletionKindVariable, Detail: "int"},
+	}))
+}
+
+func TestServerCompletionBuiltins(t *testing.T) {
+	g := NewWithT(t)
+	c := newClient(t)
+	c.open("let str = 1;\n")
+
+	items := []lsp.CompletionItem{}
+	g.Expect(c.call("textDocument/completion", position(uri, 1, 0), &items)).To(BeNil())
+	g.Expect(items).To(ContainElements(
+		lsp.CompletionItem{Label: "len", Kind: lsp.CompletionKindFunction, Detail: "builtin"},
+		lsp.CompletionItem{Label: "puts", Kind: lsp.CompletionKindFunction, Detail: "builtin"},
+		lsp.CompletionItem{Label: "str", Kind: lsp.CompletionKindVariable, Detail: "int"},
+	))
+	g.Expect(items).NotTo(ContainElement(
+		lsp.CompletionItem{Label: "str", Kind: lsp.CompletionKindFunction, Detail: "builtin"},
+	), "the builtin is hidden by the let")
+}
+
+func TestServerCompletionInLetValue(t *testing.T) {
+	g := NewWithT(t)
+	c := newClient(t)
+	c.open("let x = \"a\";\nlet y = x;\nlet x = len(x);\n")
+
+	items := []lsp.CompletionItem{}
+	g.Expect(c.call("textDocument/completion", position(uri, 1, 9), &items)).To(BeNil())
+	g.Expect(withoutBuiltins(items)).To(Equal([]lsp.CompletionItem{
+		{Label: "x", Kind: lsp.CompletionKindVariable, Detail: "string"},
+	}), "y isn't bound until its value is evaluated")
+
+	g.Expect(c.call("textDocument/completion", position(uri, 2, 13), &items)).To(BeNil())
+	g.Expect(withoutBuiltins(items)).To(Equal([]lsp.CompletionItem{
+		{Label: "x", Kind: lsp.CompletionKindVariable, Detail: "string"},
+		{Label: "y", Kind: lsp.CompletionKindVariable, Detail: "string"},
+	}), "the value sees the x it shadows")
+
+	g.Expect(c.call("textDocument/completion", position(uri, 3, 0), &items)).To(BeNil())
+	g.Expect(withoutBuiltins(items)).To(Equal([]lsp.CompletionItem{
+		{Label: "x", Kind: lsp.CompletionKindVariable, Detail: "int"},
+		{Label: "y", Kind: lsp.CompletionKindVariable, Detail: "string"},
+	}))
+}
+
+// withoutBuiltins returns the completion items of the declarations.
+func withoutBuiltins(items []lsp.CompletionItem) []lsp.CompletionItem {
+	declared := []lsp.CompletionItem{}
+	for _, item := range items {
+		if item.Detail != "builtin" {
+			declared = append(declared, item)
+		}
+	}
+	return declared
+}
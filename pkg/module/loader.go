@@ -0,0 +1,127 @@
+// Package module loads Monkey programs split in several modules.
+//
+// A module is a program whose names declared with export let can be
+// imported by other modules with import "path/to/mod". Each module is
+// run once, the first time it's imported, and the following imports get
+// the same exported values.
+package module
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/fs"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/evaluator"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lexer"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/object"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/parser"
+)
+
+// Module is a loaded module.
+type Module struct {
+	Path string
+	Root *ast.Root
+	// Exports are the values of the names declared with export let.
+	Exports map[string]object.Object
+}
+
+// CycleError is returned when a module imports itself, directly or
+// through other modules.
+type CycleError struct {
+	// Chain are the paths of the modules in the cycle, starting and
+	// ending with the module imported again.
+	Chain []string
+}
+
+func (e *CycleError) Error() string {
+	return "import cycle: " + strings.Join(e.Chain, " -> ")
+}
+
+// Loader loads modules from a Resolver, caching them by path.
+// It's not safe for concurrent use.
+type Loader struct {
+	Resolver Resolver
+	// Evaluator runs the modules. Its Importer must be the loader,
+	// for the imports of the modules to be loaded by it too.
+	Evaluator *evaluator.Evaluator
+
+	modules map[string]*Module
+	// loading are the paths of the modules being loaded, in import order.
+	loading []string
+}
+
+var _ evaluator.Importer = &Loader{}
+
+// NewLoader returns a loader for the modules found by r, which runs
+// them with a new evaluator that imports from the loader.
+func NewLoader(r Resolver) *Loader {
+	l := &Loader{
+		Resolver:  r,
+		Evaluator: evaluator.New(),
+		modules:   map[string]*Module{},
+	}
+	l.Evaluator.Importer = l
+	return l
+}
+
+// Load returns the module at path, parsing and running it if it
+// hasn't been loaded yet. The errors found in a module are prefixed
+// by its path. Modules that fail are not cached.
+func (l *Loader) Load(ctx context.Context, path string) (*Module, error) {
+	if m, ok := l.modules[path]; ok {
+		return m, nil
+	}
+	if !fs.ValidPath(path) || path == "." {
+		return nil, errors.Errorf("invalid module path %q", path)
+	}
+	for i, p := range l.loading {
+		if p == path {
+			chain := append([]string{}, l.loading[i:]...)
+			return nil, &CycleError{Chain: append(chain, path)}
+		}
+	}
+
+	l.loading = append(l.loading, path)
+	defer func() { l.loading = l.loading[:len(l.loading)-1] }()
+
+	src, err := l.Resolver.Resolve(path)
+	if err != nil {
+		return nil, errors.Wrap(err, path)
+	}
+
+	p := parser.New(lexer.New(lexer.NewRunePeeker(bufio.NewReader(bytes.NewReader(src)))))
+	root, err := p.Parse()
+	if err != nil {
+		return nil, errors.Wrap(err, path)
+	}
+
+	env := object.NewEnvironment()
+	if _, err := l.Evaluator.Eval(ctx, root, env); err != nil {
+		return nil, errors.Wrap(err, path)
+	}
+
+	m := &Module{Path: path, Root: root, Exports: map[string]object.Object{}}
+	for _, s := range root.Statements {
+		if e, ok := s.(*ast.Export); ok {
+			for _, name := range e.Let.Names() {
+				m.Exports[name.Value], _ = env.Get(name.Value)
+			}
+		}
+	}
+	l.modules[path] = m
+	return m, nil
+}
+
+// Import returns the exports of the module at path, loading it if needed.
+func (l *Loader) Import(ctx context.Context, path string) (map[string]object.Object, error) {
+	m, err := l.Load(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return m.Exports, nil
+}
@@ -0,0 +1,216 @@
+package module_test
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/evaluator"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/module"
+)
+
+//go:embed testdata
+var testdata embed.FS
+
+func TestLoaderLoad(t *testing.T) {
+	testCases := []struct {
+		name     string
+		sources  map[string]string
+		path     string
+		want     map[string]string
+		wantErr  string
+		wantLoop []string
+	}{
+		{
+			name: "exports",
+			sources: map[string]string{
+				"main": `let hidden = 1; export let x = hidden + 1; export let f = fn(y) { y * x };`,
+			},
+			path: "main",
+			want: map[string]string{"x": "2", "f": "fn(y) { ... }"},
+		},
+		{
+			name: "destructured exports",
+			sources: map[string]string{
+				"main": `export let [a, ...rest] = [1, 2]; export let {name} = {"name": "m"};`,
+			},
+			path: "main",
+			want: map[string]string{"a": "1", "rest": "[2]", "name": `"m"`},
+		},
+		{
+			name: "nested imports",
+			sources: map[string]string{
+				"main":        `import "lib/strings"; import "lib/math"; export let s = greet("x") + str(double(2));`,
+				"lib/math":    `export let double = fn(x) { x * 2 };`,
+				"lib/strings": `import "lib/math"; export let greet = fn(name) { "hi " + name };`,
+			},
+			path: "main",
+			want: map[string]string{"s": `"hi x4"`},
+		},
+		{
+			name:    "not found",
+			sources: map[string]string{"main": `import "lib/missing";`},
+			path:    "main",
+			wantErr: `main: runtime error at 1:1: importing "lib/missing": lib/missing: module not found`,
+		},
+		{
+			name:    "invalid path",
+			sources: map[string]string{"main": `import "../main";`},
+			path:    "main",
+			wantErr: `main: runtime error at 1:1: importing "../main": invalid module path "../main"`,
+		},
+		{
+			name:    "parse error",
+			sources: map[string]string{"main": `import "lib";`, "lib": `let x = ;`},
+			path:    "main",
+			wantErr: `main: runtime error at 1:1: importing "lib": lib: invalid program at 1:9 token.Token{Type:;, Literal:";"}: can't find a prefix operator for token`,
+		},
+		{
+			name:    "runtime error",
+			sources: map[string]string{"main": `import "lib";`, "lib": `export let x = 1;` + "\n" + `x / 0`},
+			path:    "main",
+			wantErr: `main: runtime error at 1:1: importing "lib": lib: runtime error at 2:3: division by zero`,
+		},
+		{
+			name:     "self import",
+			sources:  map[string]string{"main": `import "main";`},
+			path:     "main",
+			wantErr:  `main: runtime error at 1:1: importing "main": import cycle: main -> main`,
+			wantLoop: []string{"main", "main"},
+		},
+		{
+			name: "import cycle",
+			sources: map[string]string{
+				"main": `import "a";`,
+				"a":    `import "b";`,
+				"b":    "let x = 1;\nimport \"a\";",
+			},
+			path:     "main",
+			wantErr:  `main: runtime error at 1:1: importing "a": a: runtime error at 1:1: importing "b": b: runtime error at 2:1: importing "a": import cycle: a -> b -> a`,
+			wantLoop: []string{"a", "b", "a"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			l := module.NewLoader(module.Map(tc.sources))
+
+			m, err := l.Load(context.Background(), tc.path)
+			if tc.wantErr != "" {
+				g.Expect(err).To(MatchError(tc.wantErr))
+				cycle := &module.CycleError{}
+				if tc.wantLoop != nil {
+					g.Expect(errors.As(err, &cycle)).To(BeTrue())
+					g.Expect(cycle.Chain).To(Equal(tc.wantLoop))
+				} else {
+					g.Expect(errors.As(err, &cycle)).To(BeFalse())
+				}
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(m.Path).To(Equal(tc.path))
+			got := map[string]string{}
+			for name, o := range m.Exports {
+				got[name] = o.Inspect()
+			}
+			g.Expect(got).To(Equal(tc.want))
+		})
+	}
+}
+
+func TestLoaderLoadRunsModulesOnce(t *testing.T) {
+	g := NewWithT(t)
+	out := &bytes.Buffer{}
+	l := module.NewLoader(module.Map(map[string]string{
+		"main": `import "a"; import "b";`,
+		"a":    `import "log"; export let x = 1;`,
+		"b":    `import "log"; import "a"; export let y = x + 1;`,
+		"log":  `puts("loading log");`,
+	}))
+	l.Evaluator.Builtins = evaluator.DefaultBuiltins(out)
+
+	main, err := l.Load(context.Background(), "main")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out.String()).To(Equal("loading log\n"))
+
+	b, err := l.Load(context.Background(), "b")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(b.Exports["y"].Inspect()).To(Equal("2"))
+	again, err := l.Load(context.Background(), "main")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(again).To(BeIdenticalTo(main))
+	g.Expect(out.String()).To(Equal("loading log\n"))
+}
+
+func TestLoaderLoadFailedModulesAreNotCached(t *testing.T) {
+	g := NewWithT(t)
+	sources := map[string]string{"main": `import "lib"; export let x = y;`}
+	l := module.NewLoader(module.Map(sources))
+
+	_, err := l.Load(context.Background(), "main")
+	g.Expect(err).To(MatchError(module.ErrNotFound))
+
+	sources["lib"] = `export let y = 1;`
+	m, err := l.Load(context.Background(), "main")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(m.Exports["x"].Inspect()).To(Equal("1"))
+}
+
+func TestResolvers(t *testing.T) {
+	g := NewWithT(t)
+	embedded, err := fs.Sub(testdata, "testdata")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	dir := t.TempDir()
+	for path, src := range map[string]string{
+		"main.monkey":     `import "lib/math"; export let answer = square(6) + 6;`,
+		"lib/math.monkey": `export let square = fn(x) { x * x };`,
+	} {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		g.Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+		g.Expect(os.WriteFile(path, []byte(src), 0o644)).To(Succeed())
+	}
+
+	testCases := []struct {
+		name     string
+		resolver module.Resolver
+	}{
+		{
+			name:     "embed.FS",
+			resolver: module.FS(embedded),
+		},
+		{
+			name: "fs.FS",
+			resolver: module.FS(fstest.MapFS{
+				"main.monkey":     {Data: []byte(`import "lib/math"; export let answer = square(6) + 6;`)},
+				"lib/math.monkey": {Data: []byte(`export let square = fn(x) { x * x };`)},
+			}),
+		},
+		{
+			name:     "directory",
+			resolver: module.Dir(dir),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			l := module.NewLoader(tc.resolver)
+
+			m, err := l.Load(context.Background(), "main")
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(m.Exports["answer"].Inspect()).To(Equal("42"))
+
+			_, err = tc.resolver.Resolve("lib/missing")
+			g.Expect(err).To(MatchError(module.ErrNotFound))
+		})
+	}
+}
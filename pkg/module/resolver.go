@@ -0,0 +1,56 @@
+package module
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// ErrNotFound is returned by the resolvers when there is no module with the given path.
+var ErrNotFound = errors.New("module not found")
+
+// Extension is the extension of the files of the modules in a file system.
+const Extension = ".monkey"
+
+// Resolver finds the source of the modules by their import path, which
+// is a slash separated path like "lib/math" without "." or ".." elements.
+type Resolver interface {
+	// Resolve returns the source of the module at path,
+	// or an error wrapping ErrNotFound if it doesn't exist.
+	Resolve(path string) ([]byte, error)
+}
+
+// ResolverFunc adapts a function to a Resolver.
+type ResolverFunc func(path string) ([]byte, error)
+
+func (f ResolverFunc) Resolve(path string) ([]byte, error) {
+	return f(path)
+}
+
+// FS returns a resolver that reads the modules from a file system, like
+// an embed.FS, where "lib/math" is the file lib/math.monkey.
+func FS(fsys fs.FS) Resolver {
+	return ResolverFunc(func(path string) ([]byte, error) {
+		src, err := fs.ReadFile(fsys, path+Extension)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return src, err
+	})
+}
+
+// Dir returns a resolver that reads the modules from the files in dir.
+func Dir(dir string) Resolver {
+	return FS(os.DirFS(dir))
+}
+
+// Map returns a resolver that finds the modules in a map of sources by path.
+func Map(sources map[string]string) Resolver {
+	return ResolverFunc(func(path string) ([]byte, error) {
+		src, ok := sources[path]
+		if !ok {
+			return nil, ErrNotFound
+		}
+		return []byte(src), nil
+	})
+}
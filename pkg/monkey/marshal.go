@@ -0,0 +1,603 @@
+package monkey
+
+import (
+	"context"
+	"math"
+	"reflect"
+
+	"github.com/pkg/errors"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/evaluator"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/object"
+)
+
+// tagName is the struct tag used to rename fields, or skip them with "-".
+const tagName = "monkey"
+
+var (
+	objectType  = reflect.TypeOf((*object.Object)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
+
+// Marshal converts a Go value to a Monkey value.
+//
+// Booleans, strings and integers map to their Monkey counterparts. Floats
+// are accepted only when they hold an integer. Slices and arrays become
+// arrays, and maps and structs become hashes. Struct fields use their Go
+// name unless they are tagged with `monkey:"name"`, and are skipped if
+// tagged with `monkey:"-"` or unexported. Nil pointers, interfaces and
+// funcs become null. Pointers, maps and slices converted more than once,
+// because they are shared or contain themselves, become the same array
+// or hash.
+//
+// Funcs become builtins. Their arguments are converted with Unmarshal, and
+// they can return nothing, a value, an error, or a value and an error.
+//
+// Monkey values are passed through unchanged.
+func Marshal(v any) (object.Object, error) {
+	return newConverter(evaluator.New()).Marshal(v)
+}
+
+// converter converts values between Go and Monkey, calling the Monkey
+// functions converted to Go funcs with its evaluator, so they run with
+// its limits, importer and builtins.
+type converter struct {
+	evaluator *evaluator.Evaluator
+}
+
+func newConverter(e *evaluator.Evaluator) converter {
+	return converter{evaluator: e}
+}
+
+// Marshal converts a Go value to a Monkey value like the Marshal function.
+func (c converter) Marshal(v any) (object.Object, error) {
+	if v == nil {
+		return object.Null, nil
+	}
+	return c.marshal(reflect.ValueOf(v))
+}
+
+func (c converter) marshal(v reflect.Value) (object.Object, error) {
+	return c.marshalValue(v, map[reference]object.Object{}, nil)
+}
+
+// reference identifies the memory a pointer, map or slice points to,
+// together with its type and, for slices, their length.
+type reference struct {
+	pointer uintptr
+	typ     reflect.Type
+	len     int
+}
+
+// marshalValue converts a Go value like Marshal. built has the arrays and
+// hashes made for the pointers, maps and slices already converted, which
+// are used again when they are shared or contain themselves, instead of
+// recursing forever. refs are the pointers to v, so the array or hash made
+// for it is used for them too.
+func (c converter) marshalValue(v reflect.Value, built map[reference]object.Object, refs []reference) (object.Object, error) {
+	if v.Kind() != reflect.Interface && v.Type().Implements(objectType) {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return object.Null, nil
+		}
+		return v.Interface().(object.Object), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return object.NativeBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &object.Integer{Value: v.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return nil, errors.Errorf("%d overflows int64", v.Uint())
+		}
+		return &object.Integer{Value: int64(v.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		return marshalFloat(v.Float())
+	case reflect.String:
+		return &object.String{Value: v.String()}, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Len() > 0 {
+			refs = append(refs, reference{pointer: v.Pointer(), typ: v.Type(), len: v.Len()})
+		}
+		if o, ok := built[lastOf(refs)]; ok {
+			return o, nil
+		}
+		return c.marshalArray(v, built, refs)
+	case reflect.Map:
+		if !v.IsNil() {
+			refs = append(refs, reference{pointer: v.Pointer(), typ: v.Type()})
+		}
+		if o, ok := built[lastOf(refs)]; ok {
+			return o, nil
+		}
+		return c.marshalMap(v, built, refs)
+	case reflect.Struct:
+		return c.marshalStruct(v, built, refs)
+	case reflect.Pointer:
+		if v.IsNil() {
+			return object.Null, nil
+		}
+		ref := reference{pointer: v.Pointer(), typ: v.Type()}
+		if o, ok := built[ref]; ok {
+			return o, nil
+		}
+		return c.marshalValue(v.Elem(), built, append(refs, ref))
+	case reflect.Interface:
+		if v.IsNil() {
+			return object.Null, nil
+		}
+		return c.marshalValue(v.Elem(), built, refs)
+	case reflect.Func:
+		if v.IsNil() {
+			return object.Null, nil
+		}
+		return c.marshalFunc(v)
+	}
+
+	return nil, errors.Errorf("unsupported type %s", v.Type())
+}
+
+func marshalFloat(f float64) (object.Object, error) {
+	if f != math.Trunc(f) {
+		return nil, errors.Errorf("%v is not an integer", f)
+	}
+	// float64(math.MaxInt64) rounds up to 2^63, which doesn't fit
+	if f < math.MinInt64 || f >= math.MaxInt64 {
+		return nil, errors.Errorf("%v overflows int64", f)
+	}
+	return &object.Integer{Value: int64(f)}, nil
+}
+
+// lastOf returns the last reference of refs, or the zero one, which
+// is never built, if there are none.
+func lastOf(refs []reference) reference {
+	if len(refs) == 0 {
+		return reference{}
+	}
+	return refs[len(refs)-1]
+}
+
+// register records o as the object built for refs.
+func register(built map[reference]object.Object, refs []reference, o object.Object) {
+	for _, ref := range refs {
+		built[ref] = o
+	}
+}
+
+func (c converter) marshalArray(v reflect.Value, built map[reference]object.Object, refs []reference) (object.Object, error) {
+	a := &object.Array{Elements: make([]object.Object, 0, v.Len())}
+	register(built, refs, a)
+	for i := 0; i < v.Len(); i++ {
+		e, err := c.marshalValue(v.Index(i), built, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "index %d", i)
+		}
+		a.Elements = append(a.Elements, e)
+	}
+	return a, nil
+}
+
+func (c converter) marshalMap(v reflect.Value, built map[reference]object.Object, refs []reference) (object.Object, error) {
+	h := object.NewHash()
+	register(built, refs, h)
+	iter := v.MapRange()
+	for iter.Next() {
+		k, err := c.marshalValue(iter.Key(), built, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "key %v", iter.Key())
+		}
+		key, ok := k.(object.Hashable)
+		if !ok {
+			return nil, errors.Errorf("key %v: %s can't be used as a hash key", iter.Key(), k.Type())
+		}
+		value, err := c.marshalValue(iter.Value(), built, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "key %v", iter.Key())
+		}
+		h.Set(key, value)
+	}
+	return h, nil
+}
+
+func (c converter) marshalStruct(v reflect.Value, built map[reference]object.Object, refs []reference) (object.Object, error) {
+	h := object.NewHash()
+	register(built, refs, h)
+	for _, f := range fields(v.Type()) {
+		value, err := c.marshalValue(v.Field(f.index), built, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "field %s", f.goName)
+		}
+		h.Set(&object.String{Value: f.name}, value)
+	}
+	return h, nil
+}
+
+type field struct {
+	index  int
+	goName string
+	// name is the key of the field in a hash.
+	name string
+}
+
+func fields(t reflect.Type) []field {
+	var fs []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup(tagName); ok {
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		fs = append(fs, field{index: i, goName: f.Name, name: name})
+	}
+	return fs
+}
+
+func (c converter) marshalFunc(v reflect.Value) (object.Object, error) {
+	t := v.Type()
+	if err := checkResults(t); err != nil {
+		return nil, err
+	}
+
+	return &object.Builtin{
+		Name: t.String(),
+		Fn: func(args ...object.Object) (object.Object, error) {
+			in, err := c.funcArguments(t, args)
+			if err != nil {
+				return nil, err
+			}
+			return c.funcResult(v.Call(in))
+		},
+	}, nil
+}
+
+// checkResults validates that a func returns nothing, a value,
+// an error, or a value and an error.
+func checkResults(t reflect.Type) error {
+	switch t.NumOut() {
+	case 0, 1:
+		return nil
+	case 2:
+		if t.Out(1) == errorType {
+			return nil
+		}
+	}
+	return errors.Errorf("unsupported func %s: it must return at most a value and an error", t)
+}
+
+func (c converter) funcArguments(t reflect.Type, args []object.Object) ([]reflect.Value, error) {
+	fixed := t.NumIn()
+	if t.IsVariadic() {
+		fixed--
+		if len(args) < fixed {
+			return nil, errors.Errorf("wrong number of arguments: want at least %d, got=%d", fixed, len(args))
+		}
+	} else if len(args) != fixed {
+		return nil, errors.Errorf("wrong number of arguments: want=%d, got=%d", fixed, len(args))
+	}
+
+	in := make([]reflect.Value, 0, len(args))
+	for i, a := range args {
+		var argType reflect.Type
+		if i < fixed {
+			argType = t.In(i)
+		} else {
+			argType = t.In(fixed).Elem()
+		}
+
+		arg := reflect.New(argType).Elem()
+		if err := c.unmarshal(a, arg); err != nil {
+			return nil, errors.Wrapf(err, "argument %d", i+1)
+		}
+		in = append(in, arg)
+	}
+	return in, nil
+}
+
+func (c converter) funcResult(out []reflect.Value) (object.Object, error) {
+	if len(out) == 0 {
+		return object.Null, nil
+	}
+
+	last := out[len(out)-1]
+	if last.Type() == errorType {
+		if !last.IsNil() {
+			return nil, last.Interface().(error)
+		}
+		out = out[:len(out)-1]
+	}
+
+	if len(out) == 0 {
+		return object.Null, nil
+	}
+	o, err := c.marshal(out[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "result")
+	}
+	return o, nil
+}
+
+// Unmarshal converts a Monkey value into the Go value pointed to by v,
+// following the rules of Marshal in reverse. Integers that don't fit in
+// the target type are an error. Null sets the target to its zero value.
+//
+// When the target is an empty interface, integers become int64, arrays
+// []any and hashes map[any]any. Functions are kept as Monkey values.
+//
+// Monkey functions can also be unmarshalled into Go funcs. If the func
+// takes a context.Context as its first argument, it's used for the call.
+// Errors are returned if the func returns an error, otherwise they panic.
+// They are called without limits and with the default builtins; use
+// Script.GetInto to call them with the ones of a script.
+func Unmarshal(o object.Object, v any) error {
+	return newConverter(evaluator.New()).Unmarshal(o, v)
+}
+
+// Unmarshal converts a Monkey value into a Go value like the Unmarshal function.
+func (c converter) Unmarshal(o object.Object, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.Errorf("can't unmarshal into non pointer %T", v)
+	}
+	return c.unmarshal(o, rv.Elem())
+}
+
+func (c converter) unmarshal(o object.Object, v reflect.Value) error {
+	if v.Type() == objectType {
+		v.Set(reflect.ValueOf(&o).Elem())
+		return nil
+	}
+
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		if g := toGo(o); g != nil {
+			v.Set(reflect.ValueOf(g))
+		} else {
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return nil
+	}
+
+	if o == object.Null {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return c.unmarshal(o, v.Elem())
+	case reflect.Bool:
+		if b, ok := o.(*object.Boolean); ok {
+			v.SetBool(b.Value)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, ok := o.(*object.Integer); ok {
+			if v.OverflowInt(i.Value) {
+				return errors.Errorf("%d overflows %s", i.Value, v.Type())
+			}
+			v.SetInt(i.Value)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if i, ok := o.(*object.Integer); ok {
+			if i.Value < 0 || v.OverflowUint(uint64(i.Value)) {
+				return errors.Errorf("%d overflows %s", i.Value, v.Type())
+			}
+			v.SetUint(uint64(i.Value))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if i, ok := o.(*object.Integer); ok {
+			v.SetFloat(float64(i.Value))
+			return nil
+		}
+	case reflect.String:
+		if s, ok := o.(*object.String); ok {
+			v.SetString(s.Value)
+			return nil
+		}
+	case reflect.Slice:
+		if a, ok := o.(*object.Array); ok {
+			s := reflect.MakeSlice(v.Type(), len(a.Elements), len(a.Elements))
+			if err := c.unmarshalElements(a, s); err != nil {
+				return err
+			}
+			v.Set(s)
+			return nil
+		}
+	case reflect.Array:
+		if a, ok := o.(*object.Array); ok {
+			if len(a.Elements) > v.Len() {
+				return errors.Errorf("array of %d elements doesn't fit in %s", len(a.Elements), v.Type())
+			}
+			v.Set(reflect.Zero(v.Type()))
+			return c.unmarshalElements(a, v)
+		}
+	case reflect.Map:
+		if h, ok := o.(*object.Hash); ok {
+			return c.unmarshalMap(h, v)
+		}
+	case reflect.Struct:
+		if h, ok := o.(*object.Hash); ok {
+			return c.unmarshalStruct(h, v)
+		}
+	case reflect.Func:
+		switch o.(type) {
+		case *object.Function, *object.Builtin:
+			return c.unmarshalFunc(o, v)
+		}
+	}
+
+	return errors.Errorf("can't unmarshal %s into %s", o.Type(), v.Type())
+}
+
+func (c converter) unmarshalElements(a *object.Array, v reflect.Value) error {
+	for i, e := range a.Elements {
+		if err := c.unmarshal(e, v.Index(i)); err != nil {
+			return errors.Wrapf(err, "index %d", i)
+		}
+	}
+	return nil
+}
+
+func (c converter) unmarshalMap(h *object.Hash, v reflect.Value) error {
+	m := reflect.MakeMapWithSize(v.Type(), len(h.Pairs))
+	for _, p := range h.Pairs {
+		key := reflect.New(v.Type().Key()).Elem()
+		if err := c.unmarshal(p.Key, key); err != nil {
+			return errors.Wrapf(err, "key %s", p.Key.Inspect())
+		}
+		value := reflect.New(v.Type().Elem()).Elem()
+		if err := c.unmarshal(p.Value, value); err != nil {
+			return errors.Wrapf(err, "key %s", p.Key.Inspect())
+		}
+		m.SetMapIndex(key, value)
+	}
+	v.Set(m)
+	return nil
+}
+
+func (c converter) unmarshalStruct(h *object.Hash, v reflect.Value) error {
+	for _, f := range fields(v.Type()) {
+		value, ok := h.Get(&object.String{Value: f.name})
+		if !ok {
+			continue
+		}
+		if err := c.unmarshal(value, v.Field(f.index)); err != nil {
+			return errors.Wrapf(err, "field %s", f.goName)
+		}
+	}
+	return nil
+}
+
+func (c converter) unmarshalFunc(fn object.Object, v reflect.Value) error {
+	t := v.Type()
+	if err := checkResults(t); err != nil {
+		return err
+	}
+	returnsError := t.NumOut() > 0 && t.Out(t.NumOut()-1) == errorType
+
+	v.Set(reflect.MakeFunc(t, func(in []reflect.Value) []reflect.Value {
+		ctx := context.Background()
+		if t.NumIn() > 0 && t.In(0) == contextType {
+			if c, ok := in[0].Interface().(context.Context); ok && c != nil {
+				ctx = c
+			}
+			in = in[1:]
+		}
+
+		result, err := c.callFunc(ctx, fn, t, in)
+		if err != nil && !returnsError {
+			panic(err)
+		}
+
+		out := make([]reflect.Value, 0, t.NumOut())
+		if t.NumOut() == 2 || (t.NumOut() == 1 && !returnsError) {
+			out = append(out, result)
+		}
+		if returnsError {
+			errValue := reflect.New(errorType).Elem()
+			if err != nil {
+				errValue.Set(reflect.ValueOf(err))
+			}
+			out = append(out, errValue)
+		}
+		return out
+	}))
+	return nil
+}
+
+// callFunc calls a Monkey function from Go, returning the result
+// converted to the first result type of t, or an invalid value if t has none.
+func (c converter) callFunc(ctx context.Context, fn object.Object, t reflect.Type, in []reflect.Value) (reflect.Value, error) {
+	var result reflect.Value
+	if t.NumOut() > 0 && t.Out(0) != errorType {
+		result = reflect.New(t.Out(0)).Elem()
+	}
+
+	args := make([]object.Object, 0, len(in))
+	for _, a := range in {
+		if t.IsVariadic() && len(args) == len(in)-1 {
+			for i := 0; i < a.Len(); i++ {
+				o, err := c.marshal(a.Index(i))
+				if err != nil {
+					return result, errors.Wrapf(err, "argument %d", len(args)+1)
+				}
+				args = append(args, o)
+			}
+			continue
+		}
+		o, err := c.marshal(a)
+		if err != nil {
+			return result, errors.Wrapf(err, "argument %d", len(args)+1)
+		}
+		args = append(args, o)
+	}
+
+	o, err := c.evaluator.Call(ctx, fn, args)
+	if err != nil {
+		return result, err
+	}
+
+	if result.IsValid() {
+		if err := c.unmarshal(o, result); err != nil {
+			return result, errors.Wrap(err, "result")
+		}
+	}
+	return result, nil
+}
+
+// toGo converts a Monkey value to the Go value used for an empty interface.
+func toGo(o object.Object) any {
+	return convert(o, map[object.Object]any{})
+}
+
+// convert converts a Monkey value like toGo. converted has the arrays and
+// hashes already converted, so the ones that contain themselves give Go
+// values that contain themselves too, instead of recursing forever.
+func convert(o object.Object, converted map[object.Object]any) any {
+	if g, ok := converted[o]; ok {
+		return g
+	}
+
+	switch o := o.(type) {
+	case *object.Integer:
+		return o.Value
+	case *object.Boolean:
+		return o.Value
+	case *object.String:
+		return o.Value
+	case *object.NullValue:
+		return nil
+	case *object.Array:
+		// the slice is registered before its elements are set,
+		// which it shares the backing array with
+		a := make([]any, len(o.Elements))
+		converted[o] = a
+		for i, e := range o.Elements {
+			a[i] = convert(e, converted)
+		}
+		return a
+	case *object.Hash:
+		m := make(map[any]any, len(o.Pairs))
+		converted[o] = m
+		for _, p := range o.Pairs {
+			m[convert(p.Key, converted)] = convert(p.Value, converted)
+		}
+		return m
+	}
+
+	return o
+}
@@ -0,0 +1,220 @@
+package monkey_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/monkey"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/object"
+)
+
+type point struct {
+	X      int `monkey:"x"`
+	Y      int `monkey:"y"`
+	Hidden int `monkey:"-"`
+	Label  string
+	secret int
+}
+
+func TestMarshal(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   any
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "nil",
+			input: nil,
+			want:  "null",
+		},
+		{
+			name:  "scalars",
+			input: []any{true, int8(-3), uint32(7), 4.0, "text"},
+			want:  `[true, -3, 7, 4, "text"]`,
+		},
+		{
+			name:  "struct with tags",
+			input: point{X: 1, Y: 2, Hidden: 3, Label: "a", secret: 4},
+			want:  `{"Label": "a", "x": 1, "y": 2}`,
+		},
+		{
+			name:  "pointers and maps",
+			input: map[string]*point{"p": {X: 1}, "nil": nil},
+			want:  `{"nil": null, "p": {"Label": "", "x": 1, "y": 0}}`,
+		},
+		{
+			name:  "integer keys",
+			input: map[int][2]bool{1: {true, false}},
+			want:  `{1: [true, false]}`,
+		},
+		{
+			name:  "objects are passed through",
+			input: []object.Object{&object.Integer{Value: 1}},
+			want:  `[1]`,
+		},
+		{
+			name:    "uint overflow",
+			input:   []uint64{1, math.MaxUint64},
+			wantErr: "index 1: 18446744073709551615 overflows int64",
+		},
+		{
+			name:    "float with decimals",
+			input:   map[string]float64{"f": 1.5},
+			wantErr: "key f: 1.5 is not an integer",
+		},
+		{
+			name:    "float overflow",
+			input:   1e19,
+			wantErr: "1e+19 overflows int64",
+		},
+		{
+			name:    "unsupported type",
+			input:   struct{ C complex64 }{},
+			wantErr: "field C: unsupported type complex64",
+		},
+		{
+			name:    "unhashable key",
+			input:   map[[1]int]int{{1}: 1},
+			wantErr: "key [1]: ARRAY can't be used as a hash key",
+		},
+		{
+			name:    "func with too many results",
+			input:   func() (int, int) { return 1, 2 },
+			wantErr: "unsupported func func() (int, int): it must return at most a value and an error",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			got, err := monkey.Marshal(tc.input)
+			if tc.wantErr != "" {
+				g.Expect(err).To(MatchError(tc.wantErr))
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got.Inspect()).To(Equal(tc.want))
+		})
+	}
+}
+
+type node struct {
+	Value int
+	Next  *node
+}
+
+func TestMarshalSelfReferencing(t *testing.T) {
+	g := NewWithT(t)
+
+	n := &node{Value: 1}
+	n.Next = n
+	o, err := monkey.Marshal(n)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(o.Inspect()).To(Equal(`{"Next": {...}, "Value": 1}`))
+	next, _ := o.(*object.Hash).Get(&object.String{Value: "Next"})
+	g.Expect(next).To(BeIdenticalTo(o))
+
+	m := map[string]any{}
+	m["self"] = m
+	o, err = monkey.Marshal(m)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(o.Inspect()).To(Equal(`{"self": {...}}`))
+
+	s := []any{nil}
+	s[0] = s
+	o, err = monkey.Marshal(s)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(o.Inspect()).To(Equal(`[[...]]`))
+
+	shared := &point{X: 1}
+	o, err = monkey.Marshal([]*point{shared, shared, {X: 1}})
+	g.Expect(err).NotTo(HaveOccurred())
+	elements := o.(*object.Array).Elements
+	g.Expect(elements[1]).To(BeIdenticalTo(elements[0]))
+	g.Expect(elements[2]).NotTo(BeIdenticalTo(elements[0]))
+}
+
+func TestMarshalFunc(t *testing.T) {
+	g := NewWithT(t)
+	o, err := monkey.Marshal(func(p point, scale int8) (point, error) {
+		if scale == 0 {
+			return point{}, errors.New("zero scale")
+		}
+		return point{X: p.X * int(scale), Y: p.Y * int(scale)}, nil
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	fn := o.(*object.Builtin).Fn
+
+	p, err := monkey.Marshal(point{X: 1, Y: 2})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	got, err := fn(p, &object.Integer{Value: 3})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got.Inspect()).To(Equal(`{"Label": "", "x": 3, "y": 6}`))
+
+	_, err = fn(p, &object.Integer{Value: 0})
+	g.Expect(err).To(MatchError("zero scale"))
+
+	_, err = fn(p, &object.Integer{Value: 300})
+	g.Expect(err).To(MatchError("argument 2: 300 overflows int8"))
+
+	_, err = fn(p)
+	g.Expect(err).To(MatchError("wrong number of arguments: want=2, got=1"))
+}
+
+func TestUnmarshal(t *testing.T) {
+	g := NewWithT(t)
+
+	o, err := monkey.Marshal(map[string]any{
+		"points": []point{{X: 1, Label: "a"}, {Y: 2}},
+		"count":  2,
+		"none":   nil,
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var typed struct {
+		Points []*point `monkey:"points"`
+		Count  uint16   `monkey:"count"`
+		None   *int     `monkey:"none"`
+	}
+	g.Expect(monkey.Unmarshal(o, &typed)).To(Succeed())
+	g.Expect(typed.Points).To(Equal([]*point{{X: 1, Label: "a"}, {Y: 2}}))
+	g.Expect(typed.Count).To(Equal(uint16(2)))
+	g.Expect(typed.None).To(BeNil())
+
+	var untyped any
+	g.Expect(monkey.Unmarshal(o, &untyped)).To(Succeed())
+	g.Expect(untyped).To(HaveKeyWithValue("count", int64(2)))
+	g.Expect(untyped).To(HaveKeyWithValue("none", BeNil()))
+
+	var wrong struct {
+		Count bool `monkey:"count"`
+	}
+	g.Expect(monkey.Unmarshal(o, &wrong)).To(MatchError("field Count: can't unmarshal INTEGER into bool"))
+
+	var negative uint
+	g.Expect(monkey.Unmarshal(&object.Integer{Value: -1}, &negative)).To(MatchError("-1 overflows uint"))
+	g.Expect(monkey.Unmarshal(o, untyped)).To(MatchError("can't unmarshal into non pointer map[interface {}]interface {}"))
+}
+
+func TestUnmarshalFunc(t *testing.T) {
+	g := NewWithT(t)
+	s, err := monkey.Compile(`let add = fn(a, b) { a + b }; let fail = fn() { 1 / 0 }`)
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = s.Run(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var add func(context.Context, ...int) int
+	g.Expect(s.GetInto("add", &add)).To(Succeed())
+	g.Expect(add(context.Background(), 1, 2)).To(Equal(3))
+	g.Expect(func() { add(context.Background(), 1) }).To(PanicWith(MatchError("wrong number of arguments: want=2, got=1")))
+
+	var fail func() error
+	g.Expect(s.GetInto("fail", &fail)).To(Succeed())
+	g.Expect(fail()).To(MatchError("runtime error at 1:51: division by zero"))
+}
@@ -0,0 +1,124 @@
+// Package monkey embeds the Monkey language in Go programs.
+//
+// A Script is compiled once and can be run several times. Go values are
+// exchanged with the script through global variables, registered functions
+// and the value the script evaluates to.
+package monkey
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/evaluator"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lexer"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/object"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/parser"
+)
+
+// Function is a Go function that can be called from a script.
+// Arguments and result are converted the same way as globals.
+type Function func(args ...any) (any, error)
+
+// Script is a compiled Monkey program with its global variables.
+type Script struct {
+	root      *ast.Root
+	globals   *object.Environment
+	evaluator *evaluator.Evaluator
+}
+
+// Compile parses a Monkey program.
+func Compile(src string) (*Script, error) {
+	return CompileReader(strings.NewReader(src))
+}
+
+// CompileReader parses a Monkey program read from r.
+func CompileReader(r io.Reader) (*Script, error) {
+	l := lexer.New(lexer.NewRunePeeker(bufio.NewReader(r)))
+	root, err := parser.New(l).Parse()
+	if err != nil {
+		return nil, errors.Wrap(err, "compiling script")
+	}
+
+	return &Script{
+		root:      root,
+		globals:   object.NewEnvironment(),
+		evaluator: evaluator.New(),
+	}, nil
+}
+
+// Set defines a global variable, converting v to a Monkey value with Marshal.
+func (s *Script) Set(name string, v any) error {
+	o, err := s.converter().Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "setting %s", name)
+	}
+
+	s.globals.Set(name, o)
+	return nil
+}
+
+// Get returns the value of a global variable converted to Go.
+// Variables defined by the script with let are available after Run.
+func (s *Script) Get(name string) (any, error) {
+	o, ok := s.globals.Get(name)
+	if !ok {
+		return nil, errors.Errorf("undefined global %s", name)
+	}
+
+	return toGo(o), nil
+}
+
+// GetInto converts the value of a global variable into the
+// Go value pointed to by v with Unmarshal.
+func (s *Script) GetInto(name string, v any) error {
+	o, ok := s.globals.Get(name)
+	if !ok {
+		return errors.Errorf("undefined global %s", name)
+	}
+
+	return errors.Wrapf(s.converter().Unmarshal(o, v), "getting %s", name)
+}
+
+// Register makes fn callable from the script as name.
+// Funcs with other signatures can be passed to Set.
+func (s *Script) Register(name string, fn Function) {
+	b := &object.Builtin{Name: name}
+	s.globals.Set(name, b)
+
+	// Function always returns a value and an error, so this can't fail
+	o, _ := s.converter().Marshal(fn)
+	b.Fn = func(args ...object.Object) (object.Object, error) {
+		result, err := o.(*object.Builtin).Fn(args...)
+		return result, errors.Wrap(err, name)
+	}
+}
+
+// converter converts values for the script, so the Monkey functions it
+// returns as Go funcs run with the same limits and builtins as Run.
+func (s *Script) converter() converter {
+	return newConverter(s.evaluator)
+}
+
+// SetLimits bounds the resources used by each Run, so scripts that
+// aren't trusted can't run forever or exhaust the host memory.
+// It replaces evaluator.DefaultLimits, so the depth limit must be
+// kept in l to bound the stack used by recursive scripts.
+func (s *Script) SetLimits(l evaluator.Limits) {
+	s.evaluator.Limits = l
+}
+
+// Run evaluates the script and returns the value of its last statement,
+// or of its first top level return, converted to Go.
+func (s *Script) Run(ctx context.Context) (any, error) {
+	o, err := s.evaluator.Eval(ctx, s.root, s.globals)
+	if err != nil {
+		return nil, err
+	}
+
+	return toGo(o), nil
+}
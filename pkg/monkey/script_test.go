@@ -0,0 +1,153 @@
+package monkey_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/evaluator"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/monkey"
+)
+
+func TestScriptRun(t *testing.T) {
+	g := NewWithT(t)
+	s, err := monkey.Compile(`
+let limit = max * 2;
+if (isAdmin(user)) { return limit * 10; }
+limit
+`)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(s.Set("max", 5)).To(Succeed())
+	g.Expect(s.Set("user", 42)).To(Succeed())
+	s.Register("isAdmin", func(args ...any) (any, error) {
+		return args[0] == int64(1), nil
+	})
+
+	got, err := s.Run(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(Equal(int64(10)))
+	g.Expect(s.Get("limit")).To(Equal(int64(10)))
+
+	g.Expect(s.Set("user", 1)).To(Succeed())
+	got, err = s.Run(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(Equal(int64(100)))
+}
+
+func TestScriptRunErrors(t *testing.T) {
+	g := NewWithT(t)
+	s, err := monkey.CompileReader(strings.NewReader(`check(1) + 1`))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	s.Register("check", func(args ...any) (any, error) {
+		return nil, errors.New("not allowed")
+	})
+	_, err = s.Run(context.Background())
+	g.Expect(err).To(MatchError("runtime error at 1:6: check: not allowed"))
+
+	s.Register("check", func(args ...any) (any, error) {
+		return make(chan int), nil
+	})
+	_, err = s.Run(context.Background())
+	g.Expect(err).To(MatchError("runtime error at 1:6: check: result: unsupported type chan int"))
+}
+
+func TestScriptSetGetErrors(t *testing.T) {
+	g := NewWithT(t)
+	s, err := monkey.Compile(`1`)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(s.Set("x", make(chan int))).To(MatchError("setting x: unsupported type chan int"))
+	_, err = s.Get("x")
+	g.Expect(err).To(MatchError("undefined global x"))
+}
+
+func TestCompileError(t *testing.T) {
+	g := NewWithT(t)
+	_, err := monkey.Compile(`let = 5;`)
+	g.Expect(err).To(MatchError(ContainSubstring("compiling script: invalid program at 1:5")))
+}
+
+func TestScriptGoValues(t *testing.T) {
+	type limits struct {
+		Max   int    `monkey:"max"`
+		Owner string `monkey:"owner"`
+	}
+
+	g := NewWithT(t)
+	s, err := monkey.Compile(`
+let double = fn(x) { x * 2 };
+describe(config, sum(1, 2, 3))
+`)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(s.Set("config", limits{Max: 5, Owner: "ops"})).To(Succeed())
+	g.Expect(s.Set("sum", func(n ...int) int {
+		total := 0
+		for _, i := range n {
+			total += i
+		}
+		return total
+	})).To(Succeed())
+	g.Expect(s.Set("describe", func(l limits, extra uint8) string {
+		return fmt.Sprintf("%s: %d", l.Owner, l.Max+int(extra))
+	})).To(Succeed())
+
+	got, err := s.Run(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(Equal("ops: 11"))
+
+	var double func(int) (int, error)
+	g.Expect(s.GetInto("double", &double)).To(Succeed())
+	g.Expect(double(21)).To(Equal(42))
+}
+
+func TestScriptRunSelfContainingValues(t *testing.T) {
+	g := NewWithT(t)
+	s, err := monkey.Compile(`let a = [1, 2]; a[0] = a; let h = {}; h["h"] = h; [a, h]`)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	got, err := s.Run(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+
+	values := got.([]any)
+	a := values[0].([]any)
+	g.Expect(a[1]).To(Equal(int64(2)))
+	g.Expect(&a[0].([]any)[0]).To(BeIdenticalTo(&a[0]))
+	h := values[1].(map[any]any)
+	g.Expect(reflect.ValueOf(h["h"]).Pointer()).To(Equal(reflect.ValueOf(h).Pointer()))
+}
+
+func TestScriptRunLimits(t *testing.T) {
+	g := NewWithT(t)
+	s, err := monkey.Compile(`let spin = fn() { spin() }; spin()`)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	s.SetLimits(evaluator.Limits{MaxDepth: 50})
+	_, err = s.Run(context.Background())
+	g.Expect(err).To(MatchError(evaluator.ErrDepthLimit))
+}
+
+func TestScriptFuncsRunWithLimits(t *testing.T) {
+	g := NewWithT(t)
+	s, err := monkey.Compile(`let spin = fn() { while (true) {} }; apply(spin)`)
+	g.Expect(err).NotTo(HaveOccurred())
+	s.SetLimits(evaluator.Limits{MaxSteps: 1000})
+
+	var callbackErr error
+	g.Expect(s.Set("apply", func(f func() error) {
+		callbackErr = f()
+	})).To(Succeed())
+	_, err = s.Run(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(callbackErr).To(MatchError(evaluator.ErrStepLimit))
+
+	var spin func() error
+	g.Expect(s.GetInto("spin", &spin)).To(Succeed())
+	g.Expect(spin()).To(MatchError(evaluator.ErrStepLimit))
+}
@@ -0,0 +1,46 @@
+package object
+
+// Environment holds the values bound to names in a scope.
+type Environment struct {
+	store map[string]Object
+	outer *Environment
+}
+
+func NewEnvironment() *Environment {
+	return &Environment{store: map[string]Object{}}
+}
+
+// NewEnclosedEnvironment creates a scope nested in outer,
+// where names not found are looked up in outer.
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	e := NewEnvironment()
+	e.outer = outer
+	return e
+}
+
+func (e *Environment) Get(name string) (Object, bool) {
+	for ; e != nil; e = e.outer {
+		if o, ok := e.store[name]; ok {
+			return o, true
+		}
+	}
+	return nil, false
+}
+
+// Set binds a name in this scope, shadowing any outer binding.
+func (e *Environment) Set(name string, o Object) Object {
+	e.store[name] = o
+	return o
+}
+
+// Assign changes the value of a name in the innermost scope where it's
+// bound. It returns false if the name isn't bound in any scope.
+func (e *Environment) Assign(name string, o Object) bool {
+	for ; e != nil; e = e.outer {
+		if _, ok := e.store[name]; ok {
+			e.store[name] = o
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package object
+
+import (
+	"fmt"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+)
+
+// Error is an error raised by a program, either a runtime error like a
+// type mismatch or a value thrown with throw, as caught by a try statement.
+type Error struct {
+	Message string
+	// Value is the thrown value, or null for runtime errors.
+	Value Object
+	Pos   token.Position
+	// Stack are the functions being run when the error was raised,
+	// innermost first.
+	Stack []Frame
+}
+
+// Frame is a function in the stack of an Error, with the position
+// of the code it was running.
+type Frame struct {
+	// Function is the name the function was called by, or
+	// MainFunction for the code outside of any function.
+	Function string
+	Pos      token.Position
+}
+
+// MainFunction is the name of the frame of the code outside of functions.
+const MainFunction = "<main>"
+
+func (f Frame) String() string {
+	return fmt.Sprintf("%s at %s", f.Function, f.Pos)
+}
+
+func (e *Error) Type() Type {
+	return ErrorType
+}
+
+func (e *Error) Inspect() string {
+	return fmt.Sprintf("error at %s: %s", e.Pos, e.Message)
+}
@@ -0,0 +1,93 @@
+package object
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+)
+
+// Hashable is implemented by the objects that can be used as hash keys.
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
+var (
+	_ Hashable = &Integer{}
+	_ Hashable = &Boolean{}
+	_ Hashable = &String{}
+)
+
+// HashKey identifies a key in a Hash. Keys of different types never collide.
+type HashKey struct {
+	Type  Type
+	Value uint64
+}
+
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
+
+func (b *Boolean) HashKey() HashKey {
+	var v uint64
+	if b.Value {
+		v = 1
+	}
+	return HashKey{Type: b.Type(), Value: v}
+}
+
+func (s *String) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(s.Value))
+	return HashKey{Type: s.Type(), Value: h.Sum64()}
+}
+
+// HashPair keeps the original key next to its value,
+// since it can't be recovered from the HashKey.
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+type Hash struct {
+	Pairs map[HashKey]HashPair
+}
+
+// NewHash returns an empty Hash.
+func NewHash() *Hash {
+	return &Hash{Pairs: map[HashKey]HashPair{}}
+}
+
+// Set adds a pair to the hash, replacing the value if the key exists.
+func (h *Hash) Set(key Hashable, value Object) {
+	h.Pairs[key.HashKey()] = HashPair{Key: key, Value: value}
+}
+
+// Get returns the value for key.
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	p, ok := h.Pairs[key.HashKey()]
+	return p.Value, ok
+}
+
+func (h *Hash) Type() Type {
+	return HashType
+}
+
+// SortedPairs returns the pairs sorted by the Inspect form of their keys,
+// so the order is stable.
+func (h *Hash) SortedPairs() []HashPair {
+	pairs := make([]HashPair, 0, len(h.Pairs))
+	for _, p := range h.Pairs {
+		pairs = append(pairs, p)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Key.Inspect() < pairs[j].Key.Inspect()
+	})
+	return pairs
+}
+
+// Inspect prints the pairs sorted by key, so the output is stable.
+func (h *Hash) Inspect() string {
+	s, _ := InspectN(h, math.MaxInt)
+	return s
+}
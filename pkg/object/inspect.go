@@ -0,0 +1,162 @@
+package object
+
+import (
+	"context"
+	"strings"
+)
+
+// InspectN returns the Inspect form of o cut to max bytes, and whether
+// it's complete. It stops building it once it reaches max, which bounds
+// the work for arrays and hashes that share elements, since their Inspect
+// form repeats them and can be exponentially larger than their memory.
+func InspectN(o Object, max int) (string, bool) {
+	in := &inspector{max: max, visiting: map[Object]bool{}}
+	complete := in.inspect(o)
+	return in.b.String(), complete
+}
+
+type inspector struct {
+	b   strings.Builder
+	max int
+	// visiting has the arrays and hashes being printed, so the ones that
+	// contain themselves, which can be made by assigning to an index,
+	// are printed as [...] or {...}.
+	visiting map[Object]bool
+}
+
+// write adds s, or the part of it that fits in max, reporting whether it fit.
+func (in *inspector) write(s string) bool {
+	if room := in.max - in.b.Len(); len(s) > room {
+		in.b.WriteString(s[:room])
+		return false
+	}
+	in.b.WriteString(s)
+	return true
+}
+
+func (in *inspector) inspect(o Object) bool {
+	switch o := o.(type) {
+	case *Array:
+		if in.visiting[o] {
+			return in.write("[...]")
+		}
+		in.visiting[o] = true
+		defer delete(in.visiting, o)
+
+		if !in.write("[") {
+			return false
+		}
+		for i, e := range o.Elements {
+			if i > 0 && !in.write(", ") {
+				return false
+			}
+			if !in.inspect(e) {
+				return false
+			}
+		}
+		return in.write("]")
+	case *Hash:
+		if in.visiting[o] {
+			return in.write("{...}")
+		}
+		in.visiting[o] = true
+		defer delete(in.visiting, o)
+
+		if !in.write("{") {
+			return false
+		}
+		for i, p := range o.SortedPairs() {
+			if i > 0 && !in.write(", ") {
+				return false
+			}
+			if !in.write(p.Key.Inspect()) || !in.write(": ") || !in.inspect(p.Value) {
+				return false
+			}
+		}
+		return in.write("}")
+	}
+	return in.write(o.Inspect())
+}
+
+// InspectLength returns the length of the Inspect form of o without
+// building it, or max+1 if it's longer than max. It returns the error
+// of ctx if it's done before finishing.
+func InspectLength(ctx context.Context, o Object, max int64) (int64, error) {
+	c := &lengthCounter{ctx: ctx, max: max, visiting: map[Object]bool{}, lengths: map[Object]int64{}}
+	n, _, err := c.length(o)
+	return n, err
+}
+
+// checkEvery is the number of objects counted between checks of the context.
+const checkEvery = 1024
+
+type lengthCounter struct {
+	ctx      context.Context
+	max      int64
+	counted  int
+	visiting map[Object]bool
+	// lengths has the arrays and hashes already counted that don't contain
+	// a [...] or {...}, whose length is the same wherever they appear.
+	lengths map[Object]int64
+}
+
+// length returns the length of the Inspect form of o, up to max+1, and
+// whether it contains a [...] or {...}.
+func (c *lengthCounter) length(o Object) (int64, bool, error) {
+	c.counted++
+	if c.counted%checkEvery == 0 {
+		if err := c.ctx.Err(); err != nil {
+			return 0, false, err
+		}
+	}
+
+	switch o := o.(type) {
+	case *Array:
+		return c.collection(o, o.Elements, nil)
+	case *Hash:
+		pairs := o.SortedPairs()
+		values := make([]Object, 0, len(pairs))
+		keys := make([]string, 0, len(pairs))
+		for _, p := range pairs {
+			values = append(values, p.Value)
+			keys = append(keys, p.Key.Inspect())
+		}
+		return c.collection(o, values, keys)
+	}
+	return int64(len(o.Inspect())), false, nil
+}
+
+// collection counts an array, or a hash if it has keys.
+func (c *lengthCounter) collection(o Object, elements []Object, keys []string) (int64, bool, error) {
+	if n, ok := c.lengths[o]; ok {
+		return n, false, nil
+	}
+	if c.visiting[o] {
+		return int64(len("[...]")), true, nil
+	}
+	c.visiting[o] = true
+	defer delete(c.visiting, o)
+
+	n, cyclic := int64(len("[]")), false
+	for i, e := range elements {
+		if i > 0 {
+			n += int64(len(", "))
+		}
+		if keys != nil {
+			n += int64(len(keys[i]) + len(": "))
+		}
+		m, inner, err := c.length(e)
+		if err != nil {
+			return 0, false, err
+		}
+		n += m
+		cyclic = cyclic || inner
+		if n > c.max {
+			return c.max + 1, cyclic, nil
+		}
+	}
+	if !cyclic {
+		c.lengths[o] = n
+	}
+	return n, cyclic, nil
+}
@@ -0,0 +1,173 @@
+// Package object defines the values Monkey programs work with at runtime.
+package object
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+)
+
+type Type string
+
+const (
+	IntegerType  Type = "INTEGER"
+	BooleanType  Type = "BOOLEAN"
+	StringType   Type = "STRING"
+	ArrayType    Type = "ARRAY"
+	HashType     Type = "HASH"
+	NullType     Type = "NULL"
+	FunctionType Type = "FUNCTION"
+	BuiltinType  Type = "BUILTIN"
+	ErrorType    Type = "ERROR"
+	// ReturnValueType wraps the value of a return statement while it unwinds the blocks.
+	ReturnValueType Type = "RETURN_VALUE"
+)
+
+type Object interface {
+	Type() Type
+	// Inspect returns a representation of the object for humans.
+	Inspect() string
+}
+
+var (
+	_ Object = &Integer{}
+	_ Object = &Boolean{}
+	_ Object = &String{}
+	_ Object = &Array{}
+	_ Object = &Hash{}
+	_ Object = &NullValue{}
+	_ Object = &Function{}
+	_ Object = &Builtin{}
+	_ Object = &Error{}
+	_ Object = &ReturnValue{}
+)
+
+type Integer struct {
+	Value int64
+}
+
+func (i *Integer) Type() Type {
+	return IntegerType
+}
+
+func (i *Integer) Inspect() string {
+	return fmt.Sprintf("%d", i.Value)
+}
+
+type Boolean struct {
+	Value bool
+}
+
+func (b *Boolean) Type() Type {
+	return BooleanType
+}
+
+func (b *Boolean) Inspect() string {
+	return fmt.Sprintf("%t", b.Value)
+}
+
+type String struct {
+	Value string
+}
+
+func (s *String) Type() Type {
+	return StringType
+}
+
+func (s *String) Inspect() string {
+	return strconv.Quote(s.Value)
+}
+
+type Array struct {
+	Elements []Object
+}
+
+func (a *Array) Type() Type {
+	return ArrayType
+}
+
+func (a *Array) Inspect() string {
+	s, _ := InspectN(a, math.MaxInt)
+	return s
+}
+
+// Booleans and null are immutable, so there is only one instance of each.
+var (
+	True  = &Boolean{Value: true}
+	False = &Boolean{Value: false}
+	Null  = &NullValue{}
+)
+
+// NativeBool returns the Boolean instance for a Go bool.
+func NativeBool(b bool) *Boolean {
+	if b {
+		return True
+	}
+	return False
+}
+
+type NullValue struct{}
+
+func (n *NullValue) Type() Type {
+	return NullType
+}
+
+func (n *NullValue) Inspect() string {
+	return "null"
+}
+
+// Function is a function literal together with the environment it was defined in.
+type Function struct {
+	Parameters []*ast.Parameter
+	Body       *ast.Block
+	Env        *Environment
+}
+
+func (f *Function) Type() Type {
+	return FunctionType
+}
+
+func (f *Function) Inspect() string {
+	params := make([]string, 0, len(f.Parameters))
+	for _, p := range f.Parameters {
+		params = append(params, p.Name.Value)
+	}
+	return fmt.Sprintf("fn(%s) { ... }", strings.Join(params, ", "))
+}
+
+// BuiltinFunction is a function implemented in Go.
+type BuiltinFunction func(args ...Object) (Object, error)
+
+type Builtin struct {
+	Name string
+	Fn   BuiltinFunction
+	// Size estimates the bytes allocated by a call with args, so the
+	// memory can be checked before calling Fn. It returns the error of
+	// ctx if it's done while estimating. If it's nil, the size of the
+	// result is accounted for after the call.
+	Size func(ctx context.Context, args ...Object) (int64, error)
+}
+
+func (b *Builtin) Type() Type {
+	return BuiltinType
+}
+
+func (b *Builtin) Inspect() string {
+	return fmt.Sprintf("builtin %s", b.Name)
+}
+
+type ReturnValue struct {
+	Value Object
+}
+
+func (r *ReturnValue) Type() Type {
+	return ReturnValueType
+}
+
+func (r *ReturnValue) Inspect() string {
+	return r.Value.Inspect()
+}
@@ -0,0 +1,183 @@
+package parser
+
+import (
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/cst"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lexer"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+)
+
+// ParseCST parses the program like Parse, also building its concrete syntax
+// tree. For the tree to reproduce the source, the lexer must keep the trivia,
+// for example with a lexer.Lexer that has Trivia set. The returned node
+// is never nil, and its Root is the same AST returned by Parse.
+func (p *Parser) ParseCST() (*cst.Node, error) {
+	p.cst = &cstBuilder{}
+	root, err := p.Parse()
+
+	tree := p.cst.tree()
+	tree.Syntax = root
+	return tree, err
+}
+
+// cstBuilder records the structure of the program while it's parsed,
+// and builds the tree at the end. The parser starts a node when current
+// is its first token and finishes it when current is its last one.
+//
+// Tokens are added to the innermost open node when the parser moves past
+// them, or when the node they end is finished.
+//
+// All methods can be called on a nil builder, which does nothing,
+// so the parser doesn't need to check whether it's building a CST.
+type cstBuilder struct {
+	events []event
+	// open are the indexes of the start events of the nodes being parsed.
+	open []int
+	// current is the parser current token, and emitted whether it has
+	// already been added to a node.
+	current, peek *cst.Token
+	emitted       bool
+}
+
+type eventKind int
+
+const (
+	startEvent eventKind = iota
+	tokenEvent
+	finishEvent
+	// discardedEvent is a start event moved by precede.
+	discardedEvent
+)
+
+type event struct {
+	kind   eventKind
+	token  *cst.Token
+	syntax ast.Node
+	// parent is the index of the start event of a node created by precede
+	// to wrap this one, or 0 if there's none.
+	parent int
+}
+
+// marker identifies a node by the index of its start event.
+type marker int
+
+// advance moves the tokens along with the parser, adding current
+// to the innermost open node if it hasn't been added yet.
+func (b *cstBuilder) advance(next token.Token, r lexer.TokenReader) {
+	if b == nil {
+		return
+	}
+
+	b.emitCurrent()
+
+	t := &cst.Token{Token: next}
+	if tr, ok := r.(lexer.TriviaReader); ok {
+		t.Leading, t.Trailing = tr.LastTrivia()
+	}
+	b.current, b.peek, b.emitted = b.peek, t, false
+}
+
+func (b *cstBuilder) emitCurrent() {
+	if b.current == nil || b.emitted {
+		return
+	}
+	b.events = append(b.events, event{kind: tokenEvent, token: b.current})
+	b.emitted = true
+}
+
+// start opens a node that begins at the current token.
+func (b *cstBuilder) start() marker {
+	if b == nil {
+		return 0
+	}
+
+	m := marker(len(b.events))
+	b.events = append(b.events, event{kind: startEvent})
+	b.open = append(b.open, int(m))
+	return m
+}
+
+// precede opens a node wrapping the already finished node m, like an infix
+// expression does with its left operand.
+func (b *cstBuilder) precede(m marker) marker {
+	if b == nil {
+		return 0
+	}
+
+	outer := b.start()
+	b.events[m].parent = int(outer)
+	return outer
+}
+
+// finish closes the innermost open node, which ends at the current token.
+func (b *cstBuilder) finish(syntax ast.Node) {
+	if b == nil {
+		return
+	}
+
+	b.emitCurrent()
+	b.events = append(b.events, event{kind: finishEvent, syntax: syntax})
+	b.open = b.open[:len(b.open)-1]
+}
+
+// depth returns the number of open nodes.
+func (b *cstBuilder) depth() int {
+	if b == nil {
+		return 0
+	}
+	return len(b.open)
+}
+
+// abandon closes the nodes opened after depth, after a parsing error.
+// They are kept with a nil syntax, since their tokens are part of the source.
+func (b *cstBuilder) abandon(depth int) {
+	if b == nil {
+		return
+	}
+
+	for len(b.open) > depth {
+		b.events = append(b.events, event{kind: finishEvent})
+		b.open = b.open[:len(b.open)-1]
+	}
+}
+
+// tree builds the tree from the recorded events.
+func (b *cstBuilder) tree() *cst.Node {
+	root := &cst.Node{}
+	stack := []*cst.Node{root}
+	push := func(n *cst.Node) {
+		parent := stack[len(stack)-1]
+		parent.Children = append(parent.Children, n)
+		stack = append(stack, n)
+	}
+
+	for i, e := range b.events {
+		switch e.kind {
+		case startEvent:
+			// a node wrapped by precede is opened after its wrappers,
+			// which come later in the events
+			wrappers := []int{i}
+			for parent := e.parent; parent != 0; parent = b.events[parent].parent {
+				wrappers = append(wrappers, parent)
+			}
+			for j := len(wrappers) - 1; j >= 0; j-- {
+				b.events[wrappers[j]].kind = discardedEvent
+				push(&cst.Node{})
+			}
+		case tokenEvent:
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, e.token)
+		case finishEvent:
+			stack[len(stack)-1].Syntax = e.syntax
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	// the root node is the first one started by Parse
+	if len(root.Children) == 1 {
+		if n, ok := root.Children[0].(*cst.Node); ok {
+			return n
+		}
+	}
+	return root
+}
@@ -24,5 +24,14 @@ func NewError(err error, t token.Token) Error {
 }
 
 func (e Error) Error() string {
-	return fmt.Sprintf("invalid program at %s: %s", e.token, e.err)
+	return fmt.Sprintf("invalid program at %s %s: %s", e.token.Pos, e.token, e.err)
+}
+
+func (e Error) Unwrap() error {
+	return e.err
+}
+
+// Token returns the token where the error was found.
+func (e Error) Token() token.Token {
+	return e.token
 }
@@ -0,0 +1,82 @@
+package parser_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/format"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lexer"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/parser"
+)
+
+func FuzzParserParse(f *testing.F) {
+	for _, seed := range []string{
+		`let x = 5; let y = true; let foobar = y;`,
+		`return 5; return 10; return add(15);`,
+		`let x: {string: [int]} = y;`,
+		`let f: fn(int, fn(): bool): string = g;`,
+		`fn(a: int, b, c: string): bool { a }`,
+		`-a * b; !-a; a + b * c + d / e - f; 5 > 4 == 3 < 4;`,
+		`3 + 4 * 5 == 3 * 1 + 4 * 5; 3 > 5 == false; -(5 + 5); (5 + 5) * 2`,
+		`if (x < y) { x } else { return y; }`,
+		`fn(x, y) { x + y; }; fn() {}; fn(x) { x }(5)`,
+		`add(1, 2 * 3, 4 + 5); a + add(b * c) + d`,
+		"// leading\nlet x = 1; // trailing\n\n// dangling",
+		`(1 + 2`,
+		`fn(x) { x`,
+		`let x: = 5;`,
+		`1 + )`,
+		"let a\x00 = 1;",
+		"`a ${b + `${c}`} \\${d}`",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		root, p, err := parse(input)
+		if err != nil {
+			for _, e := range p.Errors() {
+				var parserErr parser.Error
+				if !errors.As(e, &parserErr) {
+					t.Fatalf("error %v is not a parser.Error", e)
+				}
+				if !parserErr.Token().Pos.IsValid() {
+					t.Fatalf("error %v has no position", e)
+				}
+			}
+			return
+		}
+
+		// printing and parsing again must give the same program
+		printed := &bytes.Buffer{}
+		if err := format.Node(printed, root); err != nil {
+			t.Fatalf("printing: %v", err)
+		}
+		reparsed, _, err := parse(printed.String())
+		if err != nil {
+			t.Fatalf("parsing printed program %q: %v", printed, err)
+		}
+		// the token of an expression statement is its first token, which
+		// can be a parenthesis the printer doesn't need to keep
+		ignoreStatementTokens := cmpopts.IgnoreFields(ast.ExpressionStatement{}, "Token")
+		if diff := cmp.Diff(root, reparsed, ignorePositions, ignoreStatementTokens); diff != "" {
+			t.Fatalf("printed program %q parses to a different AST (-want +got):\n%s", printed, diff)
+		}
+	})
+}
+
+func parse(input string) (*ast.Root, *parser.Parser, error) {
+	l := lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)
+	p := parser.New(l)
+	root, err := p.Parse()
+	return root, p, err
+}
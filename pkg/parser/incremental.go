@@ -0,0 +1,245 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+
+	perrors "github.com/pkg/errors"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lexer"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+)
+
+// Edit replaces the bytes of a source between the offsets Start and End by Text.
+type Edit struct {
+	Start, End int
+	Text       string
+}
+
+// Apply returns a copy of src with the edit applied.
+func (e Edit) Apply(src []byte) []byte {
+	out := make([]byte, 0, len(src)-(e.End-e.Start)+len(e.Text))
+	out = append(out, src[:e.Start]...)
+	out = append(out, e.Text...)
+	return append(out, src[e.End:]...)
+}
+
+// Reparse parses src after applying edit, given old, the AST of src.
+// Only the top level statements around the edit are lexed and parsed again.
+// The statements before them are reused and the ones after them are copied
+// with their positions moved. The result is the same as parsing the edited
+// source from scratch, which is what Reparse falls back to when the edited
+// statements don't parse on their own.
+//
+// old must be the AST returned by Parse for src, and isn't modified.
+func Reparse(src []byte, old *ast.Root, edit Edit) (*ast.Root, error) {
+	if edit.Start < 0 || edit.Start > edit.End || edit.End > len(src) {
+		return nil, perrors.Errorf("invalid edit of bytes %d to %d in a source of %d bytes", edit.Start, edit.End, len(src))
+	}
+	newSrc := edit.Apply(src)
+
+	statements := old.Statements
+	if len(statements) == 0 {
+		p, _ := newSourceParser(newSrc, sourceStart)
+		return p.Parse()
+	}
+
+	// statement i spans from its start to the start of the next one,
+	// so the text between statements goes with the previous one
+	spanEnd := func(i int) int {
+		if i+1 < len(statements) {
+			return statements[i+1].Pos().Offset
+		}
+		return len(src)
+	}
+
+	// the edited statements are the ones touching the edit, including
+	// the ones that end where it starts or start where it ends
+	first, last := -1, -1
+	for i, s := range statements {
+		if s.Pos().Offset <= edit.End && edit.Start <= spanEnd(i) {
+			if first == -1 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first == -1 {
+		// the edit is before the first statement
+		first, last = 0, 0
+	}
+
+	// a statement not ending with a semicolon could take tokens from the
+	// next one, so the reparsed region must start and end after one
+	for first > 0 && !endsWithSemicolon(src[statements[first-1].Pos().Offset:statements[first].Pos().Offset]) {
+		first--
+	}
+
+	start := sourceStart
+	if first > 0 {
+		start = statements[first].Pos()
+	}
+	delta := len(edit.Text) - (edit.End - edit.Start)
+
+	for {
+		end := spanEnd(last)
+		p, r := newSourceParser(newSrc[start.Offset:end+delta], start)
+		region, err := p.Parse()
+		if err != nil {
+			// errors could depend on what comes after the region
+			p, _ := newSourceParser(newSrc, sourceStart)
+			return p.Parse()
+		}
+		// the region must also end out of a comment, which would take
+		// the text of the next statement after an edited line break
+		if last+1 < len(statements) && (r.last.Type != token.Semicolon || r.inComment) {
+			last++
+			continue
+		}
+
+		return joinRegion(old, region, first, last, start.Offset, end, r.eof.Pos), nil
+	}
+}
+
+// sourceStart is the position of the first byte of a source.
+var sourceStart = token.Position{Line: 1, Column: 1}
+
+// joinRegion replaces the statements from first to last of old, and the
+// comments between the offsets start and end, by the ones in region.
+// The positions after the region are moved so that end, the start of the
+// statement after last in the old source, becomes regionEnd.
+func joinRegion(old, region *ast.Root, first, last, start, end int, regionEnd token.Position) *ast.Root {
+	var oldEnd token.Position
+	if last+1 < len(old.Statements) {
+		oldEnd = old.Statements[last+1].Pos()
+	}
+	shift := func(p token.Position) token.Position {
+		if !p.IsValid() {
+			return p
+		}
+		if p.Line == oldEnd.Line {
+			p.Column += regionEnd.Column - oldEnd.Column
+		}
+		p.Line += regionEnd.Line - oldEnd.Line
+		p.Offset += regionEnd.Offset - oldEnd.Offset
+		return p
+	}
+
+	root := &ast.Root{}
+	root.Statements = append(root.Statements, old.Statements[:first]...)
+	root.Statements = append(root.Statements, region.Statements...)
+	for _, s := range old.Statements[last+1:] {
+		root.Statements = append(root.Statements, copyWithShift(s, shift))
+	}
+
+	for _, c := range old.Comments {
+		if c.Pos().Offset < start {
+			root.Comments = append(root.Comments, c)
+		}
+	}
+	root.Comments = append(root.Comments, region.Comments...)
+	for _, c := range old.Comments {
+		if c.Pos().Offset >= end {
+			root.Comments = append(root.Comments, copyWithShift(c, shift))
+		}
+	}
+
+	return root
+}
+
+// copyWithShift returns a deep copy of an AST node, with its positions changed by shift.
+func copyWithShift[T ast.Node](n T, shift func(token.Position) token.Position) T {
+	return copyValue(reflect.ValueOf(n), shift).Interface().(T)
+}
+
+var positionType = reflect.TypeOf(token.Position{})
+
+func copyValue(v reflect.Value, shift func(token.Position) token.Position) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(copyValue(v.Elem(), shift))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(copyValue(v.Elem(), shift))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(copyValue(v.Index(i), shift))
+		}
+		return c
+	case reflect.Struct:
+		if v.Type() == positionType {
+			return reflect.ValueOf(shift(v.Interface().(token.Position)))
+		}
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			c.Field(i).Set(copyValue(v.Field(i), shift))
+		}
+		return c
+	}
+	return v
+}
+
+// endsWithSemicolon reports whether the last token in src is a semicolon.
+func endsWithSemicolon(src []byte) bool {
+	l := lexer.New(lexer.NewRunePeeker(bufio.NewReader(bytes.NewReader(src))))
+	var last token.Token
+	for t, err := range l.Tokens() {
+		if err != nil {
+			return false
+		}
+		if t.Type != token.Comment && t.Type != token.EOF {
+			last = t
+		}
+	}
+	return last.Type == token.Semicolon
+}
+
+// newSourceParser returns a parser for src, which starts at start in the
+// whole source, and the reader that records its last tokens.
+func newSourceParser(src []byte, start token.Position) (*Parser, *recordingReader) {
+	r := &recordingReader{
+		TokenReader: lexer.NewAt(lexer.NewRunePeeker(bufio.NewReader(bytes.NewReader(src))), start),
+	}
+	return New(r), r
+}
+
+// recordingReader records the last tokens returned by a TokenReader.
+type recordingReader struct {
+	lexer.TokenReader
+	// last is the last token before EOF that isn't a comment.
+	last token.Token
+	eof  token.Token
+	// inComment reports whether the input ends within a comment.
+	inComment bool
+}
+
+func (r *recordingReader) NextToken() (token.Token, error) {
+	t, err := r.TokenReader.NextToken()
+	switch {
+	case err != nil:
+	case t.Type == token.Comment:
+		r.inComment = true
+	case t.Type == token.EOF:
+		r.eof = t
+		r.inComment = r.inComment && t.Pos.Column > 1
+	default:
+		r.last = t
+		r.inComment = false
+	}
+	return t, err
+}
@@ -0,0 +1,147 @@
+package parser_test
+
+import (
+	"bufio"
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lexer"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/parser"
+)
+
+const incrementalSource = `// adds numbers
+let add = fn(a: int, b: int): int { a + b };
+let x = add(1, 2); // three
+
+if (x > 2) {
+	x
+} else { -x };
+let y = x
+* 2
+add(x, y);
+`
+
+func parseBytes(src []byte) (*ast.Root, error) {
+	l := lexer.New(lexer.NewRunePeeker(bufio.NewReader(bytes.NewReader(src))))
+	return parser.New(l).Parse()
+}
+
+func TestReparse(t *testing.T) {
+	// at returns the offset of the n-th occurrence of sub in the source
+	at := func(sub string, n int) int {
+		i := -1
+		for ; n > 0; n-- {
+			i += 1 + strings.Index(incrementalSource[i+1:], sub)
+		}
+		return i
+	}
+	testCases := []struct {
+		name string
+		edit parser.Edit
+	}{
+		{
+			name: "change a literal",
+			edit: parser.Edit{Start: at("1", 1), End: at("1", 1) + 1, Text: "100"},
+		},
+		{
+			name: "insert a statement between two others",
+			edit: parser.Edit{Start: at("let x", 1), End: at("let x", 1), Text: "let z = 3;\n"},
+		},
+		{
+			name: "remove a semicolon joining two statements",
+			edit: parser.Edit{Start: at(";", 1), End: at(";", 1) + 1, Text: ""},
+		},
+		{
+			name: "edit a statement without semicolon that continues on the next line",
+			edit: parser.Edit{Start: at("* 2", 1) + 2, End: at("* 2", 1) + 3, Text: "z"},
+		},
+		{
+			name: "insert a line at the start",
+			edit: parser.Edit{Start: 0, End: 0, Text: "let first = true;\n"},
+		},
+		{
+			name: "append at the end",
+			edit: parser.Edit{Start: len(incrementalSource), End: len(incrementalSource), Text: "x + y"},
+		},
+		{
+			name: "edit a comment",
+			edit: parser.Edit{Start: at("three", 1), End: at("three", 1) + 5, Text: "still three"},
+		},
+		{
+			name: "introduce a syntax error",
+			edit: parser.Edit{Start: at("(x > 2)", 1), End: at("(x > 2)", 1) + 1, Text: "{"},
+		},
+		{
+			name: "delete everything",
+			edit: parser.Edit{Start: 0, End: len(incrementalSource), Text: ""},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			src := []byte(incrementalSource)
+			old, err := parseBytes(src)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			got, gotErr := parser.Reparse(src, old, tc.edit)
+			want, wantErr := parseBytes(tc.edit.Apply(src))
+			g.Expect(got).To(Equal(want))
+			if wantErr == nil {
+				g.Expect(gotErr).NotTo(HaveOccurred())
+			} else {
+				g.Expect(gotErr).To(MatchError(wantErr.Error()))
+			}
+		})
+	}
+}
+
+func TestReparseKeepsOldTree(t *testing.T) {
+	g := NewWithT(t)
+	src := []byte(incrementalSource)
+	old, err := parseBytes(src)
+	g.Expect(err).NotTo(HaveOccurred())
+	oldCopy, _ := parseBytes(src)
+
+	got, err := parser.Reparse(src, old, parser.Edit{Start: strings.Index(incrementalSource, "1"), End: strings.Index(incrementalSource, "1") + 1, Text: "100"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(old).To(Equal(oldCopy))
+	// the statements before the edit are reused
+	g.Expect(got.Statements[0]).To(BeIdenticalTo(old.Statements[0]))
+}
+
+func TestReparseRandomEdits(t *testing.T) {
+	fragments := []string{"", ";", "\n", " ", "x", "1", "(", ")", "{", "}", "+", "let ", "= ", "// c\n", "fn(a) ", "if (a) { b }", "/", "\"", "\"s;\" ", "while (a) { break; }", "continue", "[", "]", "+= ", "a[0] = 1;", "{\"k\": 1}", "?.", "?? null", ".", "try { a } catch (e) { e }", "finally {}", "throw ", "match (a) { ", "_ => ", "[b] if b => b, ", "let [a, ...b] = ", "{c = 1}", "`", "`a ${b}`", "${", " c`"}
+	r := rand.New(rand.NewSource(1))
+	g := NewWithT(t)
+
+	src := []byte(incrementalSource)
+	old, _ := parseBytes(src)
+	for i := 0; i < 2000; i++ {
+		start := r.Intn(len(src) + 1)
+		end := start + r.Intn(min(10, len(src)-start)+1)
+		edit := parser.Edit{Start: start, End: end, Text: fragments[r.Intn(len(fragments))]}
+
+		got, gotErr := parser.Reparse(src, old, edit)
+		newSrc := edit.Apply(src)
+		want, wantErr := parseBytes(newSrc)
+		g.Expect(got).To(Equal(want), "edit %+v of %q", edit, src)
+		if wantErr == nil {
+			g.Expect(gotErr).NotTo(HaveOccurred(), "edit %+v of %q", edit, src)
+		} else {
+			g.Expect(gotErr).To(MatchError(wantErr.Error()), "edit %+v of %q", edit, src)
+		}
+
+		// keep editing the result, going back to the original source when it breaks
+		if wantErr == nil {
+			src, old = newSrc, got
+		} else {
+			src = []byte(incrementalSource)
+			old, _ = parseBytes(src)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+)
+
+// Limits bounds the input the parser accepts. A zero value disables a limit.
+type Limits struct {
+	// MaxDepth is the maximum nesting of expressions, blocks and types.
+	// Parsing is recursive, so this bounds the stack used by the parser.
+	MaxDepth int
+	// MaxTokens is the maximum number of tokens in the program, not counting comments.
+	MaxTokens int
+	// MaxSourceSize is the maximum size of the program in bytes. It's
+	// checked after each token, so the source should also be read through
+	// LimitReader for the limit to bound the memory used by a long token.
+	MaxSourceSize int
+}
+
+// DefaultLimits only bounds the nesting depth, far above what
+// hand written programs use, so hostile input can't exhaust the stack.
+var DefaultLimits = Limits{MaxDepth: 1000}
+
+var (
+	ErrDepthLimit      = errors.New("maximum nesting depth exceeded")
+	ErrTokenLimit      = errors.New("maximum number of tokens exceeded")
+	ErrSourceSizeLimit = errors.New("maximum source size exceeded")
+)
+
+// enter increases the nesting depth, failing if it exceeds the limit.
+// Each successful call must be followed by a call to leave.
+func (p *Parser) enter() error {
+	if p.Limits.MaxDepth > 0 && p.depth >= p.Limits.MaxDepth {
+		return p.setFatal(ErrDepthLimit, p.current)
+	}
+	p.depth++
+	return nil
+}
+
+func (p *Parser) leave() {
+	p.depth--
+}
+
+// checkInput checks the limits on the input size with the next token.
+func (p *Parser) checkInput(t token.Token) error {
+	if t.Type != token.EOF {
+		p.tokens++
+		if p.Limits.MaxTokens > 0 && p.tokens > p.Limits.MaxTokens {
+			return ErrTokenLimit
+		}
+	}
+
+	if p.Limits.MaxSourceSize > 0 && t.Pos.Offset+len(t.Literal) > p.Limits.MaxSourceSize {
+		return ErrSourceSizeLimit
+	}
+	return nil
+}
+
+// LimitReader returns a reader of r that fails with ErrSourceSizeLimit
+// when r has more than n bytes, as soon as they are read. The lexer stops
+// at the error, so a source over the limit is never read in full.
+func LimitReader(r io.Reader, n int) io.Reader {
+	return &limitedReader{r: r, n: n}
+}
+
+type limitedReader struct {
+	r io.Reader
+	// n is the number of bytes left before the limit.
+	n int
+}
+
+func (l *limitedReader) Read(b []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ErrSourceSizeLimit
+	}
+	// one more byte tells apart a source that ends at the limit
+	if len(b) > l.n+1 {
+		b = b[:l.n+1]
+	}
+	n, err := l.r.Read(b)
+	l.n -= n
+	if l.n < 0 {
+		return n - 1, ErrSourceSizeLimit
+	}
+	return n, err
+}
+
+// setFatal records the first limit error, which stops the parsing.
+func (p *Parser) setFatal(err error, t token.Token) error {
+	if p.fatal == nil {
+		e := NewError(err, t)
+		p.fatal = &e
+	}
+	return *p.fatal
+}
@@ -10,12 +10,15 @@ type precedence int
 const (
 	_ precedence = iota
 	lowest
+	assign
+	coalesce
 	equals
 	lessGreater
 	sum
 	product
 	prefix
 	call
+	index
 )
 
 type operatorParserRegistry[P any] map[token.Type]P
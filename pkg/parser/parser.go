@@ -3,6 +3,7 @@ package parser
 import (
 	"errors"
 	"strconv"
+	"strings"
 
 	// TODO: cleanup package name collision
 	perrors "github.com/pkg/errors"
@@ -13,13 +14,36 @@ import (
 )
 
 type Parser struct {
-	lexer                 *lexer.Lexer
+	// Limits protects the parser from inputs that would take too many
+	// resources. It defaults to DefaultLimits.
+	Limits Limits
+
+	lexer                 lexer.TokenReader
 	current, peek         token.Token
 	errors                []Error
+	comments              []*ast.Comment
 	prefixParsers         operatorParserRegistry[prefixParser]
 	infixParsers          operatorParserRegistry[infixParser]
 	expressionPrecedences operatorParserRegistry[precedence]
 	tokenToInfixMapping   map[token.Type]ast.InfixOperator
+	tokenToAssignMapping  map[token.Type]ast.AssignOperator
+	// depth is the current nesting of expressions, blocks and types.
+	depth int
+	// blocks is the current nesting of blocks, 0 at the top level.
+	blocks int
+	// loops is the number of loops around current in the function being parsed.
+	loops int
+	// destructuring is set while parsing the pattern of a let, which can't
+	// have the patterns that may not match.
+	destructuring bool
+	// braces is the number of { and ${ up to current that aren't closed,
+	// to find the end of an interpolation after an error in it.
+	braces int
+	tokens int
+	// fatal is set when a limit is exceeded, which stops the parsing.
+	fatal *Error
+	// cst is only set by ParseCST.
+	cst *cstBuilder
 }
 
 type (
@@ -27,29 +51,49 @@ type (
 	infixParser  func(ast.Expression) (ast.Expression, error)
 )
 
-func New(lexer *lexer.Lexer) *Parser {
+func New(lexer lexer.TokenReader) *Parser {
 	p := &Parser{
+		Limits:                DefaultLimits,
 		lexer:                 lexer,
 		prefixParsers:         make(operatorParserRegistry[prefixParser]),
 		infixParsers:          make(operatorParserRegistry[infixParser]),
 		expressionPrecedences: make(operatorParserRegistry[precedence]),
 		tokenToInfixMapping: map[token.Type]ast.InfixOperator{
-			token.Plus:        ast.Addition,
-			token.Minus:       ast.Subtraction,
-			token.Asterisk:    ast.Multiplication,
-			token.Slash:       ast.Division,
-			token.GreaterThan: ast.GreaterThan,
-			token.LowerThan:   ast.LessThan,
-			token.Equal:       ast.Equal,
-			token.NotEqual:    ast.NotEqual,
+			token.Plus:         ast.Addition,
+			token.Minus:        ast.Subtraction,
+			token.Asterisk:     ast.Multiplication,
+			token.Slash:        ast.Division,
+			token.GreaterThan:  ast.GreaterThan,
+			token.LowerThan:    ast.LessThan,
+			token.Equal:        ast.Equal,
+			token.NotEqual:     ast.NotEqual,
+			token.NullCoalesce: ast.NullCoalesce,
+		},
+		tokenToAssignMapping: map[token.Type]ast.AssignOperator{
+			token.Assign:         ast.PlainAssign,
+			token.PlusAssign:     ast.AddAssign,
+			token.MinusAssign:    ast.SubtractAssign,
+			token.AsteriskAssign: ast.MultiplyAssign,
+			token.SlashAssign:    ast.DivideAssign,
 		},
 		// TODO: could we use an array for optimization?
 	}
 
 	p.prefixParsers.register(token.Ident, p.parseIdentifier)
 	p.prefixParsers.register(token.Int, p.parseLiteral)
+	p.prefixParsers.register(token.String, p.parseString)
+	p.prefixParsers.register(token.Backtick, p.parseTemplate)
 	p.prefixParsers.register(token.Bang, p.parsePrefix)
 	p.prefixParsers.register(token.Minus, p.parsePrefix)
+	p.prefixParsers.register(token.True, p.parseBoolean)
+	p.prefixParsers.register(token.False, p.parseBoolean)
+	p.prefixParsers.register(token.Null, p.parseNull)
+	p.prefixParsers.register(token.LParen, p.parseGrouped)
+	p.prefixParsers.register(token.If, p.parseIf)
+	p.prefixParsers.register(token.Function, p.parseFunctionLiteral)
+	p.prefixParsers.register(token.LBracket, p.parseArray)
+	p.prefixParsers.register(token.LBrace, p.parseHash)
+	p.prefixParsers.register(token.Match, p.parseMatch)
 
 	p.infixParsers.register(token.Plus, p.parseInfix)
 	p.infixParsers.register(token.Minus, p.parseInfix)
@@ -59,10 +103,20 @@ func New(lexer *lexer.Lexer) *Parser {
 	p.infixParsers.register(token.NotEqual, p.parseInfix)
 	p.infixParsers.register(token.LowerThan, p.parseInfix)
 	p.infixParsers.register(token.GreaterThan, p.parseInfix)
+	p.infixParsers.register(token.NullCoalesce, p.parseInfix)
+	p.infixParsers.register(token.LParen, p.parseCall)
+	p.infixParsers.register(token.LBracket, p.parseIndex)
+	p.infixParsers.register(token.QuestionBracket, p.parseIndex)
+	p.infixParsers.register(token.Dot, p.parseProperty)
+	p.infixParsers.register(token.QuestionDot, p.parseOptional)
+	for t := range p.tokenToAssignMapping {
+		p.infixParsers.register(t, p.parseAssign)
+	}
 	// TODO: if all of them use the same parser, do we actually need
 	// a map of parsers of can we just check the validity of the
 	// infix token with a set and directly call parseInfix if valid?
 
+	p.expressionPrecedences.register(token.NullCoalesce, coalesce)
 	p.expressionPrecedences.register(token.Equal, equals)
 	p.expressionPrecedences.register(token.NotEqual, equals)
 	p.expressionPrecedences.register(token.LowerThan, lessGreater)
@@ -71,39 +125,87 @@ func New(lexer *lexer.Lexer) *Parser {
 	p.expressionPrecedences.register(token.Minus, sum)
 	p.expressionPrecedences.register(token.Slash, product)
 	p.expressionPrecedences.register(token.Asterisk, product)
+	p.expressionPrecedences.register(token.LParen, call)
+	p.expressionPrecedences.register(token.LBracket, index)
+	p.expressionPrecedences.register(token.QuestionBracket, index)
+	p.expressionPrecedences.register(token.Dot, index)
+	p.expressionPrecedences.register(token.QuestionDot, index)
+	for t := range p.tokenToAssignMapping {
+		p.expressionPrecedences.register(t, assign)
+	}
 
 	return p
 }
 
 func (p *Parser) Parse() (*ast.Root, error) {
 	r := ast.Root{}
+	p.cst.start()
 
 	// Read the initial tokens
 	// We do it twice to populate both current and peek
 	p.advanceToken()
 	p.advanceToken()
 
-	for p.current.Type != token.EOF {
-		if statement, err := p.parseStatement(); err == nil {
+	for p.current.Type != token.EOF && p.fatal == nil {
+		depth := p.cst.depth()
+		statement, err := p.parseStatement()
+		if p.fatal != nil {
+			// the statement error is a consequence of the limit
+			p.cst.abandon(depth)
+			break
+		}
+		if err == nil {
 			r.Statements = append(r.Statements, statement)
 		} else {
 			p.errors = append(p.errors, NewError(err, p.current))
+			p.cst.abandon(depth)
 		}
 		p.advanceToken()
 	}
 
+	if p.fatal != nil {
+		p.errors = append(p.errors, *p.fatal)
+	}
+
+	r.Comments = p.comments
+	p.cst.finish(&r)
+
 	return &r, p.error()
 }
 
 func (p *Parser) parseStatement() (ast.Statement, error) {
+	p.cst.start()
+
+	var s ast.Statement
+	var err error
 	switch p.current.Type {
 	case token.Let:
-		return p.parseLet()
+		s, err = p.parseLet()
 	case token.Return:
-		return p.parseReturn()
+		s, err = p.parseReturn()
+	case token.Import:
+		s, err = p.parseImport()
+	case token.Export:
+		s, err = p.parseExport()
+	case token.While:
+		s, err = p.parseWhile()
+	case token.For:
+		s, err = p.parseFor()
+	case token.Break, token.Continue:
+		s, err = p.parseLoopControl()
+	case token.Throw:
+		s, err = p.parseThrow()
+	case token.Try:
+		s, err = p.parseTry()
 	default:
-		return p.parseExpressionStatement()
+		s, err = p.parseExpressionStatement()
+	}
+	if err != nil {
+		return nil, err
 	}
+
+	p.cst.finish(s)
+	return s, nil
 }
 
 func (p *Parser) Errors() []error {
@@ -124,11 +226,56 @@ func (p *Parser) error() error {
 
 func (p *Parser) advanceToken() {
 	p.current = p.peek
+	switch p.current.Type {
+	case token.LBrace, token.DollarBrace:
+		p.braces++
+	case token.RBrace:
+		p.braces--
+	}
+	if p.fatal != nil {
+		// no more input is read after a fatal error
+		p.peek = token.Token{Type: token.EOF, Pos: p.peek.Pos}
+		p.cst.advance(p.peek, nil)
+		return
+	}
+
 	t, err := p.lexer.NextToken()
-	for ; err != nil; t, err = p.lexer.NextToken() {
-		p.errors = append(p.errors, NewError(err, p.peek))
+	for ; err == nil && t.Type == token.Comment; t, err = p.lexer.NextToken() {
+		p.comments = append(p.comments, &ast.Comment{Token: t})
+	}
+	p.addTriviaComments()
+
+	if err != nil {
+		// the input can't be read anymore, so this stops the parsing like a limit
+		p.setFatal(err, p.peek)
+		t = token.Token{Type: token.EOF, Pos: p.peek.Pos}
+	} else if err := p.checkInput(t); err != nil {
+		p.setFatal(err, t)
+		// pretend the input ends here, so the parser unwinds
+		t = token.Token{Type: token.EOF, Pos: t.Pos}
 	}
 	p.peek = t
+	p.cst.advance(t, p.lexer)
+}
+
+// addTriviaComments keeps the comments returned as trivia, when the lexer
+// keeps them, so the AST has the comments in both cases.
+func (p *Parser) addTriviaComments() {
+	tr, ok := p.lexer.(lexer.TriviaReader)
+	if !ok {
+		return
+	}
+
+	leading, trailing := tr.LastTrivia()
+	for _, trivia := range [][]token.Trivia{leading, trailing} {
+		for _, t := range trivia {
+			if t.Type == token.LineComment {
+				p.comments = append(p.comments, &ast.Comment{
+					Token: token.Token{Type: token.Comment, Literal: t.Text, Pos: t.Pos},
+				})
+			}
+		}
+	}
 }
 
 func (p *Parser) assertPeek(wantTokenType token.Type) error {
@@ -161,13 +308,40 @@ func (p *Parser) parseLet() (*ast.Let, error) {
 		Token: p.current,
 	}
 
-	if err := p.assertPeek(token.Ident); err != nil {
-		return nil, err
+	switch p.peek.Type {
+	case token.LBracket, token.LBrace:
+		p.advanceToken()
+		destructuring := p.destructuring
+		p.destructuring = true
+		pattern, err := p.parsePattern()
+		p.destructuring = destructuring
+		if err != nil {
+			return nil, err
+		}
+		l.Pattern = pattern
+	default:
+		if err := p.assertPeek(token.Ident); err != nil {
+			return nil, err
+		}
+
+		l.Name = &ast.Identifier{Token: p.peek, Value: p.peek.Literal}
+
+		p.advanceToken()
+		p.cst.start()
+		p.cst.finish(l.Name)
 	}
 
-	l.Name = &ast.Identifier{Token: p.peek, Value: p.peek.Literal}
+	if p.peek.Type == token.Colon {
+		p.advanceToken()
+		p.advanceToken()
+		// now current is at the beginning of the type
 
-	p.advanceToken()
+		t, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		l.Type = t
+	}
 
 	if err := p.assertPeek(token.Assign); err != nil {
 		return nil, err
@@ -215,6 +389,261 @@ func (p *Parser) parseReturn() (*ast.Return, error) {
 	return r, nil
 }
 
+func (p *Parser) parseImport() (*ast.Import, error) {
+	if p.blocks > 0 {
+		return nil, NewError(perrors.New("import is only allowed at the top level"), p.current)
+	}
+
+	i := &ast.Import{
+		Token: p.current,
+	}
+
+	if err := p.assertPeek(token.String); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	p.cst.start()
+	path, err := p.parseString()
+	if err != nil {
+		return nil, err
+	}
+	i.Path = path.(*ast.StringLiteral)
+	p.cst.finish(i.Path)
+
+	if p.peek.Type == token.Semicolon {
+		p.advanceToken()
+	}
+
+	return i, nil
+}
+
+func (p *Parser) parseExport() (*ast.Export, error) {
+	if p.blocks > 0 {
+		return nil, NewError(perrors.New("export is only allowed at the top level"), p.current)
+	}
+
+	e := &ast.Export{
+		Token: p.current,
+	}
+
+	if err := p.assertPeek(token.Let); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	p.cst.start()
+	l, err := p.parseLet()
+	if err != nil {
+		return nil, err
+	}
+	e.Let = l
+	p.cst.finish(l)
+
+	return e, nil
+}
+
+func (p *Parser) parseWhile() (*ast.While, error) {
+	w := &ast.While{
+		Token: p.current,
+	}
+
+	if err := p.assertPeek(token.LParen); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+	p.advanceToken()
+	// now current is at the beginning of the condition
+
+	condition, err := p.parseExpression(lowest)
+	if err != nil {
+		return nil, err
+	}
+	w.Condition = condition
+
+	if err := p.assertPeek(token.RParen); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	if w.Body, err = p.parseLoopBody(); err != nil {
+		return nil, err
+	}
+
+	if p.peek.Type == token.Semicolon {
+		p.advanceToken()
+	}
+
+	return w, nil
+}
+
+func (p *Parser) parseFor() (*ast.For, error) {
+	f := &ast.For{
+		Token: p.current,
+	}
+
+	if err := p.assertPeek(token.LParen); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	if err := p.assertPeek(token.Ident); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+	f.Variable = &ast.Identifier{Token: p.current, Value: p.current.Literal}
+	p.cst.start()
+	p.cst.finish(f.Variable)
+
+	if err := p.assertPeek(token.In); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+	p.advanceToken()
+	// now current is at the beginning of the iterable
+
+	iterable, err := p.parseExpression(lowest)
+	if err != nil {
+		return nil, err
+	}
+	f.Iterable = iterable
+
+	if err := p.assertPeek(token.RParen); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	if f.Body, err = p.parseLoopBody(); err != nil {
+		return nil, err
+	}
+
+	if p.peek.Type == token.Semicolon {
+		p.advanceToken()
+	}
+
+	return f, nil
+}
+
+// parseLoopBody parses the block of a loop, where break and continue are allowed.
+// It expects peek to be the opening brace and leaves current at the closing one.
+func (p *Parser) parseLoopBody() (*ast.Block, error) {
+	if err := p.assertPeek(token.LBrace); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	p.loops++
+	defer func() { p.loops-- }()
+	return p.parseBlock()
+}
+
+// parseLoopControl parses a break or a continue.
+func (p *Parser) parseLoopControl() (ast.Statement, error) {
+	if p.loops == 0 {
+		return nil, NewError(perrors.Errorf("%s outside of a loop", p.current.Literal), p.current)
+	}
+
+	var s ast.Statement = &ast.Break{Token: p.current}
+	if p.current.Type == token.Continue {
+		s = &ast.Continue{Token: p.current}
+	}
+
+	if p.peek.Type == token.Semicolon {
+		p.advanceToken()
+	}
+
+	return s, nil
+}
+
+func (p *Parser) parseThrow() (*ast.Throw, error) {
+	t := &ast.Throw{
+		Token: p.current,
+	}
+
+	// now current is at the beginning of the expression
+	p.advanceToken()
+
+	exp, err := p.parseExpression(lowest)
+	if err != nil {
+		return nil, err
+	}
+	t.Value = exp
+
+	if p.peek.Type == token.Semicolon {
+		p.advanceToken()
+	}
+
+	return t, nil
+}
+
+func (p *Parser) parseTry() (*ast.Try, error) {
+	t := &ast.Try{
+		Token: p.current,
+	}
+
+	if err := p.assertPeek(token.LBrace); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	var err error
+	if t.Body, err = p.parseBlock(); err != nil {
+		return nil, err
+	}
+
+	if p.peek.Type != token.Catch && p.peek.Type != token.Finally {
+		return nil, NewError(perrors.New("expected catch or finally after try"), p.peek)
+	}
+
+	if p.peek.Type == token.Catch {
+		p.advanceToken()
+		if err := p.assertPeek(token.LParen); err != nil {
+			return nil, err
+		}
+		p.advanceToken()
+
+		if err := p.assertPeek(token.Ident); err != nil {
+			return nil, err
+		}
+		p.advanceToken()
+		t.Param = &ast.Identifier{Token: p.current, Value: p.current.Literal}
+		p.cst.start()
+		p.cst.finish(t.Param)
+
+		if err := p.assertPeek(token.RParen); err != nil {
+			return nil, err
+		}
+		p.advanceToken()
+
+		if err := p.assertPeek(token.LBrace); err != nil {
+			return nil, err
+		}
+		p.advanceToken()
+
+		if t.Catch, err = p.parseBlock(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek.Type == token.Finally {
+		p.advanceToken()
+		if err := p.assertPeek(token.LBrace); err != nil {
+			return nil, err
+		}
+		p.advanceToken()
+
+		if t.Finally, err = p.parseBlock(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek.Type == token.Semicolon {
+		p.advanceToken()
+	}
+
+	return t, nil
+}
+
 func (p *Parser) parseExpressionStatement() (*ast.ExpressionStatement, error) {
 	s := &ast.ExpressionStatement{
 		Token: p.current,
@@ -233,15 +662,25 @@ func (p *Parser) parseExpressionStatement() (*ast.ExpressionStatement, error) {
 }
 
 func (p *Parser) parseExpression(precedence precedence) (ast.Expression, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
 	prefixParser := p.prefixParsers.get(p.current.Type)
+	if prefixParser == nil && p.current.Type == token.Illegal && strings.HasPrefix(p.current.Literal, `"`) {
+		return nil, NewError(perrors.New("unterminated string"), p.current)
+	}
 	if prefixParser == nil {
 		return nil, NewError(perrors.New("can't find a prefix operator for token"), p.current)
 	}
 
+	leftMarker := p.cst.start()
 	left, err := prefixParser()
 	if err != nil {
 		return nil, err
 	}
+	p.cst.finish(left)
 
 	for p.peek.Type != token.Semicolon && precedence < p.peekPrecedence() {
 		infixParser := p.infixParsers.get(p.peek.Type)
@@ -249,7 +688,12 @@ func (p *Parser) parseExpression(precedence precedence) (ast.Expression, error)
 			return left, nil
 		}
 		p.advanceToken()
+		leftMarker = p.cst.precede(leftMarker)
 		left, err = infixParser(left)
+		if err != nil {
+			return nil, err
+		}
+		p.cst.finish(left)
 	}
 
 	return left, nil
@@ -267,6 +711,84 @@ func (p *Parser) parseLiteral() (ast.Expression, error) {
 	return &ast.Literal{Token: p.current, Value: value}, nil
 }
 
+func (p *Parser) parseString() (ast.Expression, error) {
+	value, err := unquote(p.current.Literal)
+	if err != nil {
+		return nil, NewError(err, p.current)
+	}
+	return &ast.StringLiteral{Token: p.current, Value: value}, nil
+}
+
+// parseTemplate parses the texts and interpolations of a template literal.
+// It leaves current at the closing backtick.
+func (p *Parser) parseTemplate() (ast.Expression, error) {
+	t := &ast.TemplateLiteral{
+		Token: p.current,
+	}
+
+	for {
+		p.advanceToken()
+		switch p.current.Type {
+		case token.Backtick:
+			t.End = p.current.Pos
+			return t, nil
+		case token.TemplateText:
+			// the text before EOF can end with a backslash, which isn't an escape
+			if p.peek.Type == token.EOF {
+				return nil, NewError(perrors.New("unterminated template"), t.Token)
+			}
+			p.cst.start()
+			value, err := unescapeText(p.current)
+			if err != nil {
+				return nil, err
+			}
+			text := &ast.TemplateText{Token: p.current, Value: value}
+			p.cst.finish(text)
+			t.Parts = append(t.Parts, text)
+		case token.DollarBrace:
+			braces := p.braces
+			depth := p.cst.depth()
+			e, err := p.parseInterpolation()
+			if err == nil {
+				t.Parts = append(t.Parts, e)
+				continue
+			}
+			if p.fatal != nil {
+				return nil, err
+			}
+			// skip to the end of the interpolation and go on with the
+			// template, so its rest isn't parsed as statements
+			perr := NewError(err, p.current)
+			p.cst.abandon(depth)
+			for p.braces >= braces && p.current.Type != token.EOF {
+				p.advanceToken()
+			}
+			if p.current.Type == token.EOF {
+				return nil, perr
+			}
+			p.errors = append(p.errors, perr)
+		default:
+			// the lexer only ends the text of a template with EOF
+			return nil, NewError(perrors.New("unterminated template"), t.Token)
+		}
+	}
+}
+
+// parseInterpolation parses the expression of an interpolation starting
+// at its ${. It leaves current at the closing brace.
+func (p *Parser) parseInterpolation() (ast.Expression, error) {
+	p.advanceToken()
+	e, err := p.parseExpression(lowest)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.assertPeek(token.RBrace); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+	return e, nil
+}
+
 func (p *Parser) parsePrefix() (ast.Expression, error) {
 	prefixExp := &ast.Prefix{
 		Token: p.current,
@@ -306,3 +828,883 @@ func (p *Parser) parseInfix(left ast.Expression) (ast.Expression, error) {
 
 	return e, nil
 }
+
+func (p *Parser) parseBoolean() (ast.Expression, error) {
+	return &ast.Boolean{Token: p.current, Value: p.current.Type == token.True}, nil
+}
+
+func (p *Parser) parseNull() (ast.Expression, error) {
+	return &ast.Null{Token: p.current}, nil
+}
+
+func (p *Parser) parseGrouped() (ast.Expression, error) {
+	p.advanceToken()
+
+	exp, err := p.parseExpression(lowest)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.assertPeek(token.RParen); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	return exp, nil
+}
+
+func (p *Parser) parseIf() (ast.Expression, error) {
+	e := &ast.If{
+		Token: p.current,
+	}
+
+	if err := p.assertPeek(token.LParen); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+	p.advanceToken()
+	// now current is at the beginning of the condition
+
+	condition, err := p.parseExpression(lowest)
+	if err != nil {
+		return nil, err
+	}
+	e.Condition = condition
+
+	if err := p.assertPeek(token.RParen); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	if err := p.assertPeek(token.LBrace); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	if e.Consequence, err = p.parseBlock(); err != nil {
+		return nil, err
+	}
+
+	if p.peek.Type != token.Else {
+		return e, nil
+	}
+	p.advanceToken()
+
+	if err := p.assertPeek(token.LBrace); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	if e.Alternative, err = p.parseBlock(); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// parseBlock parses a list of statements enclosed in braces.
+// It expects current to be the opening brace and leaves current at the closing one.
+func (p *Parser) parseBlock() (*ast.Block, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	p.blocks++
+	defer func() { p.blocks-- }()
+
+	b := &ast.Block{
+		Token: p.current,
+	}
+	p.cst.start()
+
+	p.advanceToken()
+	for p.current.Type != token.RBrace {
+		if p.current.Type == token.EOF {
+			return nil, NewError(perrors.Errorf("expected token type %s but got %s", token.RBrace, p.current.Type), p.current)
+		}
+
+		statement, err := p.parseStatement()
+		if err != nil {
+			return nil, err
+		}
+		b.Statements = append(b.Statements, statement)
+		p.advanceToken()
+	}
+	b.End = p.current.Pos
+	p.cst.finish(b)
+
+	return b, nil
+}
+
+func (p *Parser) parseFunctionLiteral() (ast.Expression, error) {
+	f := &ast.FunctionLiteral{
+		Token: p.current,
+	}
+
+	if err := p.assertPeek(token.LParen); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	var err error
+	if f.Parameters, err = p.parseParameters(); err != nil {
+		return nil, err
+	}
+
+	if p.peek.Type == token.Colon {
+		p.advanceToken()
+		p.advanceToken()
+		if f.ReturnType, err = p.parseType(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.assertPeek(token.LBrace); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	// the loops around the function can't be stopped from its body
+	loops := p.loops
+	p.loops = 0
+	defer func() { p.loops = loops }()
+
+	if f.Body, err = p.parseBlock(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// parseParameters parses a comma separated list of optionally annotated identifiers enclosed in parentheses.
+// It expects current to be the opening parenthesis and leaves current at the closing one.
+func (p *Parser) parseParameters() ([]*ast.Parameter, error) {
+	var params []*ast.Parameter
+	if p.peek.Type == token.RParen {
+		p.advanceToken()
+		return params, nil
+	}
+
+	for {
+		if err := p.assertPeek(token.Ident); err != nil {
+			return nil, err
+		}
+		p.advanceToken()
+		param := &ast.Parameter{
+			Name: &ast.Identifier{Token: p.current, Value: p.current.Literal},
+		}
+		p.cst.start()
+		p.cst.start()
+		p.cst.finish(param.Name)
+
+		if p.peek.Type == token.Colon {
+			p.advanceToken()
+			p.advanceToken()
+			t, err := p.parseType()
+			if err != nil {
+				return nil, err
+			}
+			param.Type = t
+		}
+		p.cst.finish(param)
+		params = append(params, param)
+
+		if p.peek.Type != token.Comma {
+			break
+		}
+		p.advanceToken()
+	}
+
+	if err := p.assertPeek(token.RParen); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	return params, nil
+}
+
+func (p *Parser) parseCall(function ast.Expression) (ast.Expression, error) {
+	c := &ast.Call{
+		Token:    p.current,
+		Function: function,
+	}
+
+	var err error
+	if c.Arguments, err = p.parseExpressionList(token.RParen); err != nil {
+		return nil, err
+	}
+	c.End = p.current.Pos
+
+	return c, nil
+}
+
+// parseExpressionList parses a comma separated list of expressions ending with end.
+// It expects current to be the opening token and leaves current at the closing one.
+func (p *Parser) parseExpressionList(end token.Type) ([]ast.Expression, error) {
+	var args []ast.Expression
+	if p.peek.Type == end {
+		p.advanceToken()
+		return args, nil
+	}
+
+	for {
+		p.advanceToken()
+		arg, err := p.parseExpression(lowest)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		if p.peek.Type != token.Comma {
+			break
+		}
+		p.advanceToken()
+	}
+
+	if err := p.assertPeek(end); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	return args, nil
+}
+
+func (p *Parser) parseArray() (ast.Expression, error) {
+	a := &ast.ArrayLiteral{
+		Token: p.current,
+	}
+
+	var err error
+	if a.Elements, err = p.parseExpressionList(token.RBracket); err != nil {
+		return nil, err
+	}
+	a.End = p.current.Pos
+
+	return a, nil
+}
+
+// parseHash parses a comma separated list of key value pairs enclosed in braces.
+// It leaves current at the closing brace.
+func (p *Parser) parseHash() (ast.Expression, error) {
+	h := &ast.HashLiteral{
+		Token: p.current,
+	}
+
+	for p.peek.Type != token.RBrace {
+		p.advanceToken()
+		pair := &ast.HashPair{}
+		p.cst.start()
+
+		key, err := p.parseExpression(lowest)
+		if err != nil {
+			return nil, err
+		}
+		pair.Key = key
+
+		if err := p.assertPeek(token.Colon); err != nil {
+			return nil, err
+		}
+		p.advanceToken()
+		p.advanceToken()
+
+		value, err := p.parseExpression(lowest)
+		if err != nil {
+			return nil, err
+		}
+		pair.Value = value
+		p.cst.finish(pair)
+		h.Pairs = append(h.Pairs, pair)
+
+		if p.peek.Type != token.Comma {
+			break
+		}
+		p.advanceToken()
+	}
+
+	if err := p.assertPeek(token.RBrace); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+	h.End = p.current.Pos
+
+	return h, nil
+}
+
+func (p *Parser) parseIndex(left ast.Expression) (ast.Expression, error) {
+	i := &ast.Index{
+		Token:    p.current,
+		Left:     left,
+		Optional: p.current.Type == token.QuestionBracket,
+	}
+
+	p.advanceToken()
+	index, err := p.parseExpression(lowest)
+	if err != nil {
+		return nil, err
+	}
+	i.Index = index
+
+	if err := p.assertPeek(token.RBracket); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	return i, nil
+}
+
+// parseProperty parses a property access, leaving current at its name.
+func (p *Parser) parseProperty(left ast.Expression) (ast.Expression, error) {
+	pr := &ast.Property{
+		Token:    p.current,
+		Left:     left,
+		Optional: p.current.Type == token.QuestionDot,
+	}
+
+	if err := p.assertPeek(token.Ident); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+	pr.Name = &ast.Identifier{Token: p.current, Value: p.current.Literal}
+	p.cst.start()
+	p.cst.finish(pr.Name)
+
+	return pr, nil
+}
+
+// parseOptional parses the optional access after ?., which is either
+// a property like a?.b or a call like f?.().
+func (p *Parser) parseOptional(left ast.Expression) (ast.Expression, error) {
+	if p.peek.Type != token.LParen {
+		return p.parseProperty(left)
+	}
+	p.advanceToken()
+
+	c, err := p.parseCall(left)
+	if err != nil {
+		return nil, err
+	}
+	c.(*ast.Call).Optional = true
+	return c, nil
+}
+
+// assignable reports whether an expression can be the target of an
+// assignment: a variable or an index or property that isn't part of
+// an optional chain, which could skip it.
+func assignable(e ast.Expression) bool {
+	switch e.(type) {
+	case *ast.Identifier:
+		return true
+	case *ast.Index, *ast.Property:
+		return !optionalChain(e)
+	}
+	return false
+}
+
+// optionalChain reports whether an expression is an optional access or
+// an access whose left side is part of an optional chain, like a?.b.c.
+func optionalChain(e ast.Expression) bool {
+	for {
+		switch l := e.(type) {
+		case *ast.Index:
+			if l.Optional {
+				return true
+			}
+			e = l.Left
+		case *ast.Property:
+			if l.Optional {
+				return true
+			}
+			e = l.Left
+		case *ast.Call:
+			if l.Optional {
+				return true
+			}
+			e = l.Function
+		default:
+			return false
+		}
+	}
+}
+
+func (p *Parser) parseAssign(target ast.Expression) (ast.Expression, error) {
+	if !assignable(target) {
+		return nil, NewError(perrors.New("invalid assignment target"), p.current)
+	}
+
+	a := &ast.Assign{
+		Token:    p.current,
+		Operator: p.tokenToAssignMapping[p.current.Type],
+		Target:   target,
+	}
+
+	p.advanceToken()
+	// the value takes any assignment that follows, as they are right associative
+	value, err := p.parseExpression(lowest)
+	if err != nil {
+		return nil, err
+	}
+	a.Value = value
+
+	return a, nil
+}
+
+// parseMatch parses a match expression, leaving current at its closing brace.
+func (p *Parser) parseMatch() (ast.Expression, error) {
+	m := &ast.Match{
+		Token: p.current,
+	}
+
+	// the arms of a match in the default value of a let pattern can have any pattern
+	destructuring := p.destructuring
+	p.destructuring = false
+	defer func() { p.destructuring = destructuring }()
+
+	if err := p.assertPeek(token.LParen); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+	p.advanceToken()
+
+	subject, err := p.parseExpression(lowest)
+	if err != nil {
+		return nil, err
+	}
+	m.Subject = subject
+
+	if err := p.assertPeek(token.RParen); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	if err := p.assertPeek(token.LBrace); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	// arms are separated by commas, allowing one after the last arm
+	for p.peek.Type != token.RBrace {
+		p.advanceToken()
+		arm, err := p.parseMatchArm()
+		if err != nil {
+			return nil, err
+		}
+		m.Arms = append(m.Arms, arm)
+
+		if p.peek.Type != token.Comma {
+			break
+		}
+		p.advanceToken()
+	}
+
+	if err := p.assertPeek(token.RBrace); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+	m.End = p.current.Pos
+
+	return m, nil
+}
+
+func (p *Parser) parseMatchArm() (*ast.MatchArm, error) {
+	a := &ast.MatchArm{}
+	p.cst.start()
+
+	pattern, err := p.parsePattern()
+	if err != nil {
+		return nil, err
+	}
+	a.Pattern = pattern
+
+	if p.peek.Type == token.If {
+		p.advanceToken()
+		p.advanceToken()
+		if a.Guard, err = p.parseExpression(lowest); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.assertPeek(token.FatArrow); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+	p.advanceToken()
+
+	if a.Body, err = p.parseExpression(lowest); err != nil {
+		return nil, err
+	}
+	p.cst.finish(a)
+
+	return a, nil
+}
+
+// parsePattern parses a pattern starting at current.
+// It leaves current at the last token of the pattern.
+func (p *Parser) parsePattern() (ast.Pattern, error) {
+	pattern, _, err := p.parseMarkedPattern()
+	return pattern, err
+}
+
+// parseMarkedPattern parses a pattern like parsePattern,
+// also returning the marker of its node.
+func (p *Parser) parseMarkedPattern() (ast.Pattern, marker, error) {
+	if err := p.enter(); err != nil {
+		return nil, 0, err
+	}
+	defer p.leave()
+
+	m := p.cst.start()
+
+	var pattern ast.Pattern
+	var err error
+	switch p.current.Type {
+	case token.Underscore:
+		pattern = &ast.WildcardPattern{Token: p.current}
+	case token.Ident:
+		pattern = &ast.BindingPattern{Name: p.parsePatternIdentifier()}
+	case token.Int, token.String, token.True, token.False, token.Null, token.Minus:
+		if p.destructuring {
+			// a let has no other way to go if the value doesn't match
+			return nil, 0, NewError(perrors.New("invalid destructuring target"), p.current)
+		}
+		pattern, err = p.parseLiteralPattern()
+	case token.LBracket:
+		pattern, err = p.parseArrayPattern()
+	case token.LBrace:
+		pattern, err = p.parseHashPattern()
+	default:
+		err = NewError(perrors.New("expected a pattern"), p.current)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	p.cst.finish(pattern)
+	return pattern, m, nil
+}
+
+// parseElementPattern parses an element of an array pattern or the value
+// of a hash pattern pair, which can have a default value.
+func (p *Parser) parseElementPattern() (ast.Pattern, error) {
+	pattern, m, err := p.parseMarkedPattern()
+	if err != nil {
+		return nil, err
+	}
+	return p.parseDefault(pattern, m)
+}
+
+// parseDefault parses the default value after a pattern, if there is one,
+// leaving current at its last token.
+func (p *Parser) parseDefault(pattern ast.Pattern, m marker) (ast.Pattern, error) {
+	if p.peek.Type != token.Assign {
+		return pattern, nil
+	}
+	p.advanceToken()
+	p.cst.precede(m)
+
+	d := &ast.DefaultPattern{
+		Token:   p.current,
+		Pattern: pattern,
+	}
+
+	p.advanceToken()
+	value, err := p.parseExpression(lowest)
+	if err != nil {
+		return nil, err
+	}
+	d.Default = value
+	p.cst.finish(d)
+
+	return d, nil
+}
+
+// parseRestPattern parses `...name`, leaving current at the name.
+func (p *Parser) parseRestPattern() (ast.Pattern, error) {
+	r := &ast.RestPattern{
+		Token: p.current,
+	}
+	p.cst.start()
+
+	if err := p.assertPeek(token.Ident); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+	r.Name = p.parsePatternIdentifier()
+	p.cst.finish(r)
+
+	return r, nil
+}
+
+// parsePatternIdentifier returns the identifier at current as a node of its own.
+func (p *Parser) parsePatternIdentifier() *ast.Identifier {
+	ident := &ast.Identifier{Token: p.current, Value: p.current.Literal}
+	p.cst.start()
+	p.cst.finish(ident)
+	return ident
+}
+
+// parseLiteralPattern parses a literal, which can be a negative integer.
+func (p *Parser) parseLiteralPattern() (ast.Pattern, error) {
+	tok := p.current
+	value, err := p.parseExpression(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := value.(type) {
+	case *ast.Literal, *ast.StringLiteral, *ast.Boolean, *ast.Null:
+	case *ast.Prefix:
+		if _, ok := v.Right.(*ast.Literal); !ok || v.Operator != ast.Negative {
+			return nil, NewError(perrors.New("invalid literal pattern"), tok)
+		}
+	default:
+		return nil, NewError(perrors.New("invalid literal pattern"), tok)
+	}
+
+	return &ast.LiteralPattern{Value: value}, nil
+}
+
+func (p *Parser) parseArrayPattern() (ast.Pattern, error) {
+	a := &ast.ArrayPattern{
+		Token: p.current,
+	}
+
+	for p.peek.Type != token.RBracket {
+		p.advanceToken()
+		if p.current.Type == token.Ellipsis {
+			rest, err := p.parseRestPattern()
+			if err != nil {
+				return nil, err
+			}
+			a.Elements = append(a.Elements, rest)
+			if p.peek.Type != token.RBracket {
+				return nil, NewError(perrors.New("rest element must be last"), p.peek)
+			}
+			break
+		}
+
+		element, err := p.parseElementPattern()
+		if err != nil {
+			return nil, err
+		}
+		a.Elements = append(a.Elements, element)
+
+		if p.peek.Type != token.Comma {
+			break
+		}
+		p.advanceToken()
+	}
+
+	if err := p.assertPeek(token.RBracket); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	return a, nil
+}
+
+// parseHashPattern parses the pairs of a hash pattern, where the keys
+// are identifiers, strings or integers, leaving current at the closing brace.
+func (p *Parser) parseHashPattern() (ast.Pattern, error) {
+	h := &ast.HashPattern{
+		Token: p.current,
+	}
+
+	for p.peek.Type != token.RBrace {
+		p.advanceToken()
+		pair, err := p.parseHashPatternPair()
+		if err != nil {
+			return nil, err
+		}
+		h.Pairs = append(h.Pairs, pair)
+
+		if p.peek.Type != token.Comma {
+			break
+		}
+		p.advanceToken()
+	}
+
+	if err := p.assertPeek(token.RBrace); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	return h, nil
+}
+
+func (p *Parser) parseHashPatternPair() (*ast.HashPatternPair, error) {
+	pair := &ast.HashPatternPair{}
+	p.cst.start()
+
+	switch {
+	case p.current.Type == token.Ident && p.peek.Type != token.Colon:
+		// the shorthand binds the value to a variable named after the key
+		m := p.cst.start()
+		value := &ast.BindingPattern{Name: p.parsePatternIdentifier()}
+		p.cst.finish(value)
+
+		var err error
+		if pair.Value, err = p.parseDefault(value, m); err != nil {
+			return nil, err
+		}
+		p.cst.finish(pair)
+		return pair, nil
+	case p.current.Type == token.Ident:
+		pair.Key = p.parsePatternIdentifier()
+	case p.current.Type == token.String || p.current.Type == token.Int:
+		key, err := p.parseExpression(index)
+		if err != nil {
+			return nil, err
+		}
+		pair.Key = key
+	default:
+		return nil, NewError(perrors.New("invalid hash pattern key"), p.current)
+	}
+
+	if err := p.assertPeek(token.Colon); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+	p.advanceToken()
+
+	value, err := p.parseElementPattern()
+	if err != nil {
+		return nil, err
+	}
+	pair.Value = value
+	p.cst.finish(pair)
+
+	return pair, nil
+}
+
+// parseType parses a type annotation starting at current.
+// It leaves current at the last token of the type.
+func (p *Parser) parseType() (ast.TypeExpression, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	p.cst.start()
+
+	var t ast.TypeExpression
+	var err error
+	switch p.current.Type {
+	case token.Ident:
+		t = &ast.NamedType{Token: p.current, Name: p.current.Literal}
+	case token.LBracket:
+		t, err = p.parseArrayType()
+	case token.LBrace:
+		t, err = p.parseHashType()
+	case token.Function:
+		t, err = p.parseFunctionType()
+	default:
+		err = NewError(perrors.New("expected a type"), p.current)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.cst.finish(t)
+	return t, nil
+}
+
+func (p *Parser) parseArrayType() (ast.TypeExpression, error) {
+	t := &ast.ArrayType{
+		Token: p.current,
+	}
+
+	p.advanceToken()
+	element, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	t.Element = element
+
+	if err := p.assertPeek(token.RBracket); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	return t, nil
+}
+
+func (p *Parser) parseHashType() (ast.TypeExpression, error) {
+	t := &ast.HashType{
+		Token: p.current,
+	}
+
+	p.advanceToken()
+	key, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	t.Key = key
+
+	if err := p.assertPeek(token.Colon); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+	p.advanceToken()
+
+	value, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	t.Value = value
+
+	if err := p.assertPeek(token.RBrace); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	return t, nil
+}
+
+func (p *Parser) parseFunctionType() (ast.TypeExpression, error) {
+	t := &ast.FunctionType{
+		Token: p.current,
+	}
+
+	if err := p.assertPeek(token.LParen); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+
+	if p.peek.Type == token.RParen {
+		p.advanceToken()
+	} else {
+		for {
+			p.advanceToken()
+			param, err := p.parseType()
+			if err != nil {
+				return nil, err
+			}
+			t.Parameters = append(t.Parameters, param)
+
+			if p.peek.Type != token.Comma {
+				break
+			}
+			p.advanceToken()
+		}
+
+		if err := p.assertPeek(token.RParen); err != nil {
+			return nil, err
+		}
+		p.advanceToken()
+	}
+
+	if err := p.assertPeek(token.Colon); err != nil {
+		return nil, err
+	}
+	p.advanceToken()
+	p.advanceToken()
+
+	ret, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	t.Return = ret
+
+	return t, nil
+}
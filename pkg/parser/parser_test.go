@@ -2,10 +2,15 @@ package parser_test
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"testing"
+	"testing/iotest"
 
+	"github.com/google/go-cmp/cmp/cmpopts"
 	. "github.com/onsi/gomega"
 
 	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
@@ -49,7 +54,144 @@ let foobar = 838383;`
 
 	program, err := p.Parse()
 	g.Expect(err).NotTo(HaveOccurred())
-	g.Expect(program).To(BeComparableTo(wantProgram))
+	g.Expect(program).To(BeComparableTo(wantProgram, ignorePositions))
+}
+
+func TestParserParseTypeAnnotations(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       string
+		wantProgram *ast.Root
+	}{
+		{
+			name:  "let with named type",
+			input: `let x: int = 5;`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.Let{
+						Token: letToken(),
+						Name:  identifier("x"),
+						Type:  namedType("int"),
+						Value: literal(5),
+					},
+				},
+			},
+		},
+		{
+			name:  "let with array and hash types",
+			input: `let x: {string: [int]} = y;`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.Let{
+						Token: letToken(),
+						Name:  identifier("x"),
+						Type: &ast.HashType{
+							Token: lBraceToken(),
+							Key:   namedType("string"),
+							Value: &ast.ArrayType{
+								Token:   lBracketToken(),
+								Element: namedType("int"),
+							},
+						},
+						Value: identifier("y"),
+					},
+				},
+			},
+		},
+		{
+			name:  "let with function type",
+			input: `let f: fn(int, fn(): bool): string = g;`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.Let{
+						Token: letToken(),
+						Name:  identifier("f"),
+						Type: &ast.FunctionType{
+							Token: functionToken(),
+							Parameters: []ast.TypeExpression{
+								namedType("int"),
+								&ast.FunctionType{
+									Token:  functionToken(),
+									Return: namedType("bool"),
+								},
+							},
+							Return: namedType("string"),
+						},
+						Value: identifier("g"),
+					},
+				},
+			},
+		},
+		{
+			name:  "annotated function literal",
+			input: `fn(a: int, b, c: string): bool { a }`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: functionToken(),
+						Expression: &ast.FunctionLiteral{
+							Token: functionToken(),
+							Parameters: []*ast.Parameter{
+								parameter("a", namedType("int")),
+								parameter("b", nil),
+								parameter("c", namedType("string")),
+							},
+							ReturnType: namedType("bool"),
+							Body:       block(expressionStatement("a")),
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			l := lexer.New(
+				lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(tc.input))),
+			)
+
+			p := parser.New(l)
+
+			program, err := p.Parse()
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(program).To(BeComparableTo(tc.wantProgram, ignorePositions))
+		})
+	}
+}
+
+func TestParserParseComments(t *testing.T) {
+	g := NewWithT(t)
+
+	input := `// the answer
+let x = 42; // trailing
+// end`
+
+	wantProgram := &ast.Root{
+		Statements: []ast.Statement{
+			&ast.Let{
+				Token: letToken(),
+				Name:  identifier("x"),
+				Value: literal(42),
+			},
+		},
+		Comments: []*ast.Comment{
+			{Token: token.Token{Type: token.Comment, Literal: "// the answer", Pos: token.Position{Offset: 0, Line: 1, Column: 1}}},
+			{Token: token.Token{Type: token.Comment, Literal: "// trailing", Pos: token.Position{Offset: 26, Line: 2, Column: 13}}},
+			{Token: token.Token{Type: token.Comment, Literal: "// end", Pos: token.Position{Offset: 38, Line: 3, Column: 1}}},
+		},
+	}
+
+	l := lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)
+
+	p := parser.New(l)
+
+	program, err := p.Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(program).To(BeComparableTo(wantProgram, ignorePositions))
+	g.Expect(program.Comments).To(BeComparableTo(wantProgram.Comments))
 }
 
 func TestParserParseReturnStatements(t *testing.T) {
@@ -93,403 +235,1386 @@ return 838383;`
 
 	program, err := p.Parse()
 	g.Expect(err).NotTo(HaveOccurred())
-	g.Expect(program).To(BeComparableTo(wantProgram))
+	g.Expect(program).To(BeComparableTo(wantProgram, ignorePositions))
 }
 
-func TestParserParseExpressionStatement(t *testing.T) {
-	testCases := []struct {
-		name        string
-		input       string
-		wantProgram *ast.Root
-	}{
-		{
-			name:  "with simple identifier",
-			input: `foobar;`,
-			wantProgram: &ast.Root{
-				Statements: []ast.Statement{
-					&ast.ExpressionStatement{
-						Token:      identifierToken("foobar"),
-						Expression: identifier("foobar"),
+func TestParserParseModules(t *testing.T) {
+	g := NewWithT(t)
+
+	input := `import "lib/math";
+export let greeting = "say \"hi\"\n";`
+
+	wantProgram := &ast.Root{
+		Statements: []ast.Statement{
+			&ast.Import{
+				Token: token.Token{Type: token.Import, Literal: "import"},
+				Path:  stringLiteral(`"lib/math"`, "lib/math"),
+			},
+			&ast.Export{
+				Token: token.Token{Type: token.Export, Literal: "export"},
+				Let: &ast.Let{
+					Token: letToken(),
+					Name:  identifier("greeting"),
+					Value: stringLiteral(`"say \"hi\"\n"`, "say \"hi\"\n"),
+				},
+			},
+		},
+	}
+
+	l := lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)
+
+	p := parser.New(l)
+
+	program, err := p.Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(program).To(BeComparableTo(wantProgram, ignorePositions))
+}
+
+func TestParserParseLoops(t *testing.T) {
+	g := NewWithT(t)
+
+	input := `while (x < 10) { if (x == 5) { break; } continue }
+for (item in items) { item; }`
+
+	wantProgram := &ast.Root{
+		Statements: []ast.Statement{
+			&ast.While{
+				Token:     token.Token{Type: token.While, Literal: "while"},
+				Condition: lessThan("x", 10),
+				Body: block(
+					expressionStatement(&ast.If{
+						Token:       ifToken(),
+						Condition:   equal("x", 5),
+						Consequence: block(&ast.Break{Token: token.Token{Type: token.Break, Literal: "break"}}),
+					}),
+					&ast.Continue{Token: token.Token{Type: token.Continue, Literal: "continue"}},
+				),
+			},
+			&ast.For{
+				Token:    token.Token{Type: token.For, Literal: "for"},
+				Variable: identifier("item"),
+				Iterable: identifier("items"),
+				Body:     block(expressionStatement("item")),
+			},
+		},
+	}
+
+	l := lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)
+
+	p := parser.New(l)
+
+	program, err := p.Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(program).To(BeComparableTo(wantProgram, ignorePositions))
+}
+
+func TestParserParseAssignments(t *testing.T) {
+	g := NewWithT(t)
+
+	input := `x = y = 1; xs[0] += 2 * 3; h["k"] -= [1, 2][1]; {"a": x, 1: {}}; n *= 2; n /= 2`
+
+	wantProgram := &ast.Root{
+		Statements: []ast.Statement{
+			&ast.ExpressionStatement{
+				Token: identifierToken("x"),
+				Expression: &ast.Assign{
+					Token:    token.Token{Type: token.Assign, Literal: "="},
+					Operator: ast.PlainAssign,
+					Target:   identifier("x"),
+					Value: &ast.Assign{
+						Token:    token.Token{Type: token.Assign, Literal: "="},
+						Operator: ast.PlainAssign,
+						Target:   identifier("y"),
+						Value:    literal(1),
+					},
+				},
+			},
+			&ast.ExpressionStatement{
+				Token: identifierToken("xs"),
+				Expression: &ast.Assign{
+					Token:    token.Token{Type: token.PlusAssign, Literal: "+="},
+					Operator: ast.AddAssign,
+					Target:   index("xs", 0),
+					Value:    multiply(2, 3),
+				},
+			},
+			&ast.ExpressionStatement{
+				Token: identifierToken("h"),
+				Expression: &ast.Assign{
+					Token:    token.Token{Type: token.MinusAssign, Literal: "-="},
+					Operator: ast.SubtractAssign,
+					Target:   index("h", stringLiteral(`"k"`, "k")),
+					Value: index(&ast.ArrayLiteral{
+						Token:    lBracketToken(),
+						Elements: []ast.Expression{literal(1), literal(2)},
+					}, 1),
+				},
+			},
+			&ast.ExpressionStatement{
+				Token: lBraceToken(),
+				Expression: &ast.HashLiteral{
+					Token: lBraceToken(),
+					Pairs: []*ast.HashPair{
+						{Key: stringLiteral(`"a"`, "a"), Value: identifier("x")},
+						{Key: literal(1), Value: &ast.HashLiteral{Token: lBraceToken()}},
+					},
+				},
+			},
+			&ast.ExpressionStatement{
+				Token: identifierToken("n"),
+				Expression: &ast.Assign{
+					Token:    token.Token{Type: token.AsteriskAssign, Literal: "*="},
+					Operator: ast.MultiplyAssign,
+					Target:   identifier("n"),
+					Value:    literal(2),
+				},
+			},
+			&ast.ExpressionStatement{
+				Token: identifierToken("n"),
+				Expression: &ast.Assign{
+					Token:    token.Token{Type: token.SlashAssign, Literal: "/="},
+					Operator: ast.DivideAssign,
+					Target:   identifier("n"),
+					Value:    literal(2),
+				},
+			},
+		},
+	}
+
+	l := lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)
+
+	p := parser.New(l)
+
+	program, err := p.Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(program).To(BeComparableTo(wantProgram, ignorePositions))
+}
+
+func TestParserParseExceptions(t *testing.T) {
+	g := NewWithT(t)
+
+	input := `try { throw "boom"; } catch (e) { e } finally { x };
+try { f() } finally {}`
+
+	wantProgram := &ast.Root{
+		Statements: []ast.Statement{
+			&ast.Try{
+				Token: token.Token{Type: token.Try, Literal: "try"},
+				Body: block(&ast.Throw{
+					Token: token.Token{Type: token.Throw, Literal: "throw"},
+					Value: stringLiteral(`"boom"`, "boom"),
+				}),
+				Param:   identifier("e"),
+				Catch:   block(expressionStatement("e")),
+				Finally: block(expressionStatement("x")),
+			},
+			&ast.Try{
+				Token:   token.Token{Type: token.Try, Literal: "try"},
+				Body:    block(&ast.ExpressionStatement{Token: identifierToken("f"), Expression: call("f")}),
+				Finally: block(),
+			},
+		},
+	}
+
+	l := lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)
+
+	p := parser.New(l)
+
+	program, err := p.Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(program).To(BeComparableTo(wantProgram, ignorePositions))
+}
+
+func TestParserParseMatch(t *testing.T) {
+	g := NewWithT(t)
+
+	input := `match (x) { 0 => "zero", -1 => a, [y, _] if y > 0 => y, {name, "age": n} => n, null => b, }`
+
+	wantProgram := &ast.Root{
+		Statements: []ast.Statement{
+			&ast.ExpressionStatement{
+				Token: token.Token{Type: token.Match, Literal: "match"},
+				Expression: &ast.Match{
+					Token:   token.Token{Type: token.Match, Literal: "match"},
+					Subject: identifier("x"),
+					Arms: []*ast.MatchArm{
+						{
+							Pattern: &ast.LiteralPattern{Value: literal(0)},
+							Body:    stringLiteral(`"zero"`, "zero"),
+						},
+						{
+							Pattern: &ast.LiteralPattern{Value: &ast.Prefix{Token: minusToken(), Operator: ast.Negative, Right: literal(1)}},
+							Body:    identifier("a"),
+						},
+						{
+							Pattern: &ast.ArrayPattern{
+								Token: lBracketToken(),
+								Elements: []ast.Pattern{
+									&ast.BindingPattern{Name: identifier("y")},
+									&ast.WildcardPattern{Token: token.Token{Type: token.Underscore, Literal: "_"}},
+								},
+							},
+							Guard: greaterThan("y", 0),
+							Body:  identifier("y"),
+						},
+						{
+							Pattern: &ast.HashPattern{
+								Token: lBraceToken(),
+								Pairs: []*ast.HashPatternPair{
+									{Value: &ast.BindingPattern{Name: identifier("name")}},
+									{Key: stringLiteral(`"age"`, "age"), Value: &ast.BindingPattern{Name: identifier("n")}},
+								},
+							},
+							Body: identifier("n"),
+						},
+						{
+							Pattern: &ast.LiteralPattern{Value: &ast.Null{Token: token.Token{Type: token.Null, Literal: "null"}}},
+							Body:    identifier("b"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	l := lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)
+
+	p := parser.New(l)
+
+	program, err := p.Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(program).To(BeComparableTo(wantProgram, ignorePositions))
+}
+
+func TestParserParseDestructuring(t *testing.T) {
+	g := NewWithT(t)
+
+	input := `let [a, _ = 1, ...rest] = xs; let {name, age = 0, "k": [b], n: {c}} = p;`
+
+	assign := token.Token{Type: token.Assign, Literal: "="}
+	wantProgram := &ast.Root{
+		Statements: []ast.Statement{
+			&ast.Let{
+				Token: letToken(),
+				Pattern: &ast.ArrayPattern{
+					Token: lBracketToken(),
+					Elements: []ast.Pattern{
+						&ast.BindingPattern{Name: identifier("a")},
+						&ast.DefaultPattern{
+							Token:   assign,
+							Pattern: &ast.WildcardPattern{Token: token.Token{Type: token.Underscore, Literal: "_"}},
+							Default: literal(1),
+						},
+						&ast.RestPattern{Token: token.Token{Type: token.Ellipsis, Literal: "..."}, Name: identifier("rest")},
+					},
+				},
+				Value: identifier("xs"),
+			},
+			&ast.Let{
+				Token: letToken(),
+				Pattern: &ast.HashPattern{
+					Token: lBraceToken(),
+					Pairs: []*ast.HashPatternPair{
+						{Value: &ast.BindingPattern{Name: identifier("name")}},
+						{Value: &ast.DefaultPattern{Token: assign, Pattern: &ast.BindingPattern{Name: identifier("age")}, Default: literal(0)}},
+						{
+							Key: stringLiteral(`"k"`, "k"),
+							Value: &ast.ArrayPattern{
+								Token:    lBracketToken(),
+								Elements: []ast.Pattern{&ast.BindingPattern{Name: identifier("b")}},
+							},
+						},
+						{
+							Key: identifier("n"),
+							Value: &ast.HashPattern{
+								Token: lBraceToken(),
+								Pairs: []*ast.HashPatternPair{{Value: &ast.BindingPattern{Name: identifier("c")}}},
+							},
+						},
+					},
+				},
+				Value: identifier("p"),
+			},
+		},
+	}
+
+	l := lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)
+
+	p := parser.New(l)
+
+	program, err := p.Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(program).To(BeComparableTo(wantProgram, ignorePositions))
+}
+
+func TestParserParseTemplates(t *testing.T) {
+	g := NewWithT(t)
+
+	input := "`a\\n ${x + 1}${`b ${y}`}\\`\\${`"
+
+	backtick := token.Token{Type: token.Backtick, Literal: "`"}
+	text := func(literal, value string) *ast.TemplateText {
+		return &ast.TemplateText{Token: token.Token{Type: token.TemplateText, Literal: literal}, Value: value}
+	}
+	wantProgram := &ast.Root{
+		Statements: []ast.Statement{
+			&ast.ExpressionStatement{
+				Token: backtick,
+				Expression: &ast.TemplateLiteral{
+					Token: backtick,
+					Parts: []ast.Expression{
+						text(`a\n `, "a\n "),
+						add("x", 1),
+						&ast.TemplateLiteral{
+							Token: backtick,
+							Parts: []ast.Expression{text("b ", "b "), identifier("y")},
+						},
+						text("\\`\\${", "`${"),
+					},
+				},
+			},
+		},
+	}
+
+	l := lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)
+
+	p := parser.New(l)
+
+	program, err := p.Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(program).To(BeComparableTo(wantProgram, ignorePositions))
+}
+
+func TestParserParseOptionalChaining(t *testing.T) {
+	g := NewWithT(t)
+
+	input := `a?.b.c ?? null == x; xs?[0]; f?.(1); p.name = 1`
+
+	dot := token.Token{Type: token.Dot, Literal: "."}
+	questionDot := token.Token{Type: token.QuestionDot, Literal: "?."}
+	wantProgram := &ast.Root{
+		Statements: []ast.Statement{
+			&ast.ExpressionStatement{
+				Token: identifierToken("a"),
+				Expression: &ast.Infix{
+					Token:    token.Token{Type: token.NullCoalesce, Literal: "??"},
+					Operator: ast.NullCoalesce,
+					Left: &ast.Property{
+						Token: dot,
+						Left: &ast.Property{
+							Token:    questionDot,
+							Left:     identifier("a"),
+							Name:     identifier("b"),
+							Optional: true,
+						},
+						Name: identifier("c"),
+					},
+					Right: equal(&ast.Null{Token: token.Token{Type: token.Null, Literal: "null"}}, "x"),
+				},
+			},
+			&ast.ExpressionStatement{
+				Token: identifierToken("xs"),
+				Expression: &ast.Index{
+					Token:    token.Token{Type: token.QuestionBracket, Literal: "?["},
+					Left:     identifier("xs"),
+					Index:    literal(0),
+					Optional: true,
+				},
+			},
+			&ast.ExpressionStatement{
+				Token: identifierToken("f"),
+				Expression: &ast.Call{
+					Token:     lParenToken(),
+					Function:  identifier("f"),
+					Arguments: []ast.Expression{literal(1)},
+					Optional:  true,
+				},
+			},
+			&ast.ExpressionStatement{
+				Token: identifierToken("p"),
+				Expression: &ast.Assign{
+					Token:    token.Token{Type: token.Assign, Literal: "="},
+					Operator: ast.PlainAssign,
+					Target:   &ast.Property{Token: dot, Left: identifier("p"), Name: identifier("name")},
+					Value:    literal(1),
+				},
+			},
+		},
+	}
+
+	l := lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)
+
+	p := parser.New(l)
+
+	program, err := p.Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(program).To(BeComparableTo(wantProgram, ignorePositions))
+}
+
+func TestParserParseExpressionStatement(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       string
+		wantProgram *ast.Root
+	}{
+		{
+			name:  "with simple identifier",
+			input: `foobar;`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token:      identifierToken("foobar"),
+						Expression: identifier("foobar"),
+					},
+				},
+			},
+		},
+		{
+			name:  "with simple literal",
+			input: `5;`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token:      intToken(5),
+						Expression: literal(5),
+					},
+				},
+			},
+		},
+		{
+			name:  "with simple prefix expression bang",
+			input: `!5;`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: bangToken(),
+						Expression: &ast.Prefix{
+							Token:    bangToken(),
+							Operator: ast.Not,
+							Right:    literal(5),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "with simple prefix expression -",
+			input: `-15;`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: minusToken(),
+						Expression: &ast.Prefix{
+							Token:    minusToken(),
+							Operator: ast.Negative,
+							Right:    literal(15),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "with simple infix operator +",
+			input: `5 + 5;`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: intToken(5),
+						Expression: &ast.Infix{
+							Token:    plusToken(),
+							Left:     literal(5),
+							Operator: ast.Addition,
+							Right:    literal(5),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "with simple infix operator *",
+			input: `5 * 5;`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: intToken(5),
+						Expression: &ast.Infix{
+							Token:    asteriskToken(),
+							Left:     literal(5),
+							Operator: ast.Multiplication,
+							Right:    literal(5),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "with simple infix operator /",
+			input: `5 / 5;`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: intToken(5),
+						Expression: &ast.Infix{
+							Token:    slashToken(),
+							Left:     literal(5),
+							Operator: ast.Division,
+							Right:    literal(5),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "with simple infix operator >",
+			input: `5 > 5;`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: intToken(5),
+						Expression: &ast.Infix{
+							Token:    greaterThanToken(),
+							Left:     literal(5),
+							Operator: ast.GreaterThan,
+							Right:    literal(5),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "with simple infix operator <",
+			input: `5 < 5;`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: intToken(5),
+						Expression: &ast.Infix{
+							Token:    lessThanToken(),
+							Left:     literal(5),
+							Operator: ast.LessThan,
+							Right:    literal(5),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "with simple infix operator ==",
+			input: `5 == 5;`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: intToken(5),
+						Expression: &ast.Infix{
+							Token:    equalToken(),
+							Left:     literal(5),
+							Operator: ast.Equal,
+							Right:    literal(5),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "with simple infix operator !=",
+			input: `5 != 5;`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: intToken(5),
+						Expression: &ast.Infix{
+							Token:    notEqualToken(),
+							Left:     literal(5),
+							Operator: ast.NotEqual,
+							Right:    literal(5),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "-a * b",
+			input: `-a * b;`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: minusToken(),
+						Expression: &ast.Infix{
+							Token: asteriskToken(),
+							Left: &ast.Prefix{
+								Token:    minusToken(),
+								Right:    identifier("a"),
+								Operator: ast.Negative,
+							},
+							Operator: ast.Multiplication,
+							Right:    identifier("b"),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "!-a",
+			input: `!-a`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: bangToken(),
+						Expression: &ast.Prefix{
+							Token: bangToken(),
+							Right: &ast.Prefix{
+								Token:    minusToken(),
+								Right:    identifier("a"),
+								Operator: ast.Negative,
+							},
+							Operator: ast.Not,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "a + b + c",
+			input: `a + b + c`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: identifierToken("a"),
+						Expression: &ast.Infix{
+							Token: plusToken(),
+							Left: &ast.Infix{
+								Token:    plusToken(),
+								Left:     identifier("a"),
+								Right:    identifier("b"),
+								Operator: ast.Addition,
+							},
+							Operator: ast.Addition,
+							Right:    identifier("c"),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "a + b - c",
+			input: `a + b - c`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: identifierToken("a"),
+						Expression: &ast.Infix{
+							Token: minusToken(),
+							Left: &ast.Infix{
+								Token:    plusToken(),
+								Left:     identifier("a"),
+								Right:    identifier("b"),
+								Operator: ast.Addition,
+							},
+							Operator: ast.Subtraction,
+							Right:    identifier("c"),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "a * b * c",
+			input: `a * b * c`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: identifierToken("a"),
+						Expression: &ast.Infix{
+							Token: asteriskToken(),
+							Left: &ast.Infix{
+								Token:    asteriskToken(),
+								Left:     identifier("a"),
+								Right:    identifier("b"),
+								Operator: ast.Multiplication,
+							},
+							Operator: ast.Multiplication,
+							Right:    identifier("c"),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "a * b / c",
+			input: `a * b / c`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: identifierToken("a"),
+						Expression: &ast.Infix{
+							Token: slashToken(),
+							Left: &ast.Infix{
+								Token:    asteriskToken(),
+								Left:     identifier("a"),
+								Right:    identifier("b"),
+								Operator: ast.Multiplication,
+							},
+							Operator: ast.Division,
+							Right:    identifier("c"),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "a + b / c",
+			input: `a + b / c`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: identifierToken("a"),
+						Expression: &ast.Infix{
+							Token: plusToken(),
+							Left:  identifier("a"),
+							Right: &ast.Infix{
+								Token:    slashToken(),
+								Left:     identifier("b"),
+								Right:    identifier("c"),
+								Operator: ast.Division,
+							},
+							Operator: ast.Addition,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "a + b * c + d / e - f",
+			input: `a + b * c + d / e - f`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: identifierToken("a"),
+						Expression: sub(
+							add(
+								add("a", multiply("b", "c")),
+								divide("d", "e"),
+							),
+							"f",
+						),
+					},
+				},
+			},
+		},
+		{
+			name:  "5 > 4 == 3 < 4",
+			input: `5 > 4 == 3 < 4`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: intToken(5),
+						Expression: equal(
+							greaterThan(5, 4),
+							lessThan(3, 4),
+						),
+					},
+				},
+			},
+		},
+		{
+			name:  "5 < 4 != 3 > 4",
+			input: `5 < 4 != 3 > 4`,
+			wantProgram: &ast.Root{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: intToken(5),
+						Expression: notEqual(
+							lessThan(5, 4),
+							greaterThan(3, 4),
+						),
 					},
 				},
 			},
 		},
 		{
-			name:  "with simple literal",
-			input: `5;`,
+			name:  "3 + 4 * 5 == 3 * 1 + 4 * 5",
+			input: `3 + 4 * 5 == 3 * 1 + 4 * 5`,
 			wantProgram: &ast.Root{
 				Statements: []ast.Statement{
 					&ast.ExpressionStatement{
-						Token:      intToken(5),
-						Expression: literal(5),
+						Token: intToken(3),
+						Expression: equal(
+							add(3, multiply(4, 5)),
+							add(multiply(3, 1), multiply(4, 5)),
+						),
 					},
 				},
 			},
 		},
 		{
-			name:  "with simple prefix expression bang",
-			input: `!5;`,
+			name:  "true",
+			input: `true;`,
 			wantProgram: &ast.Root{
 				Statements: []ast.Statement{
 					&ast.ExpressionStatement{
-						Token: bangToken(),
-						Expression: &ast.Prefix{
-							Token:    bangToken(),
-							Operator: ast.Not,
-							Right:    literal(5),
-						},
+						Token:      trueToken(),
+						Expression: boolean(true),
 					},
 				},
 			},
 		},
 		{
-			name:  "with simple prefix expression -",
-			input: `-15;`,
+			name:  "false",
+			input: `false;`,
 			wantProgram: &ast.Root{
 				Statements: []ast.Statement{
 					&ast.ExpressionStatement{
-						Token: minusToken(),
-						Expression: &ast.Prefix{
-							Token:    minusToken(),
-							Operator: ast.Negative,
-							Right:    literal(15),
-						},
+						Token:      falseToken(),
+						Expression: boolean(false),
 					},
 				},
 			},
 		},
 		{
-			name:  "with simple infix operator +",
-			input: `5 + 5;`,
+			name:  "3 > 5 == false",
+			input: `3 > 5 == false`,
 			wantProgram: &ast.Root{
 				Statements: []ast.Statement{
 					&ast.ExpressionStatement{
-						Token: intToken(5),
-						Expression: &ast.Infix{
-							Token:    plusToken(),
-							Left:     literal(5),
-							Operator: ast.Addition,
-							Right:    literal(5),
-						},
+						Token: intToken(3),
+						Expression: equal(
+							greaterThan(3, 5),
+							boolean(false),
+						),
 					},
 				},
 			},
 		},
 		{
-			name:  "with simple infix operator *",
-			input: `5 * 5;`,
+			name:  "(5 + 5) * 2",
+			input: `(5 + 5) * 2`,
 			wantProgram: &ast.Root{
 				Statements: []ast.Statement{
 					&ast.ExpressionStatement{
-						Token: intToken(5),
-						Expression: &ast.Infix{
-							Token:    asteriskToken(),
-							Left:     literal(5),
-							Operator: ast.Multiplication,
-							Right:    literal(5),
-						},
+						Token:      lParenToken(),
+						Expression: multiply(add(5, 5), 2),
 					},
 				},
 			},
 		},
 		{
-			name:  "with simple infix operator /",
-			input: `5 / 5;`,
+			name:  "-(5 + 5)",
+			input: `-(5 + 5)`,
 			wantProgram: &ast.Root{
 				Statements: []ast.Statement{
 					&ast.ExpressionStatement{
-						Token: intToken(5),
-						Expression: &ast.Infix{
-							Token:    slashToken(),
-							Left:     literal(5),
-							Operator: ast.Division,
-							Right:    literal(5),
+						Token: minusToken(),
+						Expression: &ast.Prefix{
+							Token:    minusToken(),
+							Operator: ast.Negative,
+							Right:    add(5, 5),
 						},
 					},
 				},
 			},
 		},
 		{
-			name:  "with simple infix operator >",
-			input: `5 > 5;`,
+			name:  "if",
+			input: `if (x < y) { x }`,
 			wantProgram: &ast.Root{
 				Statements: []ast.Statement{
 					&ast.ExpressionStatement{
-						Token: intToken(5),
-						Expression: &ast.Infix{
-							Token:    greaterThanToken(),
-							Left:     literal(5),
-							Operator: ast.GreaterThan,
-							Right:    literal(5),
+						Token: ifToken(),
+						Expression: &ast.If{
+							Token:       ifToken(),
+							Condition:   lessThan("x", "y"),
+							Consequence: block(expressionStatement("x")),
 						},
 					},
 				},
 			},
 		},
 		{
-			name:  "with simple infix operator <",
-			input: `5 < 5;`,
+			name:  "if else",
+			input: `if (x < y) { x } else { return y; }`,
 			wantProgram: &ast.Root{
 				Statements: []ast.Statement{
 					&ast.ExpressionStatement{
-						Token: intToken(5),
-						Expression: &ast.Infix{
-							Token:    lessThanToken(),
-							Left:     literal(5),
-							Operator: ast.LessThan,
-							Right:    literal(5),
+						Token: ifToken(),
+						Expression: &ast.If{
+							Token:       ifToken(),
+							Condition:   lessThan("x", "y"),
+							Consequence: block(expressionStatement("x")),
+							Alternative: block(&ast.Return{
+								Token: returnToken(),
+								Value: identifier("y"),
+							}),
 						},
 					},
 				},
 			},
 		},
 		{
-			name:  "with simple infix operator ==",
-			input: `5 == 5;`,
+			name:  "function literal",
+			input: `fn(x, y) { x + y; }`,
 			wantProgram: &ast.Root{
 				Statements: []ast.Statement{
 					&ast.ExpressionStatement{
-						Token: intToken(5),
-						Expression: &ast.Infix{
-							Token:    equalToken(),
-							Left:     literal(5),
-							Operator: ast.Equal,
-							Right:    literal(5),
+						Token: functionToken(),
+						Expression: &ast.FunctionLiteral{
+							Token:      functionToken(),
+							Parameters: []*ast.Parameter{parameter("x", nil), parameter("y", nil)},
+							Body:       block(expressionStatement(add("x", "y"))),
 						},
 					},
 				},
 			},
 		},
 		{
-			name:  "with simple infix operator !=",
-			input: `5 != 5;`,
+			name:  "function literal without parameters",
+			input: `fn() {}`,
 			wantProgram: &ast.Root{
 				Statements: []ast.Statement{
 					&ast.ExpressionStatement{
-						Token: intToken(5),
-						Expression: &ast.Infix{
-							Token:    notEqualToken(),
-							Left:     literal(5),
-							Operator: ast.NotEqual,
-							Right:    literal(5),
+						Token: functionToken(),
+						Expression: &ast.FunctionLiteral{
+							Token: functionToken(),
+							Body:  block(),
 						},
 					},
 				},
 			},
 		},
 		{
-			name:  "-a * b",
-			input: `-a * b;`,
+			name:  "call",
+			input: `add(1, 2 * 3, 4 + 5);`,
 			wantProgram: &ast.Root{
 				Statements: []ast.Statement{
 					&ast.ExpressionStatement{
-						Token: minusToken(),
-						Expression: &ast.Infix{
-							Token: asteriskToken(),
-							Left: &ast.Prefix{
-								Token:    minusToken(),
-								Right:    identifier("a"),
-								Operator: ast.Negative,
-							},
-							Operator: ast.Multiplication,
-							Right:    identifier("b"),
-						},
+						Token:      identifierToken("add"),
+						Expression: call("add", 1, multiply(2, 3), add(4, 5)),
 					},
 				},
 			},
 		},
 		{
-			name:  "!-a",
-			input: `!-a`,
+			name:  "a + add(b * c) + d",
+			input: `a + add(b * c) + d`,
 			wantProgram: &ast.Root{
 				Statements: []ast.Statement{
 					&ast.ExpressionStatement{
-						Token: bangToken(),
-						Expression: &ast.Prefix{
-							Token: bangToken(),
-							Right: &ast.Prefix{
-								Token:    minusToken(),
-								Right:    identifier("a"),
-								Operator: ast.Negative,
-							},
-							Operator: ast.Not,
-						},
+						Token: identifierToken("a"),
+						Expression: add(
+							add("a", call("add", multiply("b", "c"))),
+							"d",
+						),
 					},
 				},
 			},
 		},
 		{
-			name:  "a + b + c",
-			input: `a + b + c`,
+			name:  "call function literal",
+			input: `fn(x) { x }(5)`,
 			wantProgram: &ast.Root{
 				Statements: []ast.Statement{
 					&ast.ExpressionStatement{
-						Token: identifierToken("a"),
-						Expression: &ast.Infix{
-							Token: plusToken(),
-							Left: &ast.Infix{
-								Token:    plusToken(),
-								Left:     identifier("a"),
-								Right:    identifier("b"),
-								Operator: ast.Addition,
+						Token: functionToken(),
+						Expression: call(
+							&ast.FunctionLiteral{
+								Token:      functionToken(),
+								Parameters: []*ast.Parameter{parameter("x", nil)},
+								Body:       block(expressionStatement("x")),
 							},
-							Operator: ast.Addition,
-							Right:    identifier("c"),
-						},
+							5,
+						),
 					},
 				},
 			},
 		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			l := lexer.New(
+				lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(tc.input))),
+			)
+
+			p := parser.New(l)
+
+			program, err := p.Parse()
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(program).To(BeComparableTo(tc.wantProgram, ignorePositions))
+		})
+	}
+}
+
+func TestParserParseErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "unclosed group",
+			input:   `(1 + 2`,
+			wantErr: "invalid program at 1:7 token.Token{Type:EOF, Literal:\"\"}: expected token type ) but got EOF",
+		},
+		{
+			name:    "unclosed block",
+			input:   `fn(x) { x`,
+			wantErr: "invalid program at 1:10 token.Token{Type:EOF, Literal:\"\"}: expected token type } but got EOF",
+		},
+		{
+			name:    "invalid parameter",
+			input:   `fn(1) { }`,
+			wantErr: "invalid program at 1:4 token.Token{Type:INT, Literal:\"1\"}: expected token type IDENT but got INT",
+		},
+		{
+			name:    "missing type",
+			input:   `let x: = 5;`,
+			wantErr: "invalid program at 1:8 token.Token{Type:ASSIGN, Literal:\"=\"}: expected a type",
+		},
+		{
+			name:    "function type without return type",
+			input:   `let f: fn(int) = g;`,
+			wantErr: "invalid program at 1:16 token.Token{Type:ASSIGN, Literal:\"=\"}: expected token type : but got ASSIGN",
+		},
+		{
+			name:    "error in infix right operand",
+			input:   `1 + )`,
+			wantErr: "invalid program at 1:5 token.Token{Type:), Literal:\")\"}: can't find a prefix operator for token",
+		},
+		{
+			name:    "unterminated string",
+			input:   `let s = "abc`,
+			wantErr: "invalid program at 1:9 token.Token{Type:ILLEGAL, Literal:\"\"abc\"}: unterminated string",
+		},
+		{
+			name:    "unknown escape sequence",
+			input:   `"a\qb"`,
+			wantErr: "invalid program at 1:1 token.Token{Type:STRING, Literal:\"\"a\\qb\"\"}: unknown escape sequence \\q",
+		},
+		{
+			name:    "unterminated template",
+			input:   "let s = `a ${b}",
+			wantErr: "invalid program at 1:9 token.Token{Type:`, Literal:\"`\"}: unterminated template",
+		},
+		{
+			name:    "unterminated template ending with a backslash",
+			input:   "`a\\",
+			wantErr: "invalid program at 1:1 token.Token{Type:`, Literal:\"`\"}: unterminated template",
+		},
+		{
+			name:    "unclosed interpolation",
+			input:   "`a ${b`",
+			wantErr: "invalid program at 1:7 token.Token{Type:`, Literal:\"`\"}: expected token type } but got `",
+		},
+		{
+			name:    "error in interpolation",
+			input:   "let s = `a\nb ${c +}`;",
+			wantErr: "invalid program at 2:8 token.Token{Type:}, Literal:\"}\"}: can't find a prefix operator for token",
+		},
+		{
+			name:    "unknown escape sequence in template",
+			input:   "`a ${b}\n c\\qd`",
+			wantErr: "invalid program at 2:3 token.Token{Type:TEMPLATE_TEXT, Literal:\"\\q\"}: unknown escape sequence \\q",
+		},
+		{
+			name:    "import without path",
+			input:   `import math;`,
+			wantErr: "invalid program at 1:8 token.Token{Type:IDENT, Literal:\"math\"}: expected token type STRING but got IDENT",
+		},
+		{
+			name:    "import in a block",
+			input:   `if (true) { import "math"; }`,
+			wantErr: "invalid program at 1:13 token.Token{Type:IMPORT, Literal:\"import\"}: import is only allowed at the top level",
+		},
+		{
+			name:    "export in a function",
+			input:   `let f = fn() { export let x = 1; };`,
+			wantErr: "invalid program at 1:16 token.Token{Type:EXPORT, Literal:\"export\"}: export is only allowed at the top level",
+		},
+		{
+			name:    "break outside of a loop",
+			input:   `if (x) { break; }`,
+			wantErr: "invalid program at 1:10 token.Token{Type:BREAK, Literal:\"break\"}: break outside of a loop",
+		},
+		{
+			name:    "continue in a function inside a loop",
+			input:   `while (true) { let f = fn() { continue; }; }`,
+			wantErr: "invalid program at 1:31 token.Token{Type:CONTINUE, Literal:\"continue\"}: continue outside of a loop",
+		},
+		{
+			name:    "for without in",
+			input:   `for (x of xs) { x }`,
+			wantErr: "invalid program at 1:8 token.Token{Type:IDENT, Literal:\"of\"}: expected token type IN but got IDENT",
+		},
+		{
+			name:    "while without parentheses",
+			input:   `while true { }`,
+			wantErr: "invalid program at 1:7 token.Token{Type:TRUE, Literal:\"true\"}: expected token type ( but got TRUE",
+		},
+		{
+			name:    "assignment to a literal",
+			input:   `1 = 2;`,
+			wantErr: "invalid program at 1:3 token.Token{Type:ASSIGN, Literal:\"=\"}: invalid assignment target",
+		},
+		{
+			name:    "compound assignment to a call",
+			input:   `f() += 1;`,
+			wantErr: "invalid program at 1:5 token.Token{Type:+=, Literal:\"+=\"}: invalid assignment target",
+		},
+		{
+			name:    "assignment to an optional property",
+			input:   `a?.b = 1;`,
+			wantErr: "invalid program at 1:6 token.Token{Type:ASSIGN, Literal:\"=\"}: invalid assignment target",
+		},
+		{
+			name:    "assignment to an optional chain",
+			input:   `a?.b.c = 1;`,
+			wantErr: "invalid program at 1:8 token.Token{Type:ASSIGN, Literal:\"=\"}: invalid assignment target",
+		},
+		{
+			name:    "optional chaining without a property",
+			input:   `a?.1`,
+			wantErr: "invalid program at 1:4 token.Token{Type:INT, Literal:\"1\"}: expected token type IDENT but got INT",
+		},
+		{
+			name:    "try without catch or finally",
+			input:   `try { 1 } 2`,
+			wantErr: "invalid program at 1:11 token.Token{Type:INT, Literal:\"2\"}: expected catch or finally after try",
+		},
+		{
+			name:    "catch without parameter",
+			input:   `try { 1 } catch { 2 }`,
+			wantErr: "invalid program at 1:17 token.Token{Type:{, Literal:\"{\"}: expected token type ( but got {",
+		},
+		{
+			name:    "throw without value",
+			input:   `throw;`,
+			wantErr: "invalid program at 1:6 token.Token{Type:;, Literal:\";\"}: can't find a prefix operator for token",
+		},
+		{
+			name:    "hash pair without value",
+			input:   `{"a" 1}`,
+			wantErr: "invalid program at 1:6 token.Token{Type:INT, Literal:\"1\"}: expected token type : but got INT",
+		},
+		{
+			name:    "match arm without arrow",
+			input:   `match (x) { 1 2 }`,
+			wantErr: "invalid program at 1:15 token.Token{Type:INT, Literal:\"2\"}: expected token type => but got INT",
+		},
+		{
+			name:    "expression as pattern",
+			input:   `match (x) { f(1) => 2 }`,
+			wantErr: "invalid program at 1:14 token.Token{Type:(, Literal:\"(\"}: expected token type => but got (",
+		},
+		{
+			name:    "invalid literal pattern",
+			input:   `match (x) { -y => 2 }`,
+			wantErr: "invalid program at 1:13 token.Token{Type:-, Literal:\"-\"}: invalid literal pattern",
+		},
+		{
+			name:    "invalid pattern",
+			input:   `match (x) { fn() {} => 2 }`,
+			wantErr: "invalid program at 1:13 token.Token{Type:FUNCTION, Literal:\"fn\"}: expected a pattern",
+		},
+		{
+			name:    "invalid hash pattern key",
+			input:   `match (x) { {[a]: b} => 2 }`,
+			wantErr: "invalid program at 1:14 token.Token{Type:[, Literal:\"[\"}: invalid hash pattern key",
+		},
+		{
+			name:    "literal in a destructuring let",
+			input:   `let [a, 1] = xs;`,
+			wantErr: "invalid program at 1:9 token.Token{Type:INT, Literal:\"1\"}: invalid destructuring target",
+		},
+		{
+			name:    "expression in a destructuring let",
+			input:   `let [a.b] = xs;`,
+			wantErr: "invalid program at 1:7 token.Token{Type:., Literal:\".\"}: expected token type ] but got .",
+		},
+		{
+			name:    "rest element not last",
+			input:   `let [...a, b] = xs;`,
+			wantErr: "invalid program at 1:10 token.Token{Type:,, Literal:\",\"}: rest element must be last",
+		},
+		{
+			name:    "rest element in a hash",
+			input:   `let {...a} = h;`,
+			wantErr: "invalid program at 1:6 token.Token{Type:..., Literal:\"...\"}: invalid hash pattern key",
+		},
+		{
+			name:    "literal in a match in a default value",
+			input:   `let [a = match (x) { 1 => 2 }, "b"] = xs;`,
+			wantErr: "invalid program at 1:32 token.Token{Type:STRING, Literal:\"\"b\"\"}: invalid destructuring target",
+		},
+		{
+			name:    "export without let",
+			input:   `export 1;`,
+			wantErr: "invalid program at 1:8 token.Token{Type:INT, Literal:\"1\"}: expected token type LET but got INT",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			l := lexer.New(
+				lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(tc.input))),
+			)
+
+			p := parser.New(l)
+
+			_, err := p.Parse()
+			g.Expect(err).To(HaveOccurred())
+			// The parser keeps going after an error, so only the first one is relevant
+			g.Expect(p.Errors()[0]).To(MatchError(tc.wantErr))
+		})
+	}
+}
+
+func TestParserParseTemplateErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		wantErrs []string
+	}{
+		{
+			name:  "empty interpolation",
+			input: "`x ${}`",
+			wantErrs: []string{
+				"invalid program at 1:6 token.Token{Type:}, Literal:\"}\"}: can't find a prefix operator for token",
+			},
+		},
+		{
+			name:  "error in interpolation followed by text",
+			input: "`ab\n  ${1 +} c`",
+			wantErrs: []string{
+				"invalid program at 2:8 token.Token{Type:}, Literal:\"}\"}: can't find a prefix operator for token",
+			},
+		},
 		{
-			name:  "a + b - c",
-			input: `a + b - c`,
-			wantProgram: &ast.Root{
-				Statements: []ast.Statement{
-					&ast.ExpressionStatement{
-						Token: identifierToken("a"),
-						Expression: &ast.Infix{
-							Token: minusToken(),
-							Left: &ast.Infix{
-								Token:    plusToken(),
-								Left:     identifier("a"),
-								Right:    identifier("b"),
-								Operator: ast.Addition,
-							},
-							Operator: ast.Subtraction,
-							Right:    identifier("c"),
-						},
-					},
-				},
+			name:  "unclosed expression in interpolation",
+			input: "`a ${1 2} b`",
+			wantErrs: []string{
+				"invalid program at 1:8 token.Token{Type:INT, Literal:\"2\"}: expected token type } but got INT",
 			},
 		},
 		{
-			name:  "a * b * c",
-			input: `a * b * c`,
-			wantProgram: &ast.Root{
-				Statements: []ast.Statement{
-					&ast.ExpressionStatement{
-						Token: identifierToken("a"),
-						Expression: &ast.Infix{
-							Token: asteriskToken(),
-							Left: &ast.Infix{
-								Token:    asteriskToken(),
-								Left:     identifier("a"),
-								Right:    identifier("b"),
-								Operator: ast.Multiplication,
-							},
-							Operator: ast.Multiplication,
-							Right:    identifier("c"),
-						},
-					},
-				},
+			name:  "errors in several interpolations",
+			input: "`a ${1 +} b ${2 +} c`; 3 +;",
+			wantErrs: []string{
+				"invalid program at 1:9 token.Token{Type:}, Literal:\"}\"}: can't find a prefix operator for token",
+				"invalid program at 1:18 token.Token{Type:}, Literal:\"}\"}: can't find a prefix operator for token",
+				"invalid program at 1:27 token.Token{Type:;, Literal:\";\"}: can't find a prefix operator for token",
 			},
 		},
 		{
-			name:  "a * b / c",
-			input: `a * b / c`,
-			wantProgram: &ast.Root{
-				Statements: []ast.Statement{
-					&ast.ExpressionStatement{
-						Token: identifierToken("a"),
-						Expression: &ast.Infix{
-							Token: slashToken(),
-							Left: &ast.Infix{
-								Token:    asteriskToken(),
-								Left:     identifier("a"),
-								Right:    identifier("b"),
-								Operator: ast.Multiplication,
-							},
-							Operator: ast.Division,
-							Right:    identifier("c"),
-						},
-					},
-				},
+			name:  "error in a hash in an interpolation",
+			input: "`a ${ {\"k\": } } b`",
+			wantErrs: []string{
+				"invalid program at 1:13 token.Token{Type:}, Literal:\"}\"}: can't find a prefix operator for token",
 			},
 		},
 		{
-			name:  "a + b / c",
-			input: `a + b / c`,
-			wantProgram: &ast.Root{
-				Statements: []ast.Statement{
-					&ast.ExpressionStatement{
-						Token: identifierToken("a"),
-						Expression: &ast.Infix{
-							Token: plusToken(),
-							Left:  identifier("a"),
-							Right: &ast.Infix{
-								Token:    slashToken(),
-								Left:     identifier("b"),
-								Right:    identifier("c"),
-								Operator: ast.Division,
-							},
-							Operator: ast.Addition,
-						},
-					},
-				},
+			name:  "error in a nested template",
+			input: "`a ${ `b ${}` } c`",
+			wantErrs: []string{
+				"invalid program at 1:12 token.Token{Type:}, Literal:\"}\"}: can't find a prefix operator for token",
 			},
 		},
 		{
-			name:  "a + b * c + d / e - f",
-			input: `a + b * c + d / e - f`,
-			wantProgram: &ast.Root{
-				Statements: []ast.Statement{
-					&ast.ExpressionStatement{
-						Token: identifierToken("a"),
-						Expression: sub(
-							add(
-								add("a", multiply("b", "c")),
-								divide("d", "e"),
-							),
-							"f",
-						),
-					},
-				},
+			name:  "error in an interpolation ended by EOF",
+			input: "`x ${1 +",
+			wantErrs: []string{
+				"invalid program at 1:9 token.Token{Type:EOF, Literal:\"\"}: can't find a prefix operator for token",
 			},
 		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			l := lexer.New(
+				lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(tc.input))),
+			)
+
+			p := parser.New(l)
+
+			_, err := p.Parse()
+			g.Expect(err).To(HaveOccurred())
+			errs := make([]string, 0, len(p.Errors()))
+			for _, err := range p.Errors() {
+				errs = append(errs, err.Error())
+			}
+			g.Expect(errs).To(Equal(tc.wantErrs))
+		})
+	}
+}
+
+func TestParserParseLimits(t *testing.T) {
+	testCases := []struct {
+		name       string
+		input      string
+		limits     parser.Limits
+		wantErr    error
+		wantErrPos string
+	}{
 		{
-			name:  "5 > 4 == 3 < 4",
-			input: `5 > 4 == 3 < 4`,
-			wantProgram: &ast.Root{
-				Statements: []ast.Statement{
-					&ast.ExpressionStatement{
-						Token: intToken(5),
-						Expression: equal(
-							greaterThan(5, 4),
-							lessThan(3, 4),
-						),
-					},
-				},
-			},
+			name:       "nested prefix operators",
+			input:      strings.Repeat("-", 1_000_000) + "1",
+			limits:     parser.DefaultLimits,
+			wantErr:    parser.ErrDepthLimit,
+			wantErrPos: "1:1001",
 		},
 		{
-			name:  "5 < 4 != 3 > 4",
-			input: `5 < 4 != 3 > 4`,
-			wantProgram: &ast.Root{
-				Statements: []ast.Statement{
-					&ast.ExpressionStatement{
-						Token: intToken(5),
-						Expression: notEqual(
-							lessThan(5, 4),
-							greaterThan(3, 4),
-						),
-					},
-				},
-			},
+			name:       "nested parentheses",
+			input:      strings.Repeat("(", 1_000_000),
+			limits:     parser.DefaultLimits,
+			wantErr:    parser.ErrDepthLimit,
+			wantErrPos: "1:1001",
 		},
 		{
-			name:  "3 + 4 * 5 == 3 * 1 + 4 * 5",
-			input: `3 + 4 * 5 == 3 * 1 + 4 * 5`,
-			wantProgram: &ast.Root{
-				Statements: []ast.Statement{
-					&ast.ExpressionStatement{
-						Token: intToken(3),
-						Expression: equal(
-							add(3, multiply(4, 5)),
-							add(multiply(3, 1), multiply(4, 5)),
-						),
-					},
-				},
-			},
+			name:       "nested blocks",
+			input:      strings.Repeat("if (true) {", 100),
+			limits:     parser.Limits{MaxDepth: 20},
+			wantErr:    parser.ErrDepthLimit,
+			wantErrPos: "1:111",
+		},
+		{
+			name:       "nested types",
+			input:      "let x: " + strings.Repeat("[", 100),
+			limits:     parser.Limits{MaxDepth: 10},
+			wantErr:    parser.ErrDepthLimit,
+			wantErrPos: "1:18",
+		},
+		{
+			name:       "token count",
+			input:      "let a = 1; let b = 2;",
+			limits:     parser.Limits{MaxTokens: 7},
+			wantErr:    parser.ErrTokenLimit,
+			wantErrPos: "1:18",
+		},
+		{
+			name:       "source size",
+			input:      "let a = 1;" + strings.Repeat(" ", 100),
+			limits:     parser.Limits{MaxSourceSize: 20},
+			wantErr:    parser.ErrSourceSizeLimit,
+			wantErrPos: "1:111",
+		},
+		{
+			name:   "within limits",
+			input:  "let a = -(1 + 2);",
+			limits: parser.Limits{MaxDepth: 5, MaxTokens: 10, MaxSourceSize: 17},
 		},
 	}
 	for _, tc := range testCases {
@@ -500,14 +1625,99 @@ func TestParserParseExpressionStatement(t *testing.T) {
 			)
 
 			p := parser.New(l)
+			p.Limits = tc.limits
 
-			program, err := p.Parse()
-			g.Expect(err).NotTo(HaveOccurred())
-			g.Expect(program).To(BeComparableTo(tc.wantProgram))
+			_, err := p.Parse()
+			if tc.wantErr == nil {
+				g.Expect(err).NotTo(HaveOccurred())
+				return
+			}
+
+			// Limits stop the parsing, so there are no cascading errors
+			g.Expect(p.Errors()).To(HaveLen(1))
+			var parserErr parser.Error
+			g.Expect(errors.As(p.Errors()[0], &parserErr)).To(BeTrue())
+			g.Expect(parserErr).To(MatchError(tc.wantErr))
+			g.Expect(parserErr.Token().Pos.String()).To(Equal(tc.wantErrPos))
 		})
 	}
 }
 
+func TestParserParseLimitReader(t *testing.T) {
+	g := NewWithT(t)
+	input := `let s = "` + strings.Repeat("a", 1<<20) + `";`
+	source := &countingReader{r: strings.NewReader(input)}
+	l := lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(parser.LimitReader(source, 100))),
+	)
+
+	p := parser.New(l)
+	p.Limits = parser.Limits{MaxSourceSize: 100}
+	_, err := p.Parse()
+	g.Expect(err).To(MatchError(parser.ErrSourceSizeLimit))
+	g.Expect(p.Errors()).To(HaveLen(1))
+	g.Expect(source.read).To(Equal(101), "the string is only read up to the limit")
+}
+
+func TestLimitReader(t *testing.T) {
+	g := NewWithT(t)
+
+	b, err := io.ReadAll(parser.LimitReader(strings.NewReader("abc"), 3))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(b)).To(Equal("abc"))
+
+	b, err = io.ReadAll(parser.LimitReader(strings.NewReader("abcd"), 3))
+	g.Expect(err).To(MatchError(parser.ErrSourceSizeLimit))
+	g.Expect(string(b)).To(Equal("abc"))
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r    io.Reader
+	read int
+}
+
+func (c *countingReader) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	c.read += n
+	return n, err
+}
+
+func TestParserParseStream(t *testing.T) {
+	g := NewWithT(t)
+	input := strings.Repeat("let add = fn(a, b) { a + b }; add(1, 2);\n", 50)
+
+	want, err := parser.New(lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)).Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	l := lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)
+	got, err := parser.New(l.Stream(context.Background(), 16)).Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(Equal(want))
+}
+
+func TestParserParseReadError(t *testing.T) {
+	g := NewWithT(t)
+	readErr := errors.New("read failed")
+	l := lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(io.MultiReader(strings.NewReader("let x = 1;\nlet y"), iotest.ErrReader(readErr)))),
+	)
+
+	p := parser.New(l)
+	_, err := p.Parse()
+	g.Expect(err).To(MatchError(readErr))
+	// reading stops at the first error, so it's only reported once
+	g.Expect(p.Errors()).To(HaveLen(1))
+	g.Expect(p.Errors()[0]).To(MatchError(ContainSubstring("invalid program at 2:5")))
+}
+
+// ignorePositions makes AST comparisons only check the structure of the tree.
+var ignorePositions = cmpopts.IgnoreTypes(token.Position{})
+
 func letToken() token.Token {
 	return token.Token{
 		Type:    token.Let,
@@ -536,6 +1746,55 @@ func intToken(n int64) token.Token {
 	}
 }
 
+func trueToken() token.Token {
+	return token.Token{
+		Type:    token.True,
+		Literal: "true",
+	}
+}
+
+func falseToken() token.Token {
+	return token.Token{
+		Type:    token.False,
+		Literal: "false",
+	}
+}
+
+func ifToken() token.Token {
+	return token.Token{
+		Type:    token.If,
+		Literal: "if",
+	}
+}
+
+func functionToken() token.Token {
+	return token.Token{
+		Type:    token.Function,
+		Literal: "fn",
+	}
+}
+
+func lParenToken() token.Token {
+	return token.Token{
+		Type:    token.LParen,
+		Literal: "(",
+	}
+}
+
+func lBracketToken() token.Token {
+	return token.Token{
+		Type:    token.LBracket,
+		Literal: "[",
+	}
+}
+
+func lBraceToken() token.Token {
+	return token.Token{
+		Type:    token.LBrace,
+		Literal: "{",
+	}
+}
+
 func bangToken() token.Token {
 	return token.Token{
 		Type:    token.Bang,
@@ -613,6 +1872,31 @@ func literal(value int64) *ast.Literal {
 	}
 }
 
+func parameter(name string, t ast.TypeExpression) *ast.Parameter {
+	return &ast.Parameter{
+		Name: identifier(name),
+		Type: t,
+	}
+}
+
+func namedType(name string) *ast.NamedType {
+	return &ast.NamedType{
+		Token: identifierToken(name),
+		Name:  name,
+	}
+}
+
+func boolean(value bool) *ast.Boolean {
+	t := trueToken()
+	if !value {
+		t = falseToken()
+	}
+	return &ast.Boolean{
+		Token: t,
+		Value: value,
+	}
+}
+
 // castExpression is a helper function to cast a string or an ast.Expression to an ast.Expression.
 // Useful to compose expected ASTs for tests.
 func castExpression(e any) ast.Expression {
@@ -621,6 +1905,8 @@ func castExpression(e any) ast.Expression {
 		return identifier(e)
 	case int:
 		return literal(int64(e))
+	case bool:
+		return boolean(e)
 	case ast.Expression:
 		return e
 	}
@@ -698,3 +1984,71 @@ func notEqual(a, b any) *ast.Infix {
 		Right:    castExpression(b),
 	}
 }
+
+func index(left, i any) *ast.Index {
+	return &ast.Index{
+		Token: lBracketToken(),
+		Left:  castExpression(left),
+		Index: castExpression(i),
+	}
+}
+
+func call(function any, args ...any) *ast.Call {
+	c := &ast.Call{
+		Token:    lParenToken(),
+		Function: castExpression(function),
+	}
+	for _, a := range args {
+		c.Arguments = append(c.Arguments, castExpression(a))
+	}
+	return c
+}
+
+// expressionStatement builds an ast.ExpressionStatement using the
+// token of the leftmost operand of the expression.
+func expressionStatement(e any) *ast.ExpressionStatement {
+	exp := castExpression(e)
+	t := exp
+	for {
+		infix, ok := t.(*ast.Infix)
+		if !ok {
+			break
+		}
+		t = infix.Left
+	}
+
+	return &ast.ExpressionStatement{
+		Token:      tokenOf(t),
+		Expression: exp,
+	}
+}
+
+func tokenOf(e ast.Expression) token.Token {
+	switch e := e.(type) {
+	case *ast.Identifier:
+		return e.Token
+	case *ast.Literal:
+		return e.Token
+	case *ast.Boolean:
+		return e.Token
+	case *ast.Prefix:
+		return e.Token
+	case *ast.If:
+		return e.Token
+	}
+	return token.Token{}
+}
+
+func stringLiteral(literal, value string) *ast.StringLiteral {
+	return &ast.StringLiteral{
+		Token: token.Token{Type: token.String, Literal: literal},
+		Value: value,
+	}
+}
+
+func block(statements ...ast.Statement) *ast.Block {
+	return &ast.Block{
+		Token:      lBraceToken(),
+		Statements: statements,
+	}
+}
@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	perrors "github.com/pkg/errors"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+)
+
+// unquote returns the value of a string literal, replacing its escape
+// sequences: \" \\ \n and \t.
+func unquote(literal string) (string, error) {
+	value, _, err := unescape(literal[1:len(literal)-1], `"`)
+	return value, err
+}
+
+// unescapeText returns the value of the text of a template literal,
+// replacing its escape sequences: \` \$ \\ \n and \t. An unknown escape
+// sequence is reported at its position in the text.
+func unescapeText(t token.Token) (string, error) {
+	value, i, err := unescape(t.Literal, "`$")
+	if err != nil {
+		_, size := utf8.DecodeRuneInString(t.Literal[i+1:])
+		return "", NewError(err, token.Token{
+			Type:    token.TemplateText,
+			Literal: t.Literal[i : i+1+size],
+			Pos:     advance(t.Pos, t.Literal[:i]),
+		})
+	}
+	return value, nil
+}
+
+// unescape replaces the escape sequences of s, which are \\ \n \t and
+// a backslash followed by any of quotes. It returns the offset of the
+// first unknown escape sequence along with the error.
+func unescape(s, quotes string) (string, int, error) {
+	if !strings.Contains(s, `\`) {
+		return s, 0, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		// the lexer never ends a string or a text with an odd number of backslashes
+		i++
+		switch {
+		case s[i] == '\\' || strings.IndexByte(quotes, s[i]) >= 0:
+			b.WriteByte(s[i])
+		case s[i] == 'n':
+			b.WriteByte('\n')
+		case s[i] == 't':
+			b.WriteByte('\t')
+		default:
+			r, _ := utf8.DecodeRuneInString(s[i:])
+			return "", i - 1, perrors.Errorf(`unknown escape sequence \%c`, r)
+		}
+	}
+	return b.String(), 0, nil
+}
+
+// advance returns the position after text, which starts at pos.
+func advance(pos token.Position, text string) token.Position {
+	pos.Offset += len(text)
+	for _, r := range text {
+		if r == '\n' {
+			pos.Line++
+			pos.Column = 1
+		} else {
+			pos.Column++
+		}
+	}
+	return pos
+}
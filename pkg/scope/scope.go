@@ -0,0 +1,297 @@
+// Package scope resolves which declaration each identifier of a program refers to.
+package scope
+
+import (
+	"math"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+)
+
+// Symbol is a name declared by a let statement, a function parameter,
+// the variable of a for loop, the parameter of a catch or a pattern
+// of a match arm.
+type Symbol struct {
+	Name *ast.Identifier
+	// Declaration is the *ast.Let, *ast.Parameter, *ast.For, *ast.Try or *ast.MatchArm
+	// that introduces the symbol.
+	Declaration ast.Node
+	// Function reports whether the symbol is bound to a function literal.
+	Function bool
+	// Exported reports whether the symbol is declared by an export let,
+	// so it can be used by other modules.
+	Exported bool
+	// Uses holds the identifiers referencing the symbol, excluding its declaration.
+	Uses []*ast.Identifier
+	// Redefines is the symbol with the same name previously declared in the same scope, if any.
+	Redefines *Symbol
+	// Start and End are the offsets between which the symbol is visible.
+	Start, End int
+}
+
+// VisibleAt reports whether the symbol can be referenced at the offset.
+func (s *Symbol) VisibleAt(offset int) bool {
+	return s.Start <= offset && offset < s.End
+}
+
+// Info is the result of resolving a program.
+type Info struct {
+	// Symbols holds all the declared symbols in source order.
+	Symbols []*Symbol
+	// Uses maps each identifier, including the declarations themselves, to its symbol.
+	Uses map[*ast.Identifier]*Symbol
+	// Identifiers holds all the identifiers in the program.
+	Identifiers []*ast.Identifier
+}
+
+// VisibleAt returns the innermost symbol for each name visible at the offset.
+func (i *Info) VisibleAt(offset int) map[string]*Symbol {
+	visible := map[string]*Symbol{}
+	for _, sym := range i.Symbols {
+		if !sym.VisibleAt(offset) {
+			continue
+		}
+		// symbols in inner scopes and later declarations start after the outer ones
+		if current, ok := visible[sym.Name.Value]; !ok || current.Start < sym.Start {
+			visible[sym.Name.Value] = sym
+		}
+	}
+	return visible
+}
+
+type scope struct {
+	parent  *scope
+	symbols map[string]*Symbol
+	end     int
+}
+
+func (s *scope) lookup(name string) *Symbol {
+	for ; s != nil; s = s.parent {
+		if sym, ok := s.symbols[name]; ok {
+			return sym
+		}
+	}
+	return nil
+}
+
+type resolver struct {
+	info *Info
+}
+
+// Resolve finds the declaration of each identifier in the program.
+// Identifiers that don't refer to any declaration are not in Info.Uses.
+func Resolve(root *ast.Root) *Info {
+	r := &resolver{
+		info: &Info{Uses: map[*ast.Identifier]*Symbol{}},
+	}
+
+	global := &scope{symbols: map[string]*Symbol{}, end: math.MaxInt}
+	for _, s := range root.Statements {
+		r.statement(global, s)
+	}
+
+	return r.info
+}
+
+func (r *resolver) declare(s *scope, name *ast.Identifier, declaration ast.Node, function bool) {
+	sym := &Symbol{
+		Name:        name,
+		Declaration: declaration,
+		Function:    function,
+		Redefines:   s.symbols[name.Value],
+		Start:       name.Pos().Offset,
+		End:         s.end,
+	}
+	s.symbols[name.Value] = sym
+	r.info.Symbols = append(r.info.Symbols, sym)
+	r.info.Uses[name] = sym
+	r.info.Identifiers = append(r.info.Identifiers, name)
+}
+
+func (r *resolver) statement(s *scope, statement ast.Statement) {
+	switch statement := statement.(type) {
+	case *ast.Let:
+		r.let(s, statement)
+	case *ast.Export:
+		for _, sym := range r.let(s, statement.Let) {
+			sym.Exported = true
+		}
+	case *ast.Return:
+		r.expression(s, statement.Value)
+	case *ast.ExpressionStatement:
+		r.expression(s, statement.Expression)
+	case *ast.Block:
+		r.block(s, statement, nil)
+	case *ast.While:
+		r.expression(s, statement.Condition)
+		r.block(s, statement.Body, nil)
+	case *ast.For:
+		r.expression(s, statement.Iterable)
+		r.bindingBlock(s, statement.Body, statement.Variable, statement)
+	case *ast.Throw:
+		r.expression(s, statement.Value)
+	case *ast.Try:
+		r.block(s, statement.Body, nil)
+		if statement.Catch != nil {
+			r.bindingBlock(s, statement.Catch, statement.Param, statement)
+		}
+		if statement.Finally != nil {
+			r.block(s, statement.Finally, nil)
+		}
+	}
+}
+
+// let resolves a let statement and returns the symbols it declares.
+func (r *resolver) let(s *scope, l *ast.Let) []*Symbol {
+	_, function := l.Value.(*ast.FunctionLiteral)
+	switch {
+	case l.Pattern != nil:
+		r.expression(s, l.Value)
+		r.pattern(s, l.Pattern, l)
+	case function:
+		// functions can call themselves
+		r.declare(s, l.Name, l, true)
+		r.expression(s, l.Value)
+	default:
+		r.expression(s, l.Value)
+		r.declare(s, l.Name, l, false)
+	}
+
+	var symbols []*Symbol
+	for _, name := range l.Names() {
+		sym := r.info.Uses[name]
+		if !function {
+			// the value is evaluated before the names are bound, so they
+			// are only visible after it, not even right at its end
+			sym.Start = ast.End(l.Value) + 1
+		}
+		symbols = append(symbols, sym)
+	}
+	return symbols
+}
+
+// block resolves the statements of a block in a new scope, declaring the given parameters first.
+func (r *resolver) block(parent *scope, b *ast.Block, params []*ast.Parameter) {
+	s := newBlockScope(parent, b)
+	for _, p := range params {
+		r.declare(s, p.Name, p, false)
+	}
+	for _, statement := range b.Statements {
+		r.statement(s, statement)
+	}
+}
+
+// bindingBlock resolves a block where a name is declared by the statement
+// around it, like the body of a for loop with its variable.
+func (r *resolver) bindingBlock(parent *scope, b *ast.Block, name *ast.Identifier, declaration ast.Node) {
+	s := newBlockScope(parent, b)
+	r.declare(s, name, declaration, false)
+	for _, statement := range b.Statements {
+		r.statement(s, statement)
+	}
+}
+
+func newBlockScope(parent *scope, b *ast.Block) *scope {
+	return &scope{
+		parent:  parent,
+		symbols: map[string]*Symbol{},
+		end:     b.End.Offset + 1,
+	}
+}
+
+func (r *resolver) expression(s *scope, e ast.Expression) {
+	switch e := e.(type) {
+	case *ast.Identifier:
+		r.info.Identifiers = append(r.info.Identifiers, e)
+		if sym := s.lookup(e.Value); sym != nil {
+			r.info.Uses[e] = sym
+			sym.Uses = append(sym.Uses, e)
+		}
+	case *ast.TemplateLiteral:
+		for _, part := range e.Parts {
+			r.expression(s, part)
+		}
+	case *ast.Prefix:
+		r.expression(s, e.Right)
+	case *ast.Infix:
+		r.expression(s, e.Left)
+		r.expression(s, e.Right)
+	case *ast.If:
+		r.expression(s, e.Condition)
+		r.block(s, e.Consequence, nil)
+		if e.Alternative != nil {
+			r.block(s, e.Alternative, nil)
+		}
+	case *ast.FunctionLiteral:
+		r.block(s, e.Body, e.Parameters)
+	case *ast.Call:
+		r.expression(s, e.Function)
+		for _, a := range e.Arguments {
+			r.expression(s, a)
+		}
+	case *ast.ArrayLiteral:
+		for _, el := range e.Elements {
+			r.expression(s, el)
+		}
+	case *ast.HashLiteral:
+		for _, pair := range e.Pairs {
+			r.expression(s, pair.Key)
+			r.expression(s, pair.Value)
+		}
+	case *ast.Index:
+		r.expression(s, e.Left)
+		r.expression(s, e.Index)
+	case *ast.Property:
+		// the name is a key of the hash, not a variable
+		r.expression(s, e.Left)
+	case *ast.Assign:
+		// the assigned variable is a use of its declaration
+		r.expression(s, e.Target)
+		r.expression(s, e.Value)
+	case *ast.Match:
+		r.expression(s, e.Subject)
+		for i, arm := range e.Arms {
+			end := e.End.Offset
+			if i+1 < len(e.Arms) {
+				end = e.Arms[i+1].Pos().Offset
+			}
+			r.arm(s, arm, end)
+		}
+	}
+}
+
+// arm resolves a match arm in a new scope that ends at the offset,
+// where the names bound by its pattern are visible to its guard and body.
+func (r *resolver) arm(parent *scope, a *ast.MatchArm, end int) {
+	s := &scope{
+		parent:  parent,
+		symbols: map[string]*Symbol{},
+		end:     end,
+	}
+	r.pattern(s, a.Pattern, a)
+	if a.Guard != nil {
+		r.expression(s, a.Guard)
+	}
+	r.expression(s, a.Body)
+}
+
+// pattern declares the names bound by a pattern, resolving its default values.
+func (r *resolver) pattern(s *scope, p ast.Pattern, declaration ast.Node) {
+	switch p := p.(type) {
+	case *ast.BindingPattern:
+		r.declare(s, p.Name, declaration, false)
+	case *ast.ArrayPattern:
+		for _, e := range p.Elements {
+			r.pattern(s, e, declaration)
+		}
+	case *ast.HashPattern:
+		// the keys are names in the hash, not variables
+		for _, pair := range p.Pairs {
+			r.pattern(s, pair.Value, declaration)
+		}
+	case *ast.RestPattern:
+		r.declare(s, p.Name, declaration, false)
+	case *ast.DefaultPattern:
+		r.expression(s, p.Default)
+		r.pattern(s, p.Pattern, declaration)
+	}
+}
@@ -0,0 +1,204 @@
+package scope_test
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lexer"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/parser"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/scope"
+)
+
+func TestResolve(t *testing.T) {
+	g := NewWithT(t)
+	input := `let x = 1;
+let f = fn(x) { let y = x; f(y) };
+let x = x + z;`
+	root, err := parser.New(lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)).Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	info := scope.Resolve(root)
+
+	var symbols []string
+	for _, s := range info.Symbols {
+		symbols = append(symbols, s.Name.Value+"@"+s.Name.Pos().String())
+	}
+	g.Expect(symbols).To(Equal([]string{"x@1:5", "f@2:5", "x@2:12", "y@2:21", "x@3:5"}))
+
+	globalX, f, param, y, redefinedX := info.Symbols[0], info.Symbols[1], info.Symbols[2], info.Symbols[3], info.Symbols[4]
+	g.Expect(globalX.Uses).To(HaveLen(1))
+	g.Expect(globalX.Uses[0].Pos().String()).To(Equal("3:9"), "the value of a let is resolved before declaring its name")
+	g.Expect(param.Uses).To(HaveLen(1))
+	g.Expect(f.Uses).To(HaveLen(1), "functions can reference themselves")
+	g.Expect(f.Function).To(BeTrue())
+	g.Expect(y.Declaration).To(BeAssignableToTypeOf(&ast.Let{}))
+	g.Expect(param.Declaration).To(BeAssignableToTypeOf(&ast.Parameter{}))
+	g.Expect(redefinedX.Redefines).To(BeIdenticalTo(globalX))
+	g.Expect(param.Redefines).To(BeNil(), "shadowing is not redefining")
+
+	var unresolved []string
+	for _, id := range info.Identifiers {
+		if _, ok := info.Uses[id]; !ok {
+			unresolved = append(unresolved, id.Value)
+		}
+	}
+	g.Expect(unresolved).To(Equal([]string{"z"}))
+
+	// inside the function body
+	visible := info.VisibleAt(strings.Index(input, "f(y)"))
+	g.Expect(visible).To(HaveLen(3))
+	g.Expect(visible["x"]).To(BeIdenticalTo(param))
+	g.Expect(visible["y"]).To(BeIdenticalTo(y))
+	g.Expect(visible["f"]).To(BeIdenticalTo(f))
+
+	// at the end of the value of the redefinition
+	visible = info.VisibleAt(strings.Index(input, "z;") + 1)
+	g.Expect(visible["x"]).To(BeIdenticalTo(globalX), "a let is only visible after its value")
+	g.Expect(visible["f"]).To(BeIdenticalTo(f))
+}
+
+func TestResolveExport(t *testing.T) {
+	g := NewWithT(t)
+	input := `export let inc = fn(x) { x + 1 }; let y = inc(1);`
+	root, err := parser.New(lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)).Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	info := scope.Resolve(root)
+
+	g.Expect(info.Symbols).To(HaveLen(3))
+	inc, y := info.Symbols[0], info.Symbols[2]
+	g.Expect(inc.Name.Value).To(Equal("inc"))
+	g.Expect(inc.Exported).To(BeTrue())
+	g.Expect(inc.Function).To(BeTrue())
+	g.Expect(inc.Uses).To(HaveLen(1))
+	g.Expect(y.Exported).To(BeFalse())
+}
+
+func TestResolveFor(t *testing.T) {
+	g := NewWithT(t)
+	input := `let x = 1; for (x in range(x)) { while (x) { x } }; x`
+	root, err := parser.New(lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)).Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	info := scope.Resolve(root)
+
+	g.Expect(info.Symbols).To(HaveLen(2))
+	global, variable := info.Symbols[0], info.Symbols[1]
+	g.Expect(variable.Declaration).To(BeAssignableToTypeOf(&ast.For{}))
+	g.Expect(global.Uses).To(HaveLen(2), "the iterable is resolved outside of the loop")
+	g.Expect(variable.Uses).To(HaveLen(2))
+	g.Expect(variable.Redefines).To(BeNil())
+}
+
+func TestResolveAssign(t *testing.T) {
+	g := NewWithT(t)
+	input := `let n = 0; let h = {}; let f = fn(k) { n += 1; h[k] = n; }; m = h?.size`
+	root, err := parser.New(lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)).Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	info := scope.Resolve(root)
+
+	g.Expect(info.Symbols).To(HaveLen(4))
+	n, h, k := info.Symbols[0], info.Symbols[1], info.Symbols[3]
+	g.Expect(n.Uses).To(HaveLen(2), "assignments are uses of the variable")
+	g.Expect(h.Uses).To(HaveLen(2))
+	g.Expect(k.Uses).To(HaveLen(1))
+
+	var unresolved []string
+	for _, id := range info.Identifiers {
+		if _, ok := info.Uses[id]; !ok {
+			unresolved = append(unresolved, id.Value)
+		}
+	}
+	g.Expect(unresolved).To(Equal([]string{"m"}), "property names are not variables")
+}
+
+func TestResolveTry(t *testing.T) {
+	g := NewWithT(t)
+	input := `let e = 1; try { throw e; } catch (e) { e } finally { e }`
+	root, err := parser.New(lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)).Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	info := scope.Resolve(root)
+
+	g.Expect(info.Symbols).To(HaveLen(2))
+	global, param := info.Symbols[0], info.Symbols[1]
+	g.Expect(param.Declaration).To(BeAssignableToTypeOf(&ast.Try{}))
+	g.Expect(global.Uses).To(HaveLen(2), "the catch parameter is only visible in the catch block")
+	g.Expect(param.Uses).To(HaveLen(1))
+}
+
+func TestResolveMatch(t *testing.T) {
+	g := NewWithT(t)
+	input := `let x = 1; match (x) { [x, y] if x > y => x, {x, "k": z} => z, _ => x }`
+	root, err := parser.New(lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)).Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	info := scope.Resolve(root)
+
+	g.Expect(info.Symbols).To(HaveLen(5))
+	global, x, y, hashX, z := info.Symbols[0], info.Symbols[1], info.Symbols[2], info.Symbols[3], info.Symbols[4]
+	g.Expect(x.Declaration).To(BeAssignableToTypeOf(&ast.MatchArm{}))
+	g.Expect(global.Uses).To(HaveLen(2), "the names of a pattern are only visible in its arm")
+	g.Expect(x.Uses).To(HaveLen(2))
+	g.Expect(y.Uses).To(HaveLen(1))
+	g.Expect(hashX.Uses).To(BeEmpty())
+	g.Expect(z.Uses).To(HaveLen(1))
+	g.Expect(x.VisibleAt(z.Start)).To(BeFalse())
+}
+
+func TestResolveDestructuring(t *testing.T) {
+	g := NewWithT(t)
+	input := `let n = 1; export let [a, b = n, ...rest] = [n]; let {name, "k": {c}} = {}; a + b + c`
+	root, err := parser.New(lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)).Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	info := scope.Resolve(root)
+
+	var names []string
+	for _, sym := range info.Symbols {
+		names = append(names, sym.Name.Value)
+		if sym.Name.Value != "n" {
+			g.Expect(sym.Declaration).To(BeAssignableToTypeOf(&ast.Let{}))
+		}
+	}
+	g.Expect(names).To(Equal([]string{"n", "a", "b", "rest", "name", "c"}))
+	n, a, rest, c := info.Symbols[0], info.Symbols[1], info.Symbols[3], info.Symbols[5]
+	g.Expect(n.Uses).To(HaveLen(2), "default values are uses")
+	g.Expect(a.Exported).To(BeTrue())
+	g.Expect(rest.Exported).To(BeTrue())
+	g.Expect(c.Exported).To(BeFalse())
+	g.Expect(c.Uses).To(HaveLen(1))
+}
+
+func TestResolveTemplate(t *testing.T) {
+	g := NewWithT(t)
+	input := "let x = 1; `${x} ${`${x}`}`"
+	root, err := parser.New(lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)).Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	info := scope.Resolve(root)
+
+	g.Expect(info.Symbols).To(HaveLen(1))
+	g.Expect(info.Symbols[0].Uses).To(HaveLen(2))
+}
@@ -1,13 +1,26 @@
 package token
 
 var keywords = map[string]Type{
-	"let":    Let,
-	"fn":     Function,
-	"true":   True,
-	"false":  False,
-	"if":     If,
-	"else":   Else,
-	"return": Return,
+	"let":      Let,
+	"fn":       Function,
+	"true":     True,
+	"false":    False,
+	"null":     Null,
+	"if":       If,
+	"else":     Else,
+	"return":   Return,
+	"import":   Import,
+	"export":   Export,
+	"while":    While,
+	"for":      For,
+	"in":       In,
+	"break":    Break,
+	"continue": Continue,
+	"throw":    Throw,
+	"try":      Try,
+	"catch":    Catch,
+	"finally":  Finally,
+	"match":    Match,
 }
 
 func IsKeyword(word string) (Type, bool) {
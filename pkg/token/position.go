@@ -0,0 +1,23 @@
+package token
+
+import "fmt"
+
+// Position identifies a location in the source code.
+// Line and Column are 1-based, Column and Offset count runes and bytes respectively.
+type Position struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// IsValid reports whether the position has been set.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	if !p.IsValid() {
+		return "-"
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
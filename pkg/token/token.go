@@ -1,6 +1,9 @@
 package token
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 type Type int
 
@@ -8,9 +11,38 @@ func (t Type) String() string {
 	return TypeString(t)
 }
 
+// ParseType returns the type with the given name, as returned by String.
+func ParseType(name string) (Type, error) {
+	for i, s := range typeStrings {
+		if s == name {
+			return Type(i), nil
+		}
+	}
+	return Illegal, fmt.Errorf("invalid token type %q", name)
+}
+
+func (t Type) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t *Type) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err != nil {
+		return err
+	}
+
+	parsed, err := ParseType(name)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
 type Token struct {
-	Type    Type
-	Literal string
+	Type    Type     `json:"type"`
+	Literal string   `json:"literal"`
+	Pos     Position `json:"pos"`
 }
 
 func (t Token) String() string {
@@ -20,9 +52,12 @@ func (t Token) String() string {
 const (
 	Illegal Type = iota
 	EOF
+	Comment
 
 	Ident
 	Int
+	String
+	TemplateText
 
 	Assign
 	Plus
@@ -30,27 +65,56 @@ const (
 	Bang
 	Asterisk
 	Slash
+	PlusAssign
+	MinusAssign
+	AsteriskAssign
+	SlashAssign
 
 	Equal
 	NotEqual
 	LowerThan
 	GreaterThan
+	NullCoalesce
 
 	Comma
 	Semicolon
+	Colon
+	Dot
+	Ellipsis
+	QuestionDot
+	QuestionBracket
+	FatArrow
+	Underscore
+	Backtick
+	DollarBrace
 
 	LParen
 	RParen
 	LBrace
 	RBrace
+	LBracket
+	RBracket
 
 	Function
 	Let
 	True
 	False
+	Null
 	If
 	Else
 	Return
+	Import
+	Export
+	While
+	For
+	In
+	Break
+	Continue
+	Throw
+	Try
+	Catch
+	Finally
+	Match
 
 	upperLimit
 )
@@ -60,31 +124,63 @@ const numberOfTypes = upperLimit
 var typeStrings = []string{
 	"ILLEGAL",
 	"EOF",
+	"COMMENT",
 	"IDENT",
 	"INT",
+	"STRING",
+	"TEMPLATE_TEXT",
 	"ASSIGN",
 	"+",
 	"-",
 	"!",
 	"*",
 	"/",
+	"+=",
+	"-=",
+	"*=",
+	"/=",
 	"==",
 	"!=",
 	"<",
 	">",
+	"??",
 	",",
 	";",
+	":",
+	".",
+	"...",
+	"?.",
+	"?[",
+	"=>",
+	"_",
+	"`",
+	"${",
 	"(",
 	")",
 	"{",
 	"}",
+	"[",
+	"]",
 	"FUNCTION",
 	"LET",
 	"TRUE",
 	"FALSE",
+	"NULL",
 	"IF",
 	"ELSE",
 	"RETURN",
+	"IMPORT",
+	"EXPORT",
+	"WHILE",
+	"FOR",
+	"IN",
+	"BREAK",
+	"CONTINUE",
+	"THROW",
+	"TRY",
+	"CATCH",
+	"FINALLY",
+	"MATCH",
 }
 
 func TypeString(t Type) string {
@@ -1,6 +1,7 @@
 package token_test
 
 import (
+	"encoding/json"
 	"math"
 	"testing"
 
@@ -30,6 +31,11 @@ func TestTypeString(t *testing.T) {
 			t:    token.EOF,
 			want: "EOF",
 		},
+		{
+			name: "Comment",
+			t:    token.Comment,
+			want: "COMMENT",
+		},
 		{
 			name: "Ident",
 			t:    token.Ident,
@@ -40,6 +46,11 @@ func TestTypeString(t *testing.T) {
 			t:    token.Int,
 			want: "INT",
 		},
+		{
+			name: "String",
+			t:    token.String,
+			want: "STRING",
+		},
 		{
 			name: "Assign",
 			t:    token.Assign,
@@ -50,6 +61,61 @@ func TestTypeString(t *testing.T) {
 			t:    token.Plus,
 			want: "+",
 		},
+		{
+			name: "PlusAssign",
+			t:    token.PlusAssign,
+			want: "+=",
+		},
+		{
+			name: "QuestionDot",
+			t:    token.QuestionDot,
+			want: "?.",
+		},
+		{
+			name: "Null",
+			t:    token.Null,
+			want: "NULL",
+		},
+		{
+			name: "Finally",
+			t:    token.Finally,
+			want: "FINALLY",
+		},
+		{
+			name: "FatArrow",
+			t:    token.FatArrow,
+			want: "=>",
+		},
+		{
+			name: "Ellipsis",
+			t:    token.Ellipsis,
+			want: "...",
+		},
+		{
+			name: "Match",
+			t:    token.Match,
+			want: "MATCH",
+		},
+		{
+			name: "TemplateText",
+			t:    token.TemplateText,
+			want: "TEMPLATE_TEXT",
+		},
+		{
+			name: "Backtick",
+			t:    token.Backtick,
+			want: "`",
+		},
+		{
+			name: "DollarBrace",
+			t:    token.DollarBrace,
+			want: "${",
+		},
+		{
+			name: "SlashAssign",
+			t:    token.SlashAssign,
+			want: "/=",
+		},
 		{
 			name: "Comma",
 			t:    token.Comma,
@@ -60,6 +126,11 @@ func TestTypeString(t *testing.T) {
 			t:    token.Semicolon,
 			want: ";",
 		},
+		{
+			name: "Colon",
+			t:    token.Colon,
+			want: ":",
+		},
 		{
 			name: "LParen",
 			t:    token.LParen,
@@ -80,6 +151,16 @@ func TestTypeString(t *testing.T) {
 			t:    token.RBrace,
 			want: "}",
 		},
+		{
+			name: "LBracket",
+			t:    token.LBracket,
+			want: "[",
+		},
+		{
+			name: "RBracket",
+			t:    token.RBracket,
+			want: "]",
+		},
 		{
 			name: "Function",
 			t:    token.Function,
@@ -115,6 +196,41 @@ func TestTypeString(t *testing.T) {
 			t:    token.Return,
 			want: "RETURN",
 		},
+		{
+			name: "import",
+			t:    token.Import,
+			want: "IMPORT",
+		},
+		{
+			name: "export",
+			t:    token.Export,
+			want: "EXPORT",
+		},
+		{
+			name: "while",
+			t:    token.While,
+			want: "WHILE",
+		},
+		{
+			name: "for",
+			t:    token.For,
+			want: "FOR",
+		},
+		{
+			name: "in",
+			t:    token.In,
+			want: "IN",
+		},
+		{
+			name: "break",
+			t:    token.Break,
+			want: "BREAK",
+		},
+		{
+			name: "continue",
+			t:    token.Continue,
+			want: "CONTINUE",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -122,3 +238,21 @@ func TestTypeString(t *testing.T) {
 		})
 	}
 }
+
+func TestTokenJSON(t *testing.T) {
+	tok := token.Token{
+		Type:    token.NotEqual,
+		Literal: "!=",
+		Pos:     token.Position{Offset: 4, Line: 2, Column: 3},
+	}
+
+	b, err := json.Marshal(tok)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"type": "!=", "literal": "!=", "pos": {"offset": 4, "line": 2, "column": 3}}`, string(b))
+
+	var got token.Token
+	assert.Nil(t, json.Unmarshal(b, &got))
+	assert.Equal(t, tok, got)
+
+	assert.EqualError(t, json.Unmarshal([]byte(`{"type": "?"}`), &got), `invalid token type "?"`)
+}
@@ -0,0 +1,29 @@
+package token
+
+// TriviaType is the kind of source text that isn't part of any token.
+type TriviaType int
+
+const (
+	// Whitespace is a run of spaces, tabs and newlines.
+	Whitespace TriviaType = iota
+	// LineComment is a comment, without the newline that ends it
+	// or the whitespace before it.
+	LineComment
+)
+
+func (t TriviaType) String() string {
+	switch t {
+	case Whitespace:
+		return "WHITESPACE"
+	case LineComment:
+		return "LINE_COMMENT"
+	}
+	return "UNKNOWN"
+}
+
+// Trivia is source text between tokens, kept to reproduce the source exactly.
+type Trivia struct {
+	Type TriviaType
+	Text string
+	Pos  Position
+}
@@ -0,0 +1,44 @@
+package types
+
+import "sort"
+
+// BuiltinNames returns the sorted names of the default builtins of the
+// evaluator, which are defined in every program the checker checks.
+func BuiltinNames() []string {
+	var names []string
+	for name := range builtinEnv().bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// builtinEnv returns the scope with the types of the default builtins of
+// the evaluator. The ones that take a variable number of arguments have a
+// type variable as type, so any call to them is accepted.
+func builtinEnv() *env {
+	e := newEnv(nil)
+	set := func(name string, t Type, vars ...*Variable) {
+		e.set(name, &scheme{vars: vars, t: t})
+	}
+
+	// the variables are quantified, so they are always replaced by fresh
+	// ones and don't need an id from the checker
+	a, k, v := &Variable{}, &Variable{}, &Variable{}
+	set("len", &Function{Params: []Type{a}, Return: Int}, a)
+	set("puts", a, a)
+	set("first", &Function{Params: []Type{&Array{Element: a}}, Return: a}, a)
+	set("last", &Function{Params: []Type{&Array{Element: a}}, Return: a}, a)
+	set("rest", &Function{Params: []Type{&Array{Element: a}}, Return: &Array{Element: a}}, a)
+	set("push", &Function{Params: []Type{&Array{Element: a}, a}, Return: &Array{Element: a}}, a)
+	set("type", &Function{Params: []Type{a}, Return: String}, a)
+	set("str", &Function{Params: []Type{a}, Return: String}, a)
+	set("int", &Function{Params: []Type{a}, Return: Int}, a)
+	set("keys", &Function{Params: []Type{&Hash{Key: k, Value: v}}, Return: &Array{Element: k}}, k, v)
+	set("values", &Function{Params: []Type{&Hash{Key: k, Value: v}}, Return: &Array{Element: v}}, k, v)
+	set("range", a, a)
+	// error never returns, so it can be used as a value of any type
+	set("error", &Function{Params: []Type{a}, Return: v}, a, v)
+
+	return e
+}
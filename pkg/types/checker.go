@@ -0,0 +1,667 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+)
+
+// Checker infers the types of a program using Hindley-Milner type inference.
+// Monkey is dynamically typed, so the checker is optional: it reports
+// programs that would fail at runtime because of mismatched types.
+type Checker struct {
+	info   *Info
+	errors []Error
+	nextID int
+	// returns is the stack of return types of the functions being checked.
+	returns []Type
+	// imports reports whether the program imports modules, whose exports
+	// are unknown to the checker.
+	imports bool
+	// assigned has the names that are the target of an assignment
+	// anywhere in the program, which are not generalized.
+	assigned map[string]bool
+}
+
+func New() *Checker {
+	return &Checker{}
+}
+
+// Info holds the result of checking a program.
+type Info struct {
+	types map[ast.Node]Type
+}
+
+// TypeOf returns the inferred type of a node or nil if the node wasn't checked.
+// Expressions, let statements and function parameters have a type.
+func (i *Info) TypeOf(n ast.Node) Type {
+	t, ok := i.types[n]
+	if !ok {
+		return nil
+	}
+
+	return resolve(t)
+}
+
+// Check infers the types of all the nodes in the program.
+// It returns the inferred types even if there are type errors.
+// Modules are checked on their own, so in programs with imports the
+// undefined names are assumed to be imported, with any type.
+func (c *Checker) Check(root *ast.Root) (*Info, error) {
+	c.info = &Info{types: map[ast.Node]Type{}}
+	c.errors = nil
+	c.imports = false
+	c.assigned = assignedNames(root)
+	for _, s := range root.Statements {
+		if _, ok := s.(*ast.Import); ok {
+			c.imports = true
+		}
+	}
+
+	e := newEnv(builtinEnv())
+	for _, s := range root.Statements {
+		c.inferStatement(e, s)
+	}
+
+	return c.info, c.error()
+}
+
+func (c *Checker) Errors() []error {
+	if len(c.errors) == 0 {
+		return nil
+	}
+
+	e := make([]error, 0, len(c.errors))
+	for _, err := range c.errors {
+		e = append(e, err)
+	}
+	return e
+}
+
+func (c *Checker) error() error {
+	return errors.Join(c.Errors()...)
+}
+
+func (c *Checker) errorf(n ast.Node, format string, args ...any) {
+	c.errors = append(c.errors, Error{Pos: n.Pos(), Msg: fmt.Sprintf(format, args...)})
+}
+
+func (c *Checker) fresh() *Variable {
+	c.nextID++
+	return &Variable{id: c.nextID}
+}
+
+func (c *Checker) record(n ast.Node, t Type) Type {
+	c.info.types[n] = t
+	return t
+}
+
+// expect unifies the type of a node with the type required by its context,
+// reporting an error at the node if they are not compatible.
+func (c *Checker) expect(want, got Type, n ast.Node, context string) {
+	if !unify(want, got) {
+		c.errorf(n, "cannot use %s as %s in %s", resolve(got), resolve(want), context)
+	}
+}
+
+func (c *Checker) generalize(e *env, t Type) *scheme {
+	inEnv := map[*Variable]struct{}{}
+	e.freeVariables(inEnv)
+	for _, r := range c.returns {
+		freeVariables(r, inEnv)
+	}
+
+	free := map[*Variable]struct{}{}
+	freeVariables(t, free)
+
+	s := &scheme{t: t}
+	for v := range free {
+		if _, ok := inEnv[v]; !ok {
+			s.vars = append(s.vars, v)
+		}
+	}
+	return s
+}
+
+func (c *Checker) instantiate(s *scheme) Type {
+	if len(s.vars) == 0 {
+		return s.t
+	}
+
+	mapping := make(map[*Variable]Type, len(s.vars))
+	for _, v := range s.vars {
+		mapping[v] = c.fresh()
+	}
+	return substitute(s.t, mapping)
+}
+
+func substitute(t Type, mapping map[*Variable]Type) Type {
+	t = prune(t)
+	if v, ok := t.(*Variable); ok {
+		if r, ok := mapping[v]; ok {
+			return r
+		}
+		return v
+	}
+
+	return mapType(t, func(t Type) Type {
+		return substitute(t, mapping)
+	})
+}
+
+// inferStatement checks a statement and returns the type of the value it produces
+// when it's the last one in a block.
+func (c *Checker) inferStatement(e *env, s ast.Statement) Type {
+	switch s := s.(type) {
+	case *ast.ExpressionStatement:
+		return c.record(s, c.infer(e, s.Expression))
+	case *ast.Let:
+		c.inferLet(e, s)
+		// a let doesn't produce a value we can reason about
+		return c.fresh()
+	case *ast.Export:
+		c.inferLet(e, s.Let)
+		return c.fresh()
+	case *ast.Import:
+		c.record(s.Path, String)
+		return c.fresh()
+	case *ast.While:
+		c.expect(Bool, c.infer(e, s.Condition), s.Condition, "while condition")
+		c.record(s.Body, c.inferBlock(e, s.Body))
+		return c.fresh()
+	case *ast.For:
+		c.inferFor(e, s)
+		return c.fresh()
+	case *ast.Throw:
+		// any value can be thrown
+		c.record(s, c.infer(e, s.Value))
+		return c.fresh()
+	case *ast.Try:
+		c.inferTry(e, s)
+		return c.fresh()
+	case *ast.Return:
+		t := c.infer(e, s.Value)
+		c.record(s, t)
+		if len(c.returns) > 0 {
+			c.expect(c.returns[len(c.returns)-1], t, s.Value, "return")
+		}
+		// the statement diverges, so the block can be considered of any type
+		return c.fresh()
+	case *ast.Block:
+		return c.record(s, c.inferBlock(e, s))
+	}
+
+	return c.fresh()
+}
+
+func (c *Checker) inferLet(e *env, l *ast.Let) {
+	var annotation Type
+	if l.Type != nil {
+		annotation = c.fromAnnotation(l.Type)
+	}
+
+	if l.Pattern != nil {
+		t := c.infer(e, l.Value)
+		if annotation != nil {
+			c.expect(annotation, t, l.Value, "assignment")
+			t = annotation
+		}
+		c.record(l, t)
+		// the names of a pattern are not generalized, like the ones of match arms
+		c.expect(c.inferPattern(e, l.Pattern), t, l.Value, "destructuring")
+		return
+	}
+
+	var t Type
+	if _, ok := l.Value.(*ast.FunctionLiteral); ok {
+		// functions can reference themselves, so the name needs to be
+		// in scope while checking the body
+		var self Type = c.fresh()
+		if annotation != nil {
+			self = annotation
+		}
+		e.set(l.Name.Value, &scheme{t: self})
+		t = c.infer(e, l.Value)
+		c.expect(self, t, l.Value, "recursive function "+l.Name.Value)
+		delete(e.bindings, l.Name.Value)
+	} else {
+		t = c.infer(e, l.Value)
+	}
+
+	if annotation != nil {
+		c.expect(annotation, t, l.Value, "assignment to "+l.Name.Value)
+		t = annotation
+	}
+
+	c.record(l, t)
+	c.record(l.Name, t)
+	// only functions that are never reassigned are generalized: the other
+	// values, like an empty array, can be changed to hold a single type
+	if _, ok := l.Value.(*ast.FunctionLiteral); ok && !c.assigned[l.Name.Value] {
+		e.set(l.Name.Value, c.generalize(e, t))
+		return
+	}
+	e.set(l.Name.Value, &scheme{t: t})
+}
+
+// assignedNames returns the names of the variables assigned in a program,
+// directly or through an index or a property of their value. Scopes are
+// ignored, so a name assigned in any of them is assigned in all.
+func assignedNames(root *ast.Root) map[string]bool {
+	names := map[string]bool{}
+	ast.Inspect(root, func(n ast.Node) bool {
+		a, ok := n.(*ast.Assign)
+		if !ok {
+			return true
+		}
+		target := a.Target
+		for {
+			switch t := target.(type) {
+			case *ast.Index:
+				target = t.Left
+				continue
+			case *ast.Property:
+				target = t.Left
+				continue
+			case *ast.Identifier:
+				names[t.Value] = true
+			}
+			break
+		}
+		return true
+	})
+	return names
+}
+
+// inferFor checks a for loop, whose variable takes the elements of an
+// array, the keys of a hash or the characters of a string.
+func (c *Checker) inferFor(e *env, f *ast.For) {
+	var element Type
+	iterable := prune(c.infer(e, f.Iterable))
+	switch t := iterable.(type) {
+	case *Array:
+		element = t.Element
+	case *Hash:
+		element = t.Key
+	case Basic:
+		if t == String {
+			element = String
+		}
+	case *Variable:
+		// it could be any of them
+		element = c.fresh()
+	}
+	if element == nil {
+		c.errorf(f.Iterable, "cannot iterate over %s", resolve(iterable))
+		element = c.fresh()
+	}
+
+	scope := newEnv(e)
+	c.record(f.Variable, element)
+	scope.set(f.Variable.Value, &scheme{t: element})
+	c.record(f.Body, c.inferBlock(scope, f.Body))
+}
+
+func (c *Checker) inferTry(e *env, t *ast.Try) {
+	c.record(t.Body, c.inferBlock(e, t.Body))
+	if t.Catch != nil {
+		scope := newEnv(e)
+		c.record(t.Param, ErrorValue)
+		scope.set(t.Param.Value, &scheme{t: ErrorValue})
+		c.record(t.Catch, c.inferBlock(scope, t.Catch))
+	}
+	if t.Finally != nil {
+		c.record(t.Finally, c.inferBlock(e, t.Finally))
+	}
+}
+
+func (c *Checker) inferBlock(e *env, b *ast.Block) Type {
+	scope := newEnv(e)
+	var t Type = c.fresh()
+	for _, s := range b.Statements {
+		t = c.inferStatement(scope, s)
+	}
+	return t
+}
+
+func (c *Checker) infer(e *env, exp ast.Expression) Type {
+	var t Type
+	switch exp := exp.(type) {
+	case *ast.Literal:
+		t = Int
+	case *ast.Boolean:
+		t = Bool
+	case *ast.StringLiteral, *ast.TemplateText:
+		t = String
+	case *ast.TemplateLiteral:
+		// the interpolated values can be of any type
+		for _, part := range exp.Parts {
+			c.infer(e, part)
+		}
+		t = String
+	case *ast.Null:
+		// null can stand for a value of any type
+		t = c.fresh()
+	case *ast.Identifier:
+		t = c.inferIdentifier(e, exp)
+	case *ast.Prefix:
+		t = c.inferPrefix(e, exp)
+	case *ast.Infix:
+		t = c.inferInfix(e, exp)
+	case *ast.If:
+		t = c.inferIf(e, exp)
+	case *ast.FunctionLiteral:
+		t = c.inferFunction(e, exp)
+	case *ast.Call:
+		t = c.inferCall(e, exp)
+	case *ast.ArrayLiteral:
+		t = c.inferArray(e, exp)
+	case *ast.HashLiteral:
+		t = c.inferHash(e, exp)
+	case *ast.Index:
+		t = c.inferIndex(e, exp)
+	case *ast.Property:
+		t = c.inferProperty(e, exp)
+	case *ast.Assign:
+		t = c.inferAssign(e, exp)
+	case *ast.Match:
+		t = c.inferMatch(e, exp)
+	default:
+		t = c.fresh()
+	}
+
+	return c.record(exp, t)
+}
+
+func (c *Checker) inferIdentifier(e *env, i *ast.Identifier) Type {
+	s, ok := e.get(i.Value)
+	if !ok && c.imports {
+		return c.fresh()
+	}
+	if !ok {
+		c.errorf(i, "undefined: %s", i.Value)
+		return c.fresh()
+	}
+
+	return c.instantiate(s)
+}
+
+func (c *Checker) inferPrefix(e *env, p *ast.Prefix) Type {
+	right := c.infer(e, p.Right)
+	context := fmt.Sprintf("operand of %s", p.Operator)
+	switch p.Operator {
+	case ast.Not:
+		c.expect(Bool, right, p.Right, context)
+		return Bool
+	case ast.Negative:
+		c.expect(Int, right, p.Right, context)
+		return Int
+	}
+
+	return c.fresh()
+}
+
+func (c *Checker) inferInfix(e *env, i *ast.Infix) Type {
+	left := c.infer(e, i.Left)
+	right := c.infer(e, i.Right)
+	context := fmt.Sprintf("operand of %s", i.Operator)
+
+	switch i.Operator {
+	case ast.Addition, ast.Subtraction, ast.Multiplication, ast.Division:
+		c.expect(Int, left, i.Left, context)
+		c.expect(Int, right, i.Right, context)
+		return Int
+	case ast.GreaterThan, ast.LessThan:
+		c.expect(Int, left, i.Left, context)
+		c.expect(Int, right, i.Right, context)
+		return Bool
+	case ast.Equal, ast.NotEqual:
+		c.expect(left, right, i.Right, context)
+		return Bool
+	case ast.NullCoalesce:
+		c.expect(left, right, i.Right, context)
+		return left
+	}
+
+	return c.fresh()
+}
+
+func (c *Checker) inferArray(e *env, a *ast.ArrayLiteral) Type {
+	t := &Array{Element: c.fresh()}
+	for _, el := range a.Elements {
+		c.expect(t.Element, c.infer(e, el), el, "array element")
+	}
+	return t
+}
+
+func (c *Checker) inferHash(e *env, h *ast.HashLiteral) Type {
+	t := &Hash{Key: c.fresh(), Value: c.fresh()}
+	for _, pair := range h.Pairs {
+		c.expect(t.Key, c.infer(e, pair.Key), pair.Key, "hash key")
+		c.expect(t.Value, c.infer(e, pair.Value), pair.Value, "hash value")
+	}
+	return t
+}
+
+func (c *Checker) inferIndex(e *env, i *ast.Index) Type {
+	left := prune(c.infer(e, i.Left))
+	index := c.infer(e, i.Index)
+
+	switch t := left.(type) {
+	case *Array:
+		c.expect(Int, index, i.Index, "array index")
+		return t.Element
+	case *Hash:
+		c.expect(t.Key, index, i.Index, "hash key")
+		return t.Value
+	case *Variable:
+		// it could be an array or a hash
+		return c.fresh()
+	}
+
+	c.errorf(i.Left, "cannot index %s", resolve(left))
+	return c.fresh()
+}
+
+// errorProperties are the types of the properties of errors.
+var errorProperties = map[string]Type{
+	"message": String,
+	"line":    Int,
+	"column":  Int,
+	"stack":   &Array{Element: String},
+}
+
+func (c *Checker) inferProperty(e *env, p *ast.Property) Type {
+	left := prune(c.infer(e, p.Left))
+	if left == ErrorValue {
+		t, ok := errorProperties[p.Name.Value]
+		if !ok {
+			// the value of a thrown value can be anything, like unknown properties
+			t = c.fresh()
+		}
+		return c.record(p.Name, t)
+	}
+
+	value := c.fresh()
+	c.expect(&Hash{Key: String, Value: value}, left, p.Left, "property access")
+	c.record(p.Name, value)
+	return value
+}
+
+func (c *Checker) inferAssign(e *env, a *ast.Assign) Type {
+	target := c.infer(e, a.Target)
+	value := c.infer(e, a.Value)
+
+	if a.Operator == ast.PlainAssign {
+		c.expect(target, value, a.Value, "assignment")
+		return target
+	}
+
+	context := fmt.Sprintf("operand of %s", a.Operator)
+	c.expect(Int, target, a.Target, context)
+	c.expect(Int, value, a.Value, context)
+	return Int
+}
+
+func (c *Checker) inferIf(e *env, i *ast.If) Type {
+	c.expect(Bool, c.infer(e, i.Condition), i.Condition, "if condition")
+
+	consequence := c.record(i.Consequence, c.inferBlock(e, i.Consequence))
+	if i.Alternative == nil {
+		return consequence
+	}
+
+	alternative := c.record(i.Alternative, c.inferBlock(e, i.Alternative))
+	c.expect(consequence, alternative, i.Alternative, "else branch")
+
+	return consequence
+}
+
+func (c *Checker) inferMatch(e *env, m *ast.Match) Type {
+	subject := c.infer(e, m.Subject)
+	var t Type = c.fresh()
+	for _, arm := range m.Arms {
+		scope := newEnv(e)
+		c.expect(subject, c.inferPattern(scope, arm.Pattern), arm.Pattern, "match pattern")
+		if arm.Guard != nil {
+			c.expect(Bool, c.infer(scope, arm.Guard), arm.Guard, "match guard")
+		}
+		body := c.record(arm, c.infer(scope, arm.Body))
+		c.expect(t, body, arm.Body, "match arm")
+	}
+	return t
+}
+
+// inferPattern returns the type of the values matched by a pattern,
+// setting the names it binds in e.
+func (c *Checker) inferPattern(e *env, p ast.Pattern) Type {
+	var t Type
+	switch p := p.(type) {
+	case *ast.BindingPattern:
+		t = c.bind(e, p.Name)
+	case *ast.LiteralPattern:
+		t = c.infer(e, p.Value)
+	case *ast.ArrayPattern:
+		a := &Array{Element: c.fresh()}
+		for _, el := range p.Elements {
+			if rest, ok := el.(*ast.RestPattern); ok {
+				// the rest of the elements are an array of the same type
+				c.expect(a, c.inferPattern(e, rest), rest, "rest of array")
+				continue
+			}
+			c.expect(a.Element, c.inferPattern(e, el), el, "array element")
+		}
+		t = a
+	case *ast.HashPattern:
+		h := &Hash{Key: c.fresh(), Value: c.fresh()}
+		for _, pair := range p.Pairs {
+			switch pair.Key.(type) {
+			case nil, *ast.Identifier:
+				// identifiers and shorthands stand for the string with their name
+				c.expect(h.Key, String, pair, "hash key")
+			default:
+				c.expect(h.Key, c.infer(e, pair.Key), pair.Key, "hash key")
+			}
+			c.expect(h.Value, c.inferPattern(e, pair.Value), pair.Value, "hash value")
+		}
+		t = h
+	case *ast.RestPattern:
+		t = c.bind(e, p.Name)
+	case *ast.DefaultPattern:
+		t = c.inferPattern(e, p.Pattern)
+		c.expect(t, c.infer(e, p.Default), p.Default, "default value")
+	default:
+		t = c.fresh()
+	}
+
+	return c.record(p, t)
+}
+
+// bind sets a name bound by a pattern to a fresh type.
+func (c *Checker) bind(e *env, name *ast.Identifier) Type {
+	t := c.record(name, c.fresh())
+	e.set(name.Value, &scheme{t: t})
+	return t
+}
+
+func (c *Checker) inferFunction(e *env, f *ast.FunctionLiteral) Type {
+	scope := newEnv(e)
+	t := &Function{Return: c.fresh()}
+	if f.ReturnType != nil {
+		t.Return = c.fromAnnotation(f.ReturnType)
+	}
+	for _, p := range f.Parameters {
+		var v Type = c.fresh()
+		if p.Type != nil {
+			v = c.fromAnnotation(p.Type)
+		}
+		t.Params = append(t.Params, v)
+		c.record(p, v)
+		c.record(p.Name, v)
+		scope.set(p.Name.Value, &scheme{t: v})
+	}
+
+	c.returns = append(c.returns, t.Return)
+	body := c.record(f.Body, c.inferBlock(scope, f.Body))
+	c.returns = c.returns[:len(c.returns)-1]
+
+	var last ast.Node = f.Body
+	if len(f.Body.Statements) > 0 {
+		last = f.Body.Statements[len(f.Body.Statements)-1]
+	}
+	c.expect(t.Return, body, last, "function body")
+
+	return t
+}
+
+func (c *Checker) inferCall(e *env, call *ast.Call) Type {
+	callee := prune(c.infer(e, call.Function))
+	args := make([]Type, 0, len(call.Arguments))
+	for _, a := range call.Arguments {
+		args = append(args, c.infer(e, a))
+	}
+
+	switch f := callee.(type) {
+	case *Variable:
+		ret := c.fresh()
+		c.expect(f, &Function{Params: args, Return: ret}, call, "call")
+		return ret
+	case *Function:
+		if len(f.Params) != len(args) {
+			c.errorf(call, "wrong number of arguments in call to %s: expected %d, got %d", f, len(f.Params), len(args))
+			return f.Return
+		}
+		for i := range args {
+			c.expect(f.Params[i], args[i], call.Arguments[i], fmt.Sprintf("argument %d of call", i+1))
+		}
+		return f.Return
+	}
+
+	c.errorf(call, "cannot call non-function %s", callee)
+	return c.fresh()
+}
+
+// fromAnnotation converts a type annotation to a type.
+func (c *Checker) fromAnnotation(t ast.TypeExpression) Type {
+	switch t := t.(type) {
+	case *ast.NamedType:
+		switch Basic(t.Name) {
+		case Int, Bool, String, ErrorValue:
+			return Basic(t.Name)
+		}
+		c.errorf(t, "unknown type %s", t.Name)
+	case *ast.ArrayType:
+		return &Array{Element: c.fromAnnotation(t.Element)}
+	case *ast.HashType:
+		return &Hash{Key: c.fromAnnotation(t.Key), Value: c.fromAnnotation(t.Value)}
+	case *ast.FunctionType:
+		f := &Function{Return: c.fromAnnotation(t.Return)}
+		for _, p := range t.Parameters {
+			f.Params = append(f.Params, c.fromAnnotation(p))
+		}
+		return f
+	}
+
+	return c.fresh()
+}
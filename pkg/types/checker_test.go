@@ -0,0 +1,310 @@
+package types_test
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/ast"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/lexer"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/parser"
+	"github.com/g-gaston/monkey-go-interpreter/pkg/types"
+)
+
+func TestCheckerCheckErrors(t *testing.T) {
+	testCases := []struct {
+		name       string
+		input      string
+		wantErrors []string
+	}{
+		{
+			name:  "well typed program",
+			input: `let a = 5; let b = a * 2 + 1; b > a == true;`,
+		},
+		{
+			name:       "int plus bool",
+			input:      `5 + true`,
+			wantErrors: []string{"type error at 1:5: cannot use bool as int in operand of +"},
+		},
+		{
+			name:       "comparison of different types",
+			input:      `let x = 5; x == true`,
+			wantErrors: []string{"type error at 1:17: cannot use bool as int in operand of =="},
+		},
+		{
+			name:  "prefix operators",
+			input: `-true; !5;`,
+			wantErrors: []string{
+				"type error at 1:2: cannot use bool as int in operand of -",
+				"type error at 1:9: cannot use int as bool in operand of !",
+			},
+		},
+		{
+			name:       "undefined identifier",
+			input:      `let x = y + 1;`,
+			wantErrors: []string{"type error at 1:9: undefined: y"},
+		},
+		{
+			name:  "loops",
+			input: `let n = 0; while (n < 3) { if (n == 1) { break; } continue; } for (i in range(n)) { i + 1 } for (c in "abc") { len(c) }`,
+		},
+		{
+			name:  "loop errors",
+			input: `while (1) { } for (x in 1) { x } for (c in "abc") { -c }`,
+			wantErrors: []string{
+				"type error at 1:8: cannot use int as bool in while condition",
+				"type error at 1:25: cannot iterate over int",
+				"type error at 1:54: cannot use string as int in operand of -",
+			},
+		},
+		{
+			name:  "collections and assignments",
+			input: `let xs = [1, 2]; let h = {"a": xs}; xs[0] += 1; h["b"] = [3]; let n = 0; n = n * 2; len(h["a"]) + xs[1]`,
+		},
+		{
+			name:  "collection errors",
+			input: `[1, true]; {"a": 1, 2: 3}; [1][true]; let n = 1; n[0]`,
+			wantErrors: []string{
+				"type error at 1:5: cannot use bool as int in array element",
+				"type error at 1:21: cannot use int as string in hash key",
+				"type error at 1:32: cannot use bool as int in array index",
+				"type error at 1:50: cannot index int",
+			},
+		},
+		{
+			name:  "assignment errors",
+			input: `let x = 1; x = true; let s = "a"; s += "b";`,
+			wantErrors: []string{
+				"type error at 1:16: cannot use bool as int in assignment",
+				"type error at 1:35: cannot use string as int in operand of +=",
+				"type error at 1:40: cannot use string as int in operand of +=",
+			},
+		},
+		{
+			name:  "null and optional chaining",
+			input: `let p = {"name": "a"}; let n = p?.name ?? null; len(n) + len(p.name); let f = fn(x) { x * 2 }; f?.(1) + [1]?[0]`,
+		},
+		{
+			name:  "optional chaining errors",
+			input: `let p = {"age": 1}; p.age ?? "a"; [1].x`,
+			wantErrors: []string{
+				"type error at 1:30: cannot use string as int in operand of ??",
+				"type error at 1:35: cannot use [int] as {string: t6} in property access",
+			},
+		},
+		{
+			name:  "exceptions",
+			input: `let f = fn(x: int): int { if (x < 0) { throw "negative"; } x }; try { f(1) } catch (e) { len(e.message) + e.line; e.value; len(e.stack) } finally { f(2) }`,
+		},
+		{
+			name:  "exception errors",
+			input: `try { 1 } catch (e) { e + 1; e.message == 1 }`,
+			wantErrors: []string{
+				"type error at 1:23: cannot use error as int in operand of +",
+				"type error at 1:43: cannot use int as string in operand of ==",
+			},
+		},
+		{
+			name:  "match",
+			input: `let describe = fn(x) { match (x) { [] => "empty", [n] if n > 0 => str(n), [_, y] => str(y), _ => "many" } }; len(describe([1])); match ({"a": 1}) { {a, "b": 2} => a, _ => 0 } + 1`,
+		},
+		{
+			name:  "match errors",
+			input: `match (1) { "a" => 1, n if n => 2, [x] => 3, _ => true }`,
+			wantErrors: []string{
+				"type error at 1:13: cannot use string as int in match pattern",
+				"type error at 1:28: cannot use int as bool in match guard",
+				"type error at 1:36: cannot use [t4] as int in match pattern",
+				"type error at 1:51: cannot use bool as int in match arm",
+			},
+		},
+		{
+			name:  "destructuring",
+			input: `let [a, b = 2, ...rest] = [1]; let {name, "age": age = 0}: {string: int} = {"age": 1}; a + b + name + age + len(rest)`,
+		},
+		{
+			name:  "destructuring errors",
+			input: `let [a] = 1; let [b = "x"] = [1]; let [...c] = ["s"]; c + 1; let {d}: {int: int} = {};`,
+			wantErrors: []string{
+				"type error at 1:11: cannot use int as [t2] in destructuring",
+				"type error at 1:30: cannot use [int] as [string] in destructuring",
+				"type error at 1:55: cannot use [string] as int in operand of +",
+				"type error at 1:84: cannot use {int: int} as {string: t16} in destructuring",
+			},
+		},
+		{
+			name:  "templates",
+			input: "let n = 1; let s: string = `${n} ${[true]} ${fn(x) { x }}`; len(s)",
+		},
+		{
+			name:  "template errors",
+			input: "let s = `${1 + true}`; s + 1",
+			wantErrors: []string{
+				"type error at 1:16: cannot use bool as int in operand of +",
+				"type error at 1:24: cannot use string as int in operand of +",
+			},
+		},
+		{
+			name:  "names from imports",
+			input: `import "math"; let x = sqrt(4) + 1;`,
+		},
+		{
+			name:       "exported let",
+			input:      `export let greeting = "hi"; let n: int = greeting;`,
+			wantErrors: []string{"type error at 1:42: cannot use string as int in assignment to n"},
+		},
+		{
+			name:  "builtins",
+			input: `let xs = push(rest(range(1, 10)), 20); len(xs) + first(xs) + int(str(last(xs))); puts(1, true); let len = fn(x) { x }; len(true)`,
+		},
+		{
+			name:       "builtin arguments",
+			input:      `first(1)`,
+			wantErrors: []string{"type error at 1:7: cannot use int as [t1] in argument 1 of call"},
+		},
+		{
+			name:       "if condition",
+			input:      `if (1) { 2 }`,
+			wantErrors: []string{"type error at 1:5: cannot use int as bool in if condition"},
+		},
+		{
+			name:       "if branches",
+			input:      `if (true) { 2 } else { false }`,
+			wantErrors: []string{"type error at 1:22: cannot use bool as int in else branch"},
+		},
+		{
+			name:  "polymorphic let",
+			input: `let id = fn(x) { x }; id(1) + 1; !id(true);`,
+		},
+		{
+			name:  "reassigned let",
+			input: `let x = null; x = true; x + 1; let a = []; a = [true]; a[0] + 1;`,
+			wantErrors: []string{
+				"type error at 1:25: cannot use bool as int in operand of +",
+				"type error at 1:57: cannot use bool as int in operand of +",
+			},
+		},
+		{
+			name:  "mutated let",
+			input: `let a = []; let b = a; b[0] = true; a[0] + 1; let id = fn(x) { x }; id = fn(x) { x + 1 }; id(true);`,
+			wantErrors: []string{
+				"type error at 1:38: cannot use bool as int in operand of +",
+				"type error at 1:94: cannot use bool as int in argument 1 of call",
+			},
+		},
+		{
+			name:       "monomorphic parameters",
+			input:      `fn(f) { f(1); f(true) }`,
+			wantErrors: []string{"type error at 1:17: cannot use bool as int in argument 1 of call"},
+		},
+		{
+			name:       "wrong number of arguments",
+			input:      `let add = fn(a, b) { a + b }; add(1);`,
+			wantErrors: []string{"type error at 1:34: wrong number of arguments in call to fn(int, int): int: expected 2, got 1"},
+		},
+		{
+			name:       "call non function",
+			input:      `let a = 1; a(2);`,
+			wantErrors: []string{"type error at 1:13: cannot call non-function int"},
+		},
+		{
+			name:       "infinite type",
+			input:      `fn(x) { x(x) }`,
+			wantErrors: []string{"type error at 1:10: cannot use fn(t2): t4 as t2 in call"},
+		},
+		{
+			name: "recursive function",
+			input: `let fact = fn(n) { if (n < 2) { return 1; } n * fact(n - 1) };
+fact(5) == true;`,
+			wantErrors: []string{"type error at 2:12: cannot use bool as int in operand of =="},
+		},
+		{
+			name:       "return type",
+			input:      `fn(x) { if (x) { return 1; } false }`,
+			wantErrors: []string{"type error at 1:30: cannot use bool as int in function body"},
+		},
+		{
+			name:  "annotated let",
+			input: `let x: int = true; let y: [int] = x;`,
+			wantErrors: []string{
+				"type error at 1:14: cannot use bool as int in assignment to x",
+				"type error at 1:35: cannot use int as [int] in assignment to y",
+			},
+		},
+		{
+			name:       "unknown type",
+			input:      `let x: float = 1;`,
+			wantErrors: []string{"type error at 1:8: unknown type float"},
+		},
+		{
+			name:       "annotated parameters",
+			input:      `let f = fn(a: bool, b): int { b }; f(1, 2);`,
+			wantErrors: []string{"type error at 1:38: cannot use int as bool in argument 1 of call"},
+		},
+		{
+			name:       "annotated return type",
+			input:      `fn(a: int): bool { a }`,
+			wantErrors: []string{"type error at 1:20: cannot use int as bool in function body"},
+		},
+		{
+			name:       "annotated function type",
+			input:      `let apply: fn(fn(int): int, int): int = fn(f, x) { f(x) }; apply(fn(x) { x == 1 }, 1);`,
+			wantErrors: []string{"type error at 1:66: cannot use fn(int): bool as fn(int): int in argument 1 of call"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			root := parse(g, tc.input)
+
+			c := types.New()
+			_, err := c.Check(root)
+			if len(tc.wantErrors) == 0 {
+				g.Expect(err).NotTo(HaveOccurred())
+				return
+			}
+
+			var got []string
+			for _, e := range c.Errors() {
+				got = append(got, e.Error())
+			}
+			g.Expect(got).To(Equal(tc.wantErrors))
+		})
+	}
+}
+
+func TestInfoTypeOf(t *testing.T) {
+	g := NewWithT(t)
+	root := parse(g, `let add = fn(a, b) { a + b };
+let isZero = fn(n) { n == 0 };
+let apply = fn(f, x) { f(x) };
+apply(isZero, 3);`)
+
+	info, err := types.New().Check(root)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	add := root.Statements[0].(*ast.Let)
+	g.Expect(info.TypeOf(add).String()).To(Equal("fn(int, int): int"))
+	g.Expect(info.TypeOf(add.Value.(*ast.FunctionLiteral).Parameters[0]).String()).To(Equal("int"))
+
+	isZero := root.Statements[1].(*ast.Let)
+	g.Expect(info.TypeOf(isZero.Name).String()).To(Equal("fn(int): bool"))
+
+	apply := root.Statements[2].(*ast.Let)
+	g.Expect(info.TypeOf(apply.Name).String()).To(MatchRegexp(`^fn\(fn\((t\d+)\): (t\d+), (t\d+)\): (t\d+)$`))
+
+	call := root.Statements[3].(*ast.ExpressionStatement)
+	g.Expect(info.TypeOf(call.Expression).String()).To(Equal("bool"))
+	g.Expect(info.TypeOf(call.Expression.(*ast.Call).Function).String()).To(Equal("fn(fn(int): bool, int): bool"))
+}
+
+func parse(g *WithT, input string) *ast.Root {
+	l := lexer.New(
+		lexer.NewRunePeeker(bufio.NewReader(strings.NewReader(input))),
+	)
+	root, err := parser.New(l).Parse()
+	g.Expect(err).NotTo(HaveOccurred())
+	return root
+}
@@ -0,0 +1,44 @@
+package types
+
+// env is a lexical scope mapping names to their types.
+type env struct {
+	parent   *env
+	bindings map[string]*scheme
+}
+
+func newEnv(parent *env) *env {
+	return &env{
+		parent:   parent,
+		bindings: map[string]*scheme{},
+	}
+}
+
+func (e *env) get(name string) (*scheme, bool) {
+	for ; e != nil; e = e.parent {
+		if s, ok := e.bindings[name]; ok {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+func (e *env) set(name string, s *scheme) {
+	e.bindings[name] = s
+}
+
+// freeVariables adds to vars all the unbound variables
+// in the types of the scope and its parents, excluding the quantified ones.
+func (e *env) freeVariables(vars map[*Variable]struct{}) {
+	for ; e != nil; e = e.parent {
+		for _, s := range e.bindings {
+			free := map[*Variable]struct{}{}
+			freeVariables(s.t, free)
+			for _, v := range s.vars {
+				delete(free, v)
+			}
+			for v := range free {
+				vars[v] = struct{}{}
+			}
+		}
+	}
+}
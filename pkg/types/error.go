@@ -0,0 +1,17 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/g-gaston/monkey-go-interpreter/pkg/token"
+)
+
+// Error is a type error found while checking a program.
+type Error struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("type error at %s: %s", e.Pos, e.Msg)
+}
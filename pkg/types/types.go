@@ -0,0 +1,225 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Type is the type of a Monkey expression as inferred by the Checker.
+type Type interface {
+	String() string
+}
+
+// Basic is a type without type parameters, like int or bool.
+type Basic string
+
+const (
+	Int    Basic = "int"
+	Bool   Basic = "bool"
+	String Basic = "string"
+	// ErrorValue is the type of the errors bound by catch.
+	ErrorValue Basic = "error"
+)
+
+var _ Type = Int
+
+func (b Basic) String() string {
+	return string(b)
+}
+
+var _ Type = &Function{}
+
+// Function is the type of a function value.
+type Function struct {
+	Params []Type
+	Return Type
+}
+
+func (f *Function) String() string {
+	params := make([]string, 0, len(f.Params))
+	for _, p := range f.Params {
+		params = append(params, p.String())
+	}
+
+	return fmt.Sprintf("fn(%s): %s", strings.Join(params, ", "), f.Return)
+}
+
+var _ Type = &Array{}
+
+// Array is the type of an array whose elements are all of the same type.
+type Array struct {
+	Element Type
+}
+
+func (a *Array) String() string {
+	return fmt.Sprintf("[%s]", a.Element)
+}
+
+var _ Type = &Hash{}
+
+// Hash is the type of a hash whose keys and values are all of the same type.
+type Hash struct {
+	Key   Type
+	Value Type
+}
+
+func (h *Hash) String() string {
+	return fmt.Sprintf("{%s: %s}", h.Key, h.Value)
+}
+
+var _ Type = &Variable{}
+
+// Variable is a type that hasn't been determined yet.
+// Unification binds it to another type by setting its instance.
+type Variable struct {
+	id       int
+	instance Type
+}
+
+func (v *Variable) String() string {
+	if v.instance != nil {
+		return v.instance.String()
+	}
+
+	return fmt.Sprintf("t%d", v.id)
+}
+
+// scheme is a polymorphic type: a type with a list of
+// quantified variables that are replaced by fresh ones on each use.
+type scheme struct {
+	vars []*Variable
+	t    Type
+}
+
+// prune follows the chain of bound variables until it finds
+// a concrete type or an unbound variable.
+func prune(t Type) Type {
+	if v, ok := t.(*Variable); ok && v.instance != nil {
+		v.instance = prune(v.instance)
+		return v.instance
+	}
+
+	return t
+}
+
+// mapType returns a copy of a composite type with f applied to all its component types.
+// Other types are returned as they are.
+func mapType(t Type, f func(Type) Type) Type {
+	switch t := t.(type) {
+	case *Function:
+		r := &Function{Return: f(t.Return)}
+		for _, p := range t.Params {
+			r.Params = append(r.Params, f(p))
+		}
+		return r
+	case *Array:
+		return &Array{Element: f(t.Element)}
+	case *Hash:
+		return &Hash{Key: f(t.Key), Value: f(t.Value)}
+	}
+
+	return t
+}
+
+// components returns the types a composite type is made of.
+func components(t Type) []Type {
+	switch t := t.(type) {
+	case *Function:
+		return append(append([]Type{}, t.Params...), t.Return)
+	case *Array:
+		return []Type{t.Element}
+	case *Hash:
+		return []Type{t.Key, t.Value}
+	}
+
+	return nil
+}
+
+// resolve returns t with all its bound variables replaced by their instances.
+func resolve(t Type) Type {
+	return mapType(prune(t), resolve)
+}
+
+// occurs reports whether variable v appears inside t.
+func occurs(v *Variable, t Type) bool {
+	t = prune(t)
+	if t == v {
+		return true
+	}
+
+	for _, c := range components(t) {
+		if occurs(v, c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// freeVariables adds to vars all the unbound variables in t.
+func freeVariables(t Type, vars map[*Variable]struct{}) {
+	t = prune(t)
+	if v, ok := t.(*Variable); ok {
+		vars[v] = struct{}{}
+		return
+	}
+
+	for _, c := range components(t) {
+		freeVariables(c, vars)
+	}
+}
+
+// unify makes a and b the same type, binding variables if needed.
+// It returns false if the types are not compatible.
+func unify(a, b Type) bool {
+	a, b = prune(a), prune(b)
+
+	if v, ok := a.(*Variable); ok {
+		if v == b {
+			return true
+		}
+		if occurs(v, b) {
+			return false
+		}
+		v.instance = b
+		return true
+	}
+
+	if _, ok := b.(*Variable); ok {
+		return unify(b, a)
+	}
+
+	if a, ok := a.(Basic); ok {
+		return a == b
+	}
+
+	ac, bc := components(a), components(b)
+	if !sameConstructor(a, b) || len(ac) != len(bc) {
+		return false
+	}
+	for i := range ac {
+		if !unify(ac[i], bc[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sameConstructor reports whether two composite types are of the same kind,
+// regardless of their components.
+func sameConstructor(a, b Type) bool {
+	switch a.(type) {
+	case *Function:
+		_, ok := b.(*Function)
+		return ok
+	case *Array:
+		_, ok := b.(*Array)
+		return ok
+	case *Hash:
+		_, ok := b.(*Hash)
+		return ok
+	}
+
+	return false
+}